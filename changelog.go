@@ -0,0 +1,34 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/pulumi/pulumi-mkschema/mkschema"
+)
+
+// changelogMain implements the `changelog` subcommand, which prints a markdown CHANGELOG section describing the
+// changes between two versions of a schema, for release notes.
+func changelogMain(args []string) {
+	flags := flag.NewFlagSet("changelog", flag.ExitOnError)
+	title := flags.String("title", "", "the section's title (defaults to the new schema's version, if it has one)")
+	flags.Usage = func() {
+		log.Printf("usage: changelog [FLAGS] [OLD-SCHEMA] [NEW-SCHEMA]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		log.Fatalf("error: %v", err)
+	}
+	if flags.NArg() != 2 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	old, new := readSchema(flags.Arg(0)), readSchema(flags.Arg(1))
+	if *title == "" {
+		*title = new.Version
+	}
+	fmt.Print(mkschema.Changelog(old, new, *title))
+}
@@ -0,0 +1,20 @@
+// This tool runs pulumi-mkschema's annotation checks as a standalone vet tool, either directly over a set of
+// packages or under `go vet -vettool=$(which pulumi-mkschema-vet)`.
+package main
+
+import (
+	"flag"
+	"os"
+
+	"golang.org/x/tools/go/analysis/singlechecker"
+
+	"github.com/pulumi/pulumi-mkschema/mkschema"
+)
+
+func main() {
+	// The Pulumi SDK's logging registers global flags, like -v, that collide with the analysis driver's own. None of
+	// them mean anything to a vet tool, so start from a clean slate.
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+
+	singlechecker.Main(mkschema.Analyzer)
+}
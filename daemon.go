@@ -0,0 +1,290 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/token"
+	"io"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+
+	"github.com/pulumi/pulumi-mkschema/mkschema"
+)
+
+// daemonMain implements the `daemon` subcommand, which keeps a package loaded in memory and answers requests about
+// it, one JSON object per line, over stdio or a Unix socket. It's a building block for editor integrations, such as
+// live schema previews, which can't afford to reload the package on every keystroke.
+func daemonMain(args []string) {
+	flags := flag.NewFlagSet("daemon", flag.ExitOnError)
+	options := optionsFlags(flags)
+	socket := flags.String("socket", "", "listen for connections on the given Unix socket, rather than using stdio")
+	flags.Usage = func() {
+		log.Printf("usage: daemon [FLAGS] [PULUMI-PKG-NAME] [GO-SOURCE-PKG]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		log.Fatalf("error: %v", err)
+	}
+	if flags.NArg() != 2 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	d := &daemon{session: mkschema.NewSession(options(flags.Arg(0), flags.Arg(1)))}
+	if *socket == "" {
+		if err := d.serve(ctx, os.Stdin, os.Stdout); err != nil {
+			log.Fatalf("error: %s", err.Error())
+		}
+		return
+	}
+
+	l, err := net.Listen("unix", *socket)
+	if err != nil {
+		log.Fatalf("error: listening: %s", err.Error())
+	}
+	go func() {
+		<-ctx.Done()
+		l.Close()
+	}()
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Fatalf("error: accepting connection: %s", err.Error())
+		}
+		go func() {
+			defer conn.Close()
+			if err := d.serve(ctx, conn, conn); err != nil {
+				log.Printf("warning: %s", err.Error())
+			}
+		}()
+	}
+}
+
+// daemon answers requests about one package, keeping it loaded between them.
+type daemon struct {
+	session *mkschema.Session
+}
+
+// daemonRequest is a request to the daemon. Methods are:
+//
+//   - `regenerate`, whose result is the current schema and its diagnostics;
+//   - `diagnostics`, whose result is just the current diagnostics;
+//   - `explain`, whose result describes the resource, type, or enum named by the `type` parameter, a Go type name or
+//     schema token, and the Go declarations it was gathered from.
+type daemonRequest struct {
+	ID     json.RawMessage   `json:"id,omitempty"`     // echoed in the response, to match it with the request.
+	Method string            `json:"method"`           // the method to call.
+	Params map[string]string `json:"params,omitempty"` // the method's parameters.
+}
+
+// daemonResponse is the response to a request, carrying either its result or an error.
+type daemonResponse struct {
+	ID     json.RawMessage `json:"id,omitempty"`     // the request's ID.
+	Result interface{}     `json:"result,omitempty"` // the method's result.
+	Error  string          `json:"error,omitempty"`  // the error answering the request, if any.
+}
+
+// serve answers the requests read from r, writing a response to w for each, until r is exhausted.
+func (d *daemon) serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	enc := json.NewEncoder(w)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 16*1024*1024)
+	for scanner.Scan() {
+		var req daemonRequest
+		var resp daemonResponse
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			resp.Error = fmt.Sprintf("parsing request: %v", err)
+		} else {
+			resp.ID = req.ID
+			if resp.Result, err = d.handle(ctx, req); err != nil {
+				resp.Error = err.Error()
+			}
+		}
+
+		if err := enc.Encode(resp); err != nil {
+			return errors.Wrapf(err, "writing response")
+		}
+	}
+	return scanner.Err()
+}
+
+// handle answers a single request.
+func (d *daemon) handle(ctx context.Context, req daemonRequest) (interface{}, error) {
+	switch req.Method {
+	case "regenerate":
+		spec, diags, err := d.session.Generate(ctx)
+		if spec == nil {
+			return nil, err
+		}
+		return struct {
+			Schema      *schema.PackageSpec `json:"schema"`
+			Diagnostics []diagnosticJSON    `json:"diagnostics"`
+		}{spec, diagnosticsJSON(diags)}, nil
+	case "diagnostics":
+		_, diags, err := d.session.Model(ctx)
+		var partial *mkschema.PartialError
+		if err != nil && !errors.As(err, &partial) && len(diags) == 0 {
+			return nil, err
+		}
+		return diagnosticsJSON(diags), nil
+	case "explain":
+		m, _, err := d.session.Model(ctx)
+		if m == nil {
+			return nil, err
+		}
+		return explain(m, req.Params["type"])
+	default:
+		return nil, errors.Errorf("unknown method %q", req.Method)
+	}
+}
+
+// diagnosticJSON is a diagnostic as reported by the daemon.
+type diagnosticJSON struct {
+	Severity   string `json:"severity"`             // `error` or `warning`.
+	Position   string `json:"position,omitempty"`   // the position in the Go source, as `FILE:LINE:COLUMN`.
+	Type       string `json:"type,omitempty"`       // the Go type the problem was found in.
+	Field      string `json:"field,omitempty"`      // the Go field the problem was found in.
+	Message    string `json:"message"`              // a description of the problem.
+	Rule       string `json:"rule,omitempty"`       // the rule that was broken, if any.
+	Code       string `json:"code,omitempty"`       // the rule's stable code, such as MKS005, if any.
+	Suggestion string `json:"suggestion,omitempty"` // how the problem might be fixed, if known.
+}
+
+func diagnosticsJSON(diags []mkschema.Diagnostic) []diagnosticJSON {
+	result := make([]diagnosticJSON, len(diags))
+	for i, d := range diags {
+		result[i] = diagnosticJSON{
+			Severity:   d.Severity.String(),
+			Position:   positionJSON(d.Pos),
+			Type:       d.Type,
+			Field:      d.Field,
+			Message:    d.Message,
+			Rule:       d.Rule,
+			Code:       d.Code,
+			Suggestion: d.Suggestion,
+		}
+	}
+	return result
+}
+
+func positionJSON(pos token.Position) string {
+	if !pos.IsValid() {
+		return ""
+	}
+	return pos.String()
+}
+
+// explanation describes a gathered resource, type, or enum, and where each part of it came from.
+type explanation struct {
+	Kind        string                 `json:"kind"`                  // `resource`, `type`, or `enum`.
+	Token       string                 `json:"token"`                 // the schema token.
+	GoType      string                 `json:"goType"`                // the fully qualified Go type name.
+	Position    string                 `json:"position"`              // the position of the Go type declaration.
+	Description string                 `json:"description,omitempty"` // the description.
+	Properties  []explainedProperty    `json:"properties,omitempty"`  // the properties, for resources and types.
+	Values      []explainedEnumValue   `json:"values,omitempty"`      // the values, for enums.
+	Schema      schema.ComplexTypeSpec `json:"schema"`                // the generated schema.
+}
+
+// explainedProperty describes a property and the Go field it came from.
+type explainedProperty struct {
+	Name     string              `json:"name"`              // the schema property name.
+	Field    string              `json:"field"`             // the Go field name.
+	Position string              `json:"position"`          // the position of the Go field declaration.
+	Options  []string            `json:"options,omitempty"` // the field's `pschema` options, such as `optional`.
+	Schema   schema.PropertySpec `json:"schema"`            // the generated property schema.
+}
+
+// explainedEnumValue describes an enum value and the Go constant it came from.
+type explainedEnumValue struct {
+	Name     string      `json:"name"`     // the value's schema name.
+	Const    string      `json:"const"`    // the Go constant name.
+	Position string      `json:"position"` // the position of the Go constant declaration.
+	Value    interface{} `json:"value"`    // the value itself.
+}
+
+// explain describes the resource, type, or enum with the given Go type name or schema token.
+func explain(m *mkschema.Model, name string) (*explanation, error) {
+	if name == "" {
+		return nil, errors.New("missing `type` parameter")
+	}
+	for _, t := range append(append([]*mkschema.Type(nil), m.Resources...), m.Types...) {
+		if t.Token != name && t.Object.Name() != name {
+			continue
+		}
+		e := &explanation{
+			Kind:        "type",
+			Token:       t.Token,
+			GoType:      t.Object.Pkg().Path() + "." + t.Object.Name(),
+			Position:    positionJSON(t.Pos),
+			Description: t.Description,
+			Schema:      schema.ComplexTypeSpec{ObjectTypeSpec: t.ObjectTypeSpec()},
+		}
+		if t.IsResource {
+			e.Kind = "resource"
+		}
+		for _, p := range t.Properties {
+			e.Properties = append(e.Properties, explainedProperty{
+				Name:     p.Name,
+				Field:    p.Field.Name(),
+				Position: positionJSON(p.Pos),
+				Options:  pschemaOptions(p.Options),
+				Schema:   p.Spec,
+			})
+		}
+		return e, nil
+	}
+	for _, enum := range m.Enums {
+		if enum.Token != name && enum.Object.Name() != name {
+			continue
+		}
+		e := &explanation{
+			Kind:        "enum",
+			Token:       enum.Token,
+			GoType:      enum.Object.Pkg().Path() + "." + enum.Object.Name(),
+			Position:    positionJSON(enum.Pos),
+			Description: enum.Description,
+			Schema:      enum.ComplexTypeSpec(),
+		}
+		for _, v := range enum.Values {
+			e.Values = append(e.Values, explainedEnumValue{
+				Name:     v.Name,
+				Const:    v.Const.Name(),
+				Position: positionJSON(v.Pos),
+				Value:    v.Value,
+			})
+		}
+		return e, nil
+	}
+	return nil, errors.Errorf("no resource, type, or enum named %q", name)
+}
+
+// pschemaOptions lists the options set in a property's `pschema` tag, as they're written there.
+func pschemaOptions(opts mkschema.PropertyOptions) []string {
+	var result []string
+	for _, o := range []struct {
+		name string
+		set  bool
+	}{{"optional", opts.Optional}, {"replaces", opts.Replaces}, {"in", opts.In}, {"out", opts.Out}} {
+		if o.set {
+			result = append(result, o.name)
+		}
+	}
+	if opts.Ref != "" {
+		result = append(result, "ref="+opts.Ref)
+	}
+	return result
+}
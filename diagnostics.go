@@ -0,0 +1,210 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/pulumi/pulumi-mkschema/mkschema"
+)
+
+// diagnosticReporter writes diagnostics, either rendered for a terminal or as JSON lines for editors, bots, and CI
+// annotations to consume, and counts them by severity for a summary line.
+type diagnosticReporter struct {
+	json   bool                      // true to write JSON lines, rather than rendered text.
+	w      io.Writer                 // where to write diagnostics.
+	color  bool                      // true to highlight rendered diagnostics with ANSI colors.
+	counts map[mkschema.Severity]int // the number of diagnostics reported, by severity.
+	mu     sync.Mutex                // serializes reports, which may come from concurrent generations.
+
+	cleanup func() // if non-nil, run before exiting on a failed generation, such as to remove a fetched package.
+}
+
+// newDiagnosticReporter creates a reporter writing in the given format, `text` or `json`, to the given file, or to
+// stderr if it's empty. Colors are `always`, `never`, or `auto`, to use them only if writing to a terminal, and
+// NO_COLOR isn't set.
+func newDiagnosticReporter(format, file, color string) (*diagnosticReporter, error) {
+	r := &diagnosticReporter{w: os.Stderr, counts: make(map[mkschema.Severity]int)}
+	switch format {
+	case "text":
+	case "json":
+		r.json = true
+	default:
+		return nil, errors.Errorf("unknown diagnostics format %q; expected `text` or `json`", format)
+	}
+	switch color {
+	case "always":
+		r.color = true
+	case "never":
+	case "auto":
+		r.color = isTerminal(os.Stderr) && os.Getenv("NO_COLOR") == ""
+	default:
+		return nil, errors.Errorf("unknown color mode %q; expected `auto`, `always`, or `never`", color)
+	}
+	if file != "" {
+		f, err := os.Create(file)
+		if err != nil {
+			return nil, errors.Wrapf(err, "creating diagnostics file")
+		}
+		r.w = f
+	}
+	return r, nil
+}
+
+// diagnosticRecord is a diagnostic as written in the JSON format, one per line.
+type diagnosticRecord struct {
+	Severity   string       `json:"severity"`             // `error` or `warning`.
+	Message    string       `json:"message"`              // a description of the problem.
+	File       string       `json:"file,omitempty"`       // the Go source file the problem was found in, if known.
+	Range      *sourceRange `json:"range,omitempty"`      // the offending source within the file, if known.
+	Type       string       `json:"type,omitempty"`       // the Go type the problem was found in.
+	Field      string       `json:"field,omitempty"`      // the Go field the problem was found in.
+	Rule       string       `json:"rule,omitempty"`       // the rule that was broken, if any.
+	Code       string       `json:"code,omitempty"`       // the rule's stable code, such as MKS005, if any.
+	Suggestion string       `json:"suggestion,omitempty"` // how the problem might be fixed, if known.
+	Fixes      []editRecord `json:"fixes,omitempty"`      // edits to the file that fix the problem mechanically.
+}
+
+// editRecord is an edit to a Go source file, replacing the source in its range with its new text.
+type editRecord struct {
+	Range   sourceRange `json:"range"`   // the source to replace.
+	NewText string      `json:"newText"` // the text to replace it with.
+}
+
+// sourceRange is a range of Go source, from its start up to, but not including, its end.
+type sourceRange struct {
+	Start sourcePosition `json:"start"`
+	End   sourcePosition `json:"end"`
+}
+
+// sourcePosition is a 1-based line and column, in bytes, within a file.
+type sourcePosition struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+func newDiagnosticRecord(d mkschema.Diagnostic) diagnosticRecord {
+	record := diagnosticRecord{
+		Severity:   d.Severity.String(),
+		Message:    d.Message,
+		Type:       d.Type,
+		Field:      d.Field,
+		Rule:       d.Rule,
+		Code:       d.Code,
+		Suggestion: d.Suggestion,
+	}
+	for _, e := range d.Fixes {
+		record.Fixes = append(record.Fixes, editRecord{
+			Range: sourceRange{
+				Start: sourcePosition{Line: e.Pos.Line, Column: e.Pos.Column},
+				End:   sourcePosition{Line: e.End.Line, Column: e.End.Column},
+			},
+			NewText: e.NewText,
+		})
+	}
+	if d.Pos.IsValid() {
+		record.File = d.Pos.Filename
+		end := d.End
+		if !end.IsValid() {
+			end = token.Position{Line: d.Pos.Line, Column: d.Pos.Column}
+		}
+		record.Range = &sourceRange{
+			Start: sourcePosition{Line: d.Pos.Line, Column: d.Pos.Column},
+			End:   sourcePosition{Line: end.Line, Column: end.Column},
+		}
+	}
+	return record
+}
+
+// isTerminal returns true if f is a terminal, rather than a file or pipe.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// report writes a diagnostic.
+func (r *diagnosticReporter) report(d mkschema.Diagnostic) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.counts[d.Severity]++
+	if !r.json {
+		if r.color && r.w == io.Writer(os.Stderr) {
+			fmt.Fprintf(r.w, "%s\n\n", d.RenderColor())
+		} else {
+			fmt.Fprintf(r.w, "%s\n\n", d.Render())
+		}
+		return
+	}
+	b, err := json.Marshal(newDiagnosticRecord(d))
+	if err != nil {
+		log.Fatalf("error: serializing diagnostic: %v", err)
+	}
+	fmt.Fprintf(r.w, "%s\n", b)
+}
+
+// count returns the number of diagnostics of the given severity reported so far.
+func (r *diagnosticReporter) count(severity mkschema.Severity) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.counts[severity]
+}
+
+// fatal reports a failed generation and exits. The errors gathered from the package are written as diagnostics, as
+// warnings are, followed by the summary; any other error is written on its own.
+func (r *diagnosticReporter) fatal(err error) {
+	var list *mkschema.ErrorList
+	if errors.As(err, &list) {
+		for _, d := range list.Sorted() {
+			r.report(d)
+		}
+	} else {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", r.style(mkschema.SeverityError.Color(), "error"), err.Error())
+	}
+	r.finish()
+	if r.cleanup != nil {
+		r.cleanup()
+	}
+	os.Exit(1)
+}
+
+// finish writes a summary line counting the diagnostics by severity to stderr, if there were any, and closes the
+// diagnostics file, if there is one.
+func (r *diagnosticReporter) finish() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var counts []string
+	for _, sev := range []mkschema.Severity{mkschema.SeverityError, mkschema.SeverityWarning, mkschema.SeverityNote} {
+		if n := r.counts[sev]; n > 0 {
+			noun := sev.String()
+			if n > 1 {
+				noun += "s"
+			}
+			counts = append(counts, r.style(sev.Color(), fmt.Sprintf("%d %s", n, noun)))
+		}
+	}
+	if len(counts) > 0 {
+		fmt.Fprintf(os.Stderr, "found %s\n", strings.Join(counts, ", "))
+	}
+
+	if f, ok := r.w.(*os.File); ok && f != os.Stderr {
+		if err := f.Close(); err != nil {
+			log.Fatalf("error: writing diagnostics file: %v", err)
+		}
+	}
+}
+
+// style highlights s with the given ANSI SGR parameters, if stderr is colored.
+func (r *diagnosticReporter) style(params, s string) string {
+	if !r.color {
+		return s
+	}
+	return mkschema.Colorize(params, s)
+}
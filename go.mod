@@ -3,9 +3,14 @@ module github.com/pulumi/pulumi-mkschema
 go 1.16
 
 require (
-	github.com/pkg/errors v0.9.1 // indirect
+	github.com/blang/semver v3.5.1+incompatible
+	github.com/golang/protobuf v1.5.2
+	github.com/hashicorp/hcl/v2 v2.3.0
+	github.com/pkg/errors v0.9.1
 	github.com/pulumi/pulumi/pkg v1.14.1 // indirect
-	github.com/pulumi/pulumi/pkg/v3 v3.14.0 // indirect
-	github.com/pulumi/pulumi/sdk/v3 v3.15.0 // indirect
-	golang.org/x/tools v0.1.7 // indirect
+	github.com/pulumi/pulumi/pkg/v3 v3.14.0
+	github.com/pulumi/pulumi/sdk/v3 v3.15.0
+	golang.org/x/tools v0.1.7
+	google.golang.org/grpc v1.37.0
+	gopkg.in/yaml.v2 v2.2.8
 )
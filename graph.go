@@ -0,0 +1,44 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/pulumi/pulumi-mkschema/mkschema"
+)
+
+// graphMain implements the `graph` subcommand, which prints the dependency graph of a schema's resources, functions,
+// and types, in Graphviz's DOT language or as JSON, so that authors can see how they're coupled before publishing.
+func graphMain(args []string) {
+	flags := flag.NewFlagSet("graph", flag.ExitOnError)
+	format := flags.String("format", "dot", "the graph's format: `dot`, for Graphviz, or `json`")
+	flags.Usage = func() {
+		log.Printf("usage: graph [FLAGS] [SCHEMA]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		log.Fatalf("error: %v", err)
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	spec := readSchema(flags.Arg(0))
+	graph := mkschema.SchemaGraph(spec)
+	switch *format {
+	case "dot":
+		fmt.Print(graph.DOT(spec.Name))
+	case "json":
+		b, err := json.MarshalIndent(graph, "", "    ")
+		if err != nil {
+			log.Fatalf("error: %v", err)
+		}
+		fmt.Println(string(b))
+	default:
+		log.Fatalf("error: unknown graph format %q", *format)
+	}
+}
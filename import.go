@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+
+	"github.com/pulumi/pulumi-mkschema/mkschema"
+)
+
+// importMain implements the `import` subcommand, which scaffolds annotated Go structs from an existing schema.
+func importMain(args []string) {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	goPkg := flags.String("package", "", "the name of the Go package to declare (defaults to the schema's name)")
+	out := flags.String("o", "", "write the Go source to the given file, rather than to stdout")
+	flags.Usage = func() {
+		log.Printf("usage: import [FLAGS] [SCHEMA-JSON]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		log.Fatalf("error: %v", err)
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	writeImport(readSchema(flags.Arg(0)), *goPkg, *out)
+}
+
+// writeImport scaffolds the Go source declaring a schema, in the given Go package, which defaults to one named after
+// the schema, and writes it to the given file, or to stdout if it's empty, exiting on failure.
+func writeImport(spec *schema.PackageSpec, goPkg, out string) {
+	if goPkg == "" {
+		goPkg = strings.ToLower(strings.Map(func(r rune) rune {
+			if r == '-' || r == '_' || r == '.' {
+				return -1
+			}
+			return r
+		}, spec.Name))
+	}
+	src, err := mkschema.Import(spec, goPkg)
+	if err != nil {
+		log.Fatalf("error: %v", err)
+	}
+
+	if out == "" {
+		_, err = os.Stdout.Write(src)
+	} else {
+		err = ioutil.WriteFile(out, src, 0644)
+	}
+	if err != nil {
+		log.Fatalf("error: writing Go source: %v", err)
+	}
+}
+
+// readSchema reads and parses a schema from the given path, or from stdin if the path is `-`, exiting on failure.
+func readSchema(path string) *schema.PackageSpec {
+	var b []byte
+	var err error
+	if path == "-" {
+		b, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		b, err = ioutil.ReadFile(path)
+	}
+	if err != nil {
+		log.Fatalf("error: reading schema: %v", err)
+	}
+	var spec schema.PackageSpec
+	if err = json.Unmarshal(b, &spec); err != nil {
+		log.Fatalf("error: parsing schema: %v", err)
+	}
+	return &spec
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+
+	"github.com/pulumi/pulumi-mkschema/mkschema"
+)
+
+// importCRDMain implements the `import-crd` subcommand, which scaffolds annotated Go structs, or a schema, from the
+// OpenAPI v3 schemas of Kubernetes CustomResourceDefinitions, as a start on components wrapping an operator.
+func importCRDMain(args []string) {
+	flags := flag.NewFlagSet("import-crd", flag.ExitOnError)
+	goPkg := flags.String("package", "", "the name of the Go package to declare (defaults to the package's name)")
+	out := flags.String("o", "", "write the Go source, or schema, to the given file, rather than to stdout")
+	name := flags.String("name", "", "the Pulumi package's name (defaults to the first label of the group)")
+	version := flags.String("version", "", "the version of each definition to import (defaults to the stored one)")
+	var kinds listFlag
+	flags.Var(&kinds, "kind", "import the definition of the given `kind` (repeatable; defaults to all)")
+	asSchema := flags.Bool("schema", false, "write the Pulumi schema, rather than Go structs")
+	flags.Usage = func() {
+		log.Printf("usage: import-crd [FLAGS] [CRD-YAML]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		log.Fatalf("error: %v", err)
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	var b []byte
+	var err error
+	if path := flags.Arg(0); path == "-" {
+		b, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		b, err = ioutil.ReadFile(path)
+	}
+	if err != nil {
+		log.Fatalf("error: reading CustomResourceDefinitions: %v", err)
+	}
+	spec, err := mkschema.CRDSchema(b, mkschema.CRDOptions{Name: *name, Version: *version, Kinds: kinds})
+	if err != nil {
+		log.Fatalf("error: %v", err)
+	}
+	if !*asSchema {
+		writeImport(spec, *goPkg, *out)
+		return
+	}
+
+	sch, err := json.MarshalIndent(spec, "", "    ")
+	if err != nil {
+		log.Fatalf("error: %v", err)
+	}
+	if *out == "" {
+		_, err = fmt.Println(string(sch))
+	} else {
+		err = ioutil.WriteFile(*out, append(sch, '\n'), 0600)
+	}
+	if err != nil {
+		log.Fatalf("error: writing schema: %v", err)
+	}
+}
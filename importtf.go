@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"log"
+	"os"
+
+	"github.com/pulumi/pulumi-mkschema/mkschema"
+)
+
+// importTFMain implements the `import-tf` subcommand, which scaffolds annotated Go structs from the resources of a
+// Terraform provider schema, as a start on component wrappers around bridged infrastructure.
+func importTFMain(args []string) {
+	flags := flag.NewFlagSet("import-tf", flag.ExitOnError)
+	goPkg := flags.String("package", "", "the name of the Go package to declare (defaults to the package's name)")
+	out := flags.String("o", "", "write the Go source to the given file, rather than to stdout")
+	provider := flags.String("provider", "", "the provider to import, by source address or type, such as `aws` "+
+		"(needed only if the schema has more than one)")
+	name := flags.String("name", "", "the Pulumi package's name (defaults to the provider's type)")
+	var resources listFlag
+	flags.Var(&resources, "resource", "import the Terraform resources matching the given `pattern`, such as "+
+		"`aws_s3_*` (repeatable; defaults to all)")
+	flags.Usage = func() {
+		log.Printf("usage: import-tf [FLAGS] [TERRAFORM-SCHEMA-JSON]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		log.Fatalf("error: %v", err)
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	var b []byte
+	var err error
+	if path := flags.Arg(0); path == "-" {
+		b, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		b, err = ioutil.ReadFile(path)
+	}
+	if err != nil {
+		log.Fatalf("error: reading Terraform schema: %v", err)
+	}
+	spec, err := mkschema.TerraformSchema(b, mkschema.TerraformOptions{
+		Provider:  *provider,
+		Name:      *name,
+		Resources: resources,
+	})
+	if err != nil {
+		log.Fatalf("error: %v", err)
+	}
+	writeImport(spec, *goPkg, *out)
+}
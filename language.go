@@ -0,0 +1,134 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/pulumi/pulumi-mkschema/mkschema"
+)
+
+// languageFlags registers the flags that set fields of the schema's language sections on fs. It returns a function
+// that applies those that were set, once the flags have been parsed, creating sections as needed.
+func languageFlags(fs *flag.FlagSet) func(languages *mkschema.Languages) {
+	goImportBasePath := fs.String("go-import-base-path", "", "the import path of the Go SDK's root package, "+
+		"such as `github.com/org/mypkg/sdk/go/mypkg`")
+	goContainerTypes := fs.Bool("go-generate-resource-container-types", false,
+		"generate array and map types for resources in the Go SDK")
+	var goInternalDeps listFlag
+	fs.Var(&goInternalDeps, "go-internal-dependency",
+		"a Go package the Go SDK depends on only internally, to keep in its go.mod (repeatable)")
+	nodePackageName := fs.String("nodejs-package-name", "", "the TypeScript SDK's NPM package name, such as "+
+		"`@org/mypkg`")
+	nodeDeps, nodeDevDeps := make(mapFlag), make(mapFlag)
+	fs.Var(nodeDeps, "nodejs-dependency", "a dependency of the TypeScript SDK, as `NAME=VERSION` (repeatable)")
+	fs.Var(nodeDevDeps, "nodejs-dev-dependency",
+		"a development dependency of the TypeScript SDK, as `NAME=VERSION` (repeatable)")
+	nodeRespectVersion := fs.Bool("nodejs-respect-schema-version", false,
+		"version the TypeScript SDK's package by the schema's version")
+	pyPackageName := fs.String("python-package-name", "", "the Python SDK's PyPI package name, such as `pulumi_mypkg`")
+	pyRequires := make(mapFlag)
+	fs.Var(pyRequires, "python-requires", "a dependency of the Python SDK, as `NAME=VERSION` (repeatable)")
+	pyProject := fs.Bool("python-pyproject", false, "generate a pyproject.toml for the Python SDK")
+	pyRespectVersion := fs.Bool("python-respect-schema-version", false,
+		"version the Python SDK's package by the schema's version")
+	csRootNamespace := fs.String("csharp-root-namespace", "", "the namespace the .NET SDK's namespaces are nested in")
+	csPackageRefs, csNamespaces := make(mapFlag), make(mapFlag)
+	fs.Var(csPackageRefs, "csharp-package-reference",
+		"a NuGet package reference of the .NET SDK, as `NAME=VERSION` (repeatable)")
+	fs.Var(csNamespaces, "csharp-namespace",
+		"generate a schema module, or the package itself, into a .NET namespace, as `MODULE=NAMESPACE` (repeatable)")
+	csRespectVersion := fs.Bool("csharp-respect-schema-version", false,
+		"version the .NET SDK's package by the schema's version")
+	javaBasePackage := fs.String("java-base-package", "", "the Java package the Java SDK's packages are nested in")
+	javaBuildFiles := fs.String("java-build-files", "", "the build system to generate the Java SDK's build files for, "+
+		"such as `gradle`")
+	javaDeps := make(mapFlag)
+	fs.Var(javaDeps, "java-dependency", "a dependency of the Java SDK, as `GROUP:ARTIFACT=VERSION` (repeatable)")
+
+	return func(languages *mkschema.Languages) {
+		if *goImportBasePath != "" || *goContainerTypes || len(goInternalDeps) > 0 {
+			if languages.Go == nil {
+				languages.Go = &mkschema.GoLanguage{}
+			}
+			if *goImportBasePath != "" {
+				languages.Go.ImportBasePath = *goImportBasePath
+			}
+			if *goContainerTypes {
+				languages.Go.GenerateResourceContainerTypes = true
+			}
+			if len(goInternalDeps) > 0 {
+				languages.Go.InternalDependencies = goInternalDeps
+			}
+		}
+
+		if *nodePackageName != "" || len(nodeDeps) > 0 || len(nodeDevDeps) > 0 || *nodeRespectVersion {
+			if languages.NodeJS == nil {
+				languages.NodeJS = &mkschema.NodeJSLanguage{}
+			}
+			if *nodePackageName != "" {
+				languages.NodeJS.PackageName = *nodePackageName
+			}
+			languages.NodeJS.Dependencies = mergeStringMap(languages.NodeJS.Dependencies, nodeDeps)
+			languages.NodeJS.DevDependencies = mergeStringMap(languages.NodeJS.DevDependencies, nodeDevDeps)
+			if *nodeRespectVersion {
+				languages.NodeJS.RespectSchemaVersion = true
+			}
+		}
+
+		if *pyPackageName != "" || len(pyRequires) > 0 || *pyProject || *pyRespectVersion {
+			if languages.Python == nil {
+				languages.Python = &mkschema.PythonLanguage{}
+			}
+			if *pyPackageName != "" {
+				languages.Python.PackageName = *pyPackageName
+			}
+			languages.Python.Requires = mergeStringMap(languages.Python.Requires, pyRequires)
+			if *pyProject {
+				languages.Python.PyProject = &mkschema.PythonPyProject{Enabled: true}
+			}
+			if *pyRespectVersion {
+				languages.Python.RespectSchemaVersion = true
+			}
+		}
+
+		if *csRootNamespace != "" || len(csPackageRefs) > 0 || len(csNamespaces) > 0 || *csRespectVersion {
+			if languages.CSharp == nil {
+				languages.CSharp = &mkschema.CSharpLanguage{}
+			}
+			if *csRootNamespace != "" {
+				languages.CSharp.RootNamespace = *csRootNamespace
+			}
+			languages.CSharp.PackageReferences = mergeStringMap(languages.CSharp.PackageReferences, csPackageRefs)
+			languages.CSharp.Namespaces = mergeStringMap(languages.CSharp.Namespaces, csNamespaces)
+			if *csRespectVersion {
+				languages.CSharp.RespectSchemaVersion = true
+			}
+		}
+
+		if *javaBasePackage != "" || *javaBuildFiles != "" || len(javaDeps) > 0 {
+			if languages.Java == nil {
+				languages.Java = &mkschema.JavaLanguage{}
+			}
+			if *javaBasePackage != "" {
+				languages.Java.BasePackage = *javaBasePackage
+			}
+			if *javaBuildFiles != "" {
+				languages.Java.BuildFiles = *javaBuildFiles
+			}
+			languages.Java.Dependencies = mergeStringMap(languages.Java.Dependencies, javaDeps)
+		}
+	}
+}
+
+// mergeStringMap adds the entries of flags to m, overriding any already there, and returns it.
+func mergeStringMap(m map[string]string, flags mapFlag) map[string]string {
+	if len(flags) == 0 {
+		return m
+	}
+	if m == nil {
+		m = make(map[string]string, len(flags))
+	}
+	for k, v := range flags {
+		m[k] = v
+	}
+	return m
+}
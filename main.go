@@ -4,28 +4,827 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+
+	"github.com/pulumi/pulumi-mkschema/mkschema"
+	"github.com/pulumi/pulumi-mkschema/mkschema/emitters"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "import":
+			importMain(os.Args[2:])
+			return
+		case "import-tf":
+			importTFMain(os.Args[2:])
+			return
+		case "import-crd":
+			importCRDMain(os.Args[2:])
+			return
+		case "package":
+			packageMain(os.Args[2:])
+			return
+		case "serve":
+			serveMain(os.Args[2:])
+			return
+		case "changelog":
+			changelogMain(os.Args[2:])
+			return
+		case "daemon":
+			daemonMain(os.Args[2:])
+			return
+		case "verify":
+			verifyMain(os.Args[2:])
+			return
+		case "graph":
+			graphMain(os.Args[2:])
+			return
+		}
+	}
+
+	options := optionsFlags(flag.CommandLine)
+	construct := flag.String("construct", "",
+		"cross-check the schema against the Go package implementing the component's Construct")
+	freeze := flag.String("freeze", "",
+		"fail if a token published in the given token freeze file has disappeared without an alias or removal")
+	updateFreeze := flag.Bool("update-freeze", false,
+		"rewrite the -freeze file to list all tokens in the generated schema")
+	dryRun := flag.Bool("dry-run", false,
+		"load, gather, and validate everything, but emit nothing")
+	fix := flag.Bool("fix", false, "first apply mechanical fixes, such as adding missing property names, to the tags "+
+		"in the Go source package, then generate from the fixed source")
+	var emits listFlag
+	flag.Var(&emits, "emit", "run the named emitter on the generated schema to produce extra artifacts (repeatable)")
+	emitSDKs := flag.String("emit-sdks", "", "emit SDKs into the -out directory's `sdk` subdirectory, for a "+
+		"comma-separated list of languages ("+strings.Join(emitters.SDKLanguages, ", ")+") or `all`")
+	emitExamples := flag.Bool("emit-examples", false, "emit example programs for each resource in each language "+
+		"into the -out directory's `examples` subdirectory")
+	emitOpenAPI := flag.Bool("emit-openapi", false, "emit an OpenAPI 3 document of the package's types, and its "+
+		"resources' and functions' inputs and outputs, into the -out directory's `openapi.json`")
+	emitDocs := flag.Bool("emit-docs", false, "emit registry-style markdown docs into the -out directory's `docs` "+
+		"subdirectory")
+	scaffold := flag.String("scaffold-provider", "", "scaffold a component provider host serving the schema into "+
+		"the given directory, keeping any existing construct.go")
+	framework := flag.String("scaffold-framework", string(mkschema.FrameworkSDK), "the framework to build the "+
+		"-scaffold-provider host on: `sdk` for the Pulumi SDK's component provider support, or `infer` for "+
+		"pulumi-go-provider")
+	convertHelpers := flag.String("convert-helpers", "", "write helpers converting between property maps and the "+
+		"annotated structs to the given Go file, which belongs in the Go source package")
+	enumHelpers := flag.String("enum-helpers", "", "write helpers validating, printing, and parsing the values of "+
+		"the gathered enums to the given Go file, which belongs in the Go source package")
+	tokens := flag.String("tokens", "", "write constants for the schema tokens of the gathered resources, types, and "+
+		"enums to the given Go file, which belongs in the Go source package")
+	patch := flag.String("patch", "", "patch the given schema file in place, regenerating only the types whose files "+
+		"changed since it was last patched, rather than printing the schema; requires -cache-dir")
+	sourceMap := flag.String("source-map", "", "write a source map, from the JSON pointer of each resource, type, "+
+		"property, and enum value in the schema to its Go declaration, to the given JSON file")
+	report := flag.String("report", "", "write a provenance report, recording the Go declaration and the rule "+
+		"behind each resource, type, property, and enum value in the schema, to the given JSON file")
+	pluginJSON := flag.String("plugin-json", "", "write the schema's name, version, and plugin download URL into the "+
+		"given pulumi-plugin.json file, creating it if need be")
+	var variantFlags listFlag
+	flag.Var(&variantFlags, "variant", "generate a variant of the schema from the files selected by build tags, as "+
+		"`NAME=TAGS` with comma-separated tags, into the -out directory's `schema-NAME.json`, rather than printing "+
+		"the schema (repeatable)")
+	minimize := flag.Bool("minimize", false, "write the schema as the smallest JSON that means the same thing, "+
+		"leaving out empty objects and arrays and redundant defaults, for embedding in provider binaries")
+	sourceOrder := flag.Bool("source-order", false, "write the schema's resources and types, and their properties, "+
+		"in the order they're declared in Go, rather than sorted, so that related ones stay together")
+	outDir := flag.String("out", ".", "the directory emitters write their artifacts into")
+	cpuProfile := flag.String("cpuprofile", "", "write a CPU profile to the given file")
+	memProfile := flag.String("memprofile", "",
+		"write a memory profile, taken once the schema is generated, to the given file")
+	diagnosticsFormat := flag.String("diagnostics", "text", "the `FORMAT` to write diagnostics in: `text`, rendered "+
+		"with their source, or `json`, one object per line")
+	diagnosticsFile := flag.String("diagnostics-file", "", "write diagnostics to the given file, rather than stderr")
+	color := flag.String("color", "auto", "highlight diagnostics with colors: `auto`, to do so only on a terminal "+
+		"without NO_COLOR set, `always`, or `never`")
+	failOnWarnings := flag.Bool("fail-on-warnings", false, "fail if there are any warnings, such as for missing "+
+		"descriptions or untagged fields, as for errors; for CI, while leaving local development unblocked")
+	debugResolve := flag.Bool("debug-resolve", false, "trace how each field's Go type was resolved to a schema type, "+
+		"or why it was rejected, to stderr; this bypasses the cache")
+	quietStdout := flag.Bool("quiet-stdout", false,
+		"guarantee that nothing but the schema is written to stdout, redirecting any other output to stderr")
+	flag.Parse()
+
+	// The schema is the only thing this tool writes to stdout, so that it can be piped into other tools, such as
+	// `pulumi package gen-sdk`. Diagnostics always go to stderr, and with -quiet-stdout, so does anything that
+	// emitters' code generators would otherwise print.
+	stdout := os.Stdout
+	if *quietStdout {
+		os.Stdout = os.Stderr
+	}
+
 	// This tool simply takes an array of files to parse. These files must include only Go types of the
 	// expected kinds: resource definitions and annotated struct types. It will issue an error for anything else.
-	if len(os.Args) < 3 {
-		log.Fatalf("error: usage: [PULUMI-PKG-NAME] [GO-SOURCE-PKG]")
+	args := flag.Args()
+	if len(args) < 2 {
+		log.Fatalf("error: usage: [FLAGS] [PULUMI-PKG-NAME] [GO-SOURCE-PKG]")
+	}
+	if *emitSDKs == "all" {
+		*emitSDKs = strings.Join(emitters.SDKLanguages, ",")
+	}
+	if *emitSDKs != "" {
+		for _, lang := range strings.Split(*emitSDKs, ",") {
+			emits = append(emits, "sdk-"+lang)
+		}
+	}
+	if *emitDocs {
+		emits = append(emits, "docs")
+	}
+	if *emitExamples {
+		emits = append(emits, "examples")
+	}
+	if *emitOpenAPI {
+		emits = append(emits, "openapi")
+	}
+	if *dryRun && *updateFreeze {
+		log.Fatalf("error: -dry-run cannot be combined with -update-freeze")
+	} else if *dryRun && *fix {
+		log.Fatalf("error: -dry-run cannot be combined with -fix")
+	}
+	var variants []mkschema.Variant
+	for _, f := range variantFlags {
+		v, err := mkschema.ParseVariant(f)
+		if err != nil {
+			log.Fatalf("error: %s", err.Error())
+		}
+		variants = append(variants, v)
+	}
+	// The sidecars are derived from the gathered model, rather than from the schema alone.
+	sidecars := *convertHelpers != "" || *enumHelpers != "" || *tokens != "" || *sourceMap != "" || *report != "" ||
+		*scaffold != "" || *sourceOrder
+	if len(variants) > 0 && (*construct != "" || *patch != "" || *freeze != "" || *updateFreeze || len(emits) > 0 ||
+		*pluginJSON != "" || sidecars) {
+		log.Fatalf("error: -variant can only be combined with the flags that control how the package is gathered")
+	} else if *patch != "" && sidecars {
+		log.Fatalf("error: -patch gathers only the types that changed, so it cannot be combined with " +
+			"-convert-helpers, -enum-helpers, -tokens, -source-map, -report, -scaffold-provider, or -source-order, " +
+			"which need them all")
 	}
-	sch, err := Generate(os.Args[1], os.Args[2])
+
+	// If requested, profile the run, so that slow cases can be diagnosed.
+	if *cpuProfile != "" {
+		f, err := os.Create(*cpuProfile)
+		if err != nil {
+			log.Fatalf("error: creating CPU profile: %s", err.Error())
+		}
+		defer f.Close()
+		if err = pprof.StartCPUProfile(f); err != nil {
+			log.Fatalf("error: starting CPU profile: %s", err.Error())
+		}
+		defer pprof.StopCPUProfile()
+	}
+
+	// Cancel cleanly on Ctrl-C, so that long runs still report what they'd found so far.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	opts := options(args[0], args[1])
+	if *debugResolve {
+		opts.ResolveTrace, opts.CacheDir = os.Stderr, ""
+	}
+	if *report != "" {
+		opts.Provenance = true
+	}
+	// Report anything skipped in best-effort mode as soon as it's found; errors are reported on exit.
+	reporter, err := newDiagnosticReporter(*diagnosticsFormat, *diagnosticsFile, *color)
 	if err != nil {
 		log.Fatalf("error: %s", err.Error())
 	}
+	defer reporter.finish()
+	opts.Diagnostics = func(d mkschema.Diagnostic) {
+		if d.Severity != mkschema.SeverityError {
+			reporter.report(d)
+		}
+	}
+	if *fix && (opts.Hermetic || len(opts.Files) > 0) {
+		log.Fatalf("error: -fix can't be combined with -hermetic or -file, since it rewrites the package's files")
+	}
+	if mkschema.IsRemote(opts.Package) {
+		// A package at a version is fetched into a scratch directory, which is removed once we're done with it.
+		if opts.Hermetic || len(opts.Files) > 0 {
+			log.Fatalf("error: a package at a version, %s, can't be fetched with -hermetic or -file", opts.Package)
+		}
+		if *fix || *patch != "" {
+			log.Fatalf("error: a package at a version, %s, can't be fixed or patched", opts.Package)
+		}
+		remote, cleanup, err := mkschema.FetchRemote(ctx, opts)
+		if err != nil {
+			reporter.fatal(err)
+		}
+		defer cleanup()
+		opts, reporter.cleanup = remote, cleanup
+	}
 
-	// Now serialize the schema into JSON and print it out.
-	b, err := json.Marshal(sch)
+	// If requested, fix what can be fixed first, so that only the problems needing a person are reported below.
+	if *fix {
+		fixOpts := opts
+		fixOpts.Diagnostics = nil
+		fixed, err := mkschema.Fix(ctx, fixOpts)
+		if err != nil {
+			reporter.fatal(err)
+		}
+		for _, d := range fixed {
+			fmt.Fprintf(os.Stderr, "fixed %s:%d:%d: %s\n", d.Pos.Filename, d.Pos.Line, d.Pos.Column, d.Suggestion)
+		}
+	}
+
+	// Variants are each written to their own file, and nothing else is done with them.
+	if len(variants) > 0 {
+		specs, err := mkschema.GenerateVariants(ctx, opts, variants)
+		var partial *mkschema.PartialError
+		if err != nil && !errors.As(err, &partial) {
+			reporter.fatal(err)
+		}
+		if n := reporter.count(mkschema.SeverityWarning); *failOnWarnings && n > 0 {
+			reporter.fatal(errors.Errorf("failing on %d warning(s), because of -fail-on-warnings", n))
+		}
+		if !*dryRun {
+			if err = writeVariants(specs, *outDir, *minimize); err != nil {
+				log.Fatalf("error: writing schema variants: %s", err.Error())
+			}
+		}
+		return
+	}
+
+	var sch *schema.PackageSpec
+	var model *mkschema.Model
+	if *construct != "" && opts.BestEffort {
+		log.Fatalf("error: -construct cannot be combined with -best-effort")
+	} else if *construct != "" && *patch != "" {
+		log.Fatalf("error: -construct cannot be combined with -patch")
+	} else if *patch != "" {
+		// Patch the schema file, if there is one yet; otherwise, it'll be generated in full.
+		var prior *schema.PackageSpec
+		if _, err = os.Stat(*patch); err == nil {
+			prior = readSchema(*patch)
+		}
+		sch, err = mkschema.Patch(ctx, opts, prior)
+		var partial *mkschema.PartialError
+		if err != nil && !errors.As(err, &partial) {
+			reporter.fatal(err)
+		}
+	} else if *construct != "" {
+		// If an implementation was supplied, generate and then check that the two agree.
+		var mismatches []mkschema.ConstructMismatch
+		sch, model, mismatches, err = mkschema.CheckConstructModel(ctx, opts, *construct)
+		if err != nil {
+			reporter.fatal(err)
+		}
+		if len(mismatches) > 0 {
+			for _, m := range mismatches {
+				fmt.Fprintf(os.Stderr, "error: %s\n", m)
+			}
+			log.Fatalf("error: schema does not match the Construct implementation (%d mismatches)", len(mismatches))
+		}
+	} else {
+		// In best-effort mode, everything that was skipped has been reported, so carry on emitting the schema. The
+		// sidecars are derived from the same run's model, which the cache doesn't keep.
+		if sidecars {
+			sch, model, err = mkschema.GenerateModel(ctx, opts)
+		} else {
+			sch, err = mkschema.Generate(ctx, opts)
+		}
+		var partial *mkschema.PartialError
+		if err != nil && !errors.As(err, &partial) {
+			reporter.fatal(err)
+		}
+	}
+
+	// Warnings, including anything skipped in best-effort mode, have all been reported by now.
+	if n := reporter.count(mkschema.SeverityWarning); *failOnWarnings && n > 0 {
+		reporter.fatal(errors.Errorf("failing on %d warning(s), because of -fail-on-warnings", n))
+	}
+
+	if *memProfile != "" {
+		f, err := os.Create(*memProfile)
+		if err != nil {
+			log.Fatalf("error: creating memory profile: %s", err.Error())
+		}
+		runtime.GC()
+		if err = pprof.WriteHeapProfile(f); err != nil {
+			log.Fatalf("error: writing memory profile: %s", err.Error())
+		}
+		f.Close()
+	}
+
+	// If there is a token freeze file, ensure no published tokens went missing, or refresh it if requested.
+	if *freeze != "" {
+		prior, err := mkschema.ReadTokenFreeze(*freeze)
+		if err != nil && !(os.IsNotExist(err) && *updateFreeze) {
+			log.Fatalf("error: reading token freeze file: %s", err.Error())
+		}
+		if prior != nil {
+			prior.ApplyAliases(sch)
+			if !*updateFreeze {
+				if violations := prior.Check(sch); len(violations) > 0 {
+					for _, v := range violations {
+						fmt.Fprintf(os.Stderr, "error: %s: %s\n", *freeze, v)
+					}
+					log.Fatalf("error: schema removes %d published token(s)", len(violations))
+				}
+			}
+		}
+		if *updateFreeze {
+			if err = mkschema.WriteTokenFreeze(*freeze, sch, prior); err != nil {
+				log.Fatalf("error: writing token freeze file: %s", err.Error())
+			}
+		}
+	} else if *updateFreeze {
+		log.Fatalf("error: -update-freeze requires a -freeze file")
+	}
+
+	// Now serialize the schema into JSON and print it out, unless this is just a check.
+	if *dryRun {
+		return
+	}
+	if len(emits) > 0 {
+		if err = mkschema.Emit(ctx, sch, emits, *outDir); err != nil {
+			log.Fatalf("error: %s", err.Error())
+		}
+	}
+	if *convertHelpers != "" {
+		if err = writeConversionHelpers(model, *convertHelpers); err != nil {
+			log.Fatalf("error: generating conversion helpers: %s", err.Error())
+		}
+	}
+	if *enumHelpers != "" {
+		if err = writeEnumHelpers(model, *enumHelpers); err != nil {
+			log.Fatalf("error: generating enum helpers: %s", err.Error())
+		}
+	}
+	if *tokens != "" {
+		if err = writeTokenConstants(model, *tokens); err != nil {
+			log.Fatalf("error: generating token constants: %s", err.Error())
+		}
+	}
+	if *sourceMap != "" {
+		if err = writeSourceMap(model, *sourceMap); err != nil {
+			log.Fatalf("error: generating source map: %s", err.Error())
+		}
+	}
+	if *report != "" {
+		if err = writeProvenance(model, *report); err != nil {
+			log.Fatalf("error: generating provenance report: %s", err.Error())
+		}
+	}
+	if *pluginJSON != "" {
+		if err = mkschema.StampPluginJSON(*pluginJSON, sch); err != nil {
+			log.Fatalf("error: writing plugin description: %s", err.Error())
+		}
+	}
+	if *scaffold != "" {
+		if err = scaffoldProvider(model, sch, *scaffold, mkschema.ProviderFramework(*framework)); err != nil {
+			log.Fatalf("error: scaffolding provider: %s", err.Error())
+		}
+	}
+	b, err := marshalSchema(sch, *minimize)
 	if err != nil {
 		log.Fatalf("error: serializing schema to JSON: %s", err.Error())
 	}
+	if *sourceOrder {
+		if b, err = reorderSchema(model, b); err != nil {
+			log.Fatalf("error: serializing schema to JSON: %s", err.Error())
+		}
+	}
+
+	if *patch != "" {
+		if err = ioutil.WriteFile(*patch, append(b, '\n'), 0644); err != nil {
+			log.Fatalf("error: writing schema: %s", err.Error())
+		}
+		return
+	}
+	if _, err = fmt.Fprintf(stdout, "%s\n", string(b)); err != nil {
+		log.Fatalf("error: writing schema: %s", err.Error())
+	}
+}
+
+// marshalSchema serializes a schema to JSON, as compactly as possible if minimize is true.
+func marshalSchema(spec *schema.PackageSpec, minimize bool) ([]byte, error) {
+	if minimize {
+		return mkschema.MarshalMinimal(spec)
+	}
+	return json.Marshal(spec)
+}
+
+// reorderSchema rewrites a schema's JSON so that its elements are in the order they're declared in Go.
+func reorderSchema(m *mkschema.Model, b []byte) ([]byte, error) {
+	return m.SourceOrder().Reorder(b)
+}
+
+// writeVariants writes each variant's schema into dir, as `schema-NAME.json`, and prints the paths written.
+func writeVariants(specs map[string]*schema.PackageSpec, dir string, minimize bool) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	names := make([]string, 0, len(specs))
+	for name := range specs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		b, err := marshalSchema(specs[name], minimize)
+		if err != nil {
+			return errors.Wrapf(err, "serializing variant %s to JSON", name)
+		}
+		path := filepath.Join(dir, "schema-"+name+".json")
+		if err = ioutil.WriteFile(path, append(b, '\n'), 0600); err != nil {
+			return err
+		}
+		fmt.Fprintln(os.Stderr, path)
+	}
+	return nil
+}
+
+// scaffoldProvider writes a component provider host serving the schema into dir. The stub constructors in
+// construct.go are the component author's to fill in, so that file is only written if it doesn't exist yet.
+func scaffoldProvider(m *mkschema.Model, sch *schema.PackageSpec, dir string,
+	framework mkschema.ProviderFramework) error {
+	scaffold, err := m.ScaffoldProvider(sch, framework)
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(filepath.Join(dir, "main.go"), scaffold.Main, 0644); err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(filepath.Join(dir, "schema.json"), scaffold.Schema, 0644); err != nil {
+		return err
+	}
+	construct := filepath.Join(dir, "construct.go")
+	if _, err = os.Stat(construct); os.IsNotExist(err) {
+		return ioutil.WriteFile(construct, scaffold.Construct, 0644)
+	}
+	return err
+}
+
+// writeConversionHelpers writes the property map conversion helpers for the gathered structs to file.
+func writeConversionHelpers(m *mkschema.Model, file string) error {
+	src, err := m.ConversionHelpers()
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, src, 0644)
+}
+
+// writeTokenConstants writes constants for the schema tokens of the gathered resources, types, and enums to file.
+func writeTokenConstants(m *mkschema.Model, file string) error {
+	src, err := m.TokenConstants()
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, src, 0644)
+}
+
+// writeSourceMap writes the source map of the gathered model to file, with paths relative to its directory.
+func writeSourceMap(m *mkschema.Model, file string) error {
+	base, err := filepath.Abs(filepath.Dir(file))
+	if err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(m.SourceMap(base), "", "    ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, append(b, '\n'), 0600)
+}
+
+// writeProvenance writes the provenance of the gathered model's elements to file.
+func writeProvenance(m *mkschema.Model, file string) error {
+	b, err := json.MarshalIndent(m.Provenance(), "", "    ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, append(b, '\n'), 0600)
+}
+
+// writeEnumHelpers writes helpers for the gathered enums to file.
+func writeEnumHelpers(m *mkschema.Model, file string) error {
+	src, err := m.EnumHelpers()
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, src, 0644)
+}
+
+// overrideMetadata overrides each field of meta that's set in override.
+func overrideMetadata(meta *mkschema.Metadata, override mkschema.Metadata) {
+	for _, f := range []struct {
+		field    *string
+		override string
+	}{{&meta.Version, override.Version}, {&meta.Description, override.Description}, {&meta.License, override.License},
+		{&meta.Repository, override.Repository}, {&meta.Homepage, override.Homepage}, {&meta.LogoURL, override.LogoURL},
+		{&meta.PluginDownloadURL, override.PluginDownloadURL}} {
+		if f.override != "" {
+			*f.field = f.override
+		}
+	}
+	if len(override.Keywords) > 0 {
+		meta.Keywords = override.Keywords
+	}
+	if override.ModuleFormat != "" {
+		meta.ModuleFormat = override.ModuleFormat
+	}
+	meta.Suppress = append(meta.Suppress, override.Suppress...)
+	meta.CustomResources = meta.CustomResources || override.CustomResources
+	if override.Language.Go != nil {
+		meta.Language.Go = override.Language.Go
+	}
+	if override.Language.NodeJS != nil {
+		meta.Language.NodeJS = override.Language.NodeJS
+	}
+	if override.Language.Python != nil {
+		meta.Language.Python = override.Language.Python
+	}
+	if override.Language.CSharp != nil {
+		meta.Language.CSharp = override.Language.CSharp
+	}
+	if override.Language.Java != nil {
+		meta.Language.Java = override.Language.Java
+	}
+}
+
+// optionsFlags registers the flags that control how a package is gathered on fs. It returns a function that builds the
+// options for generating a Pulumi package from a Go package, once the flags have been parsed.
+func optionsFlags(fs *flag.FlagSet) func(name, pkg string) mkschema.Options {
+	bestEffort := fs.Bool("best-effort", false,
+		"emit a schema for everything that can be processed, reporting what couldn't, rather than failing")
+	includePreview := fs.Bool("include-preview", false, "include the resources and types marked "+
+		"`//pschema:preview`, noting in their descriptions that they're experimental")
+	skipGenerated := fs.Bool("skip-generated", false, "leave the types declared in generated Go files, which start "+
+		"with a `// Code generated ... DO NOT EDIT.` comment, out of the schema")
+	prune := fs.Bool("prune-unreachable", false, "leave the types and enums that no resource refers to, directly "+
+		"or through other types, out of the schema, noting each one")
+	only := fs.String("only", "", "keep only the resources with the given comma-separated tokens, such as "+
+		"`mypkg:index:Cluster,mypkg:index:NodeGroup`, and the types they refer to, directly or through other types")
+	strict := fs.Bool("strict", false,
+		"reject exported fields of gathered structs that lack a `pulumi` tag")
+	nameTags := fs.String("name-tags", "", "name the properties of fields without a `pulumi` tag by the first of "+
+		"the given comma-separated tags they have, in order, such as `protobuf,yaml,json`")
+	autoSecret := fs.Bool("auto-secret", false, "mark the properties named like secrets, such as `dbPassword` or "+
+		"`apiKey`, `secret`, noting each one, rather than warning that they aren't")
+	modules := make(mapFlag)
+	fs.Var(modules, "module", "map a Go package path to a schema module, as `GO-PKG=MODULE` (repeatable)")
+	modulePolicy := fs.String("module-policy", string(mkschema.ModulesIndex), "how to pick the modules of Go "+
+		"packages that `-module` doesn't map: `index`, `versioned` to put versioned packages in versioned modules, or "+
+		"`path` to name modules after packages' paths, such as `compute/instance`")
+	mappings := make(mapFlag)
+	fs.Var(mappings, "mapping", "map a Go type to an external schema type reference, as `GO-TYPE=REF` (repeatable)")
+	resolveRefs := fs.Bool("resolve-refs", false, "check that references to other packages' types and resources "+
+		"resolve, against the schemas of their provider plugins in the plugin cache, or at their URLs")
+	dir := fs.String("dir", "", "resolve Go packages from the given directory, within its module or go.work workspace")
+	mod := fs.String("mod", "", "the go command's module download mode, such as `vendor` to use the vendor directory")
+	hermetic := fs.Bool("hermetic", false, "keep the go command off the network, and away from the user's go "+
+		"environment and workspace, so that generation can run as a sandboxed build action")
+	var files listFlag
+	fs.Var(&files, "file", "generate from the given `FILE` of the package, rather than from all of the files the go "+
+		"command finds; tests are searched for examples, and markdown files are resources' docs (repeatable)")
+	goCache := fs.String("gocache", "", "the go command's build cache directory, in place of its default")
+	goModCache := fs.String("gomodcache", "", "the go command's module cache directory, in place of its default")
+	cacheDir := fs.String("cache-dir", "",
+		"cache generated schemas in the given directory, regenerating only when the package's files change")
+	annotations := fs.String("annotations", "", "attach schema options to Go types and fields, by fully qualified "+
+		"name, from the given YAML or JSON file, for declarations that can't carry tags")
+	renames := fs.String("renames", "", "rename tokens by the `<old-token> <new-token>` lines of the given file, "+
+		"aliasing each renamed resource to its old token")
+	config := fs.String("config", "", "read the package's metadata from the given YAML or JSON file, whose keys are "+
+		"the schema's, such as `version`, `license`, and `language`; flags take precedence")
+	project := fs.String("from-project", "", "seed the package's metadata from the Pulumi.yaml or PulumiPlugin.yaml "+
+		"file at, or in the directory at, the given path; -config and flags take precedence")
+	version := fs.String("version", "", "the package's version")
+	versionFrom := fs.String("version-from", "", "take the package's version from the given `SOURCE`: `git`, to "+
+		"describe the git checkout containing -dir, or the current directory; -version takes precedence")
+	description := fs.String("description", "", "the package's description")
+	license := fs.String("license", "", "the package's license, such as `Apache-2.0`")
+	repository := fs.String("repository", "", "the URL of the package's source repository")
+	homepage := fs.String("homepage", "", "the URL of the package's homepage")
+	logoURL := fs.String("logo-url", "", "the URL of the package's logo")
+	pluginDownloadURL := fs.String("plugin-download-url", "", "the URL of the server to download the provider "+
+		"plugin from, such as `github://api.github.com/org/mypkg`")
+	var keywords listFlag
+	fs.Var(&keywords, "keyword", "a keyword to help find the package in registries (repeatable)")
+	moduleFormat := fs.String("module-format", "", "a regex whose first capturing group extracts the module name "+
+		"from the module part of a token, emitted as the schema's `meta.moduleFormat`")
+	var suppress listFlag
+	fs.Var(&suppress, "suppress", "suppress the diagnostics with the given `CODE`, such as MKS010, throughout the "+
+		"package (repeatable)")
+	languages := languageFlags(fs)
+	var sets listFlag
+	fs.Var(&sets, "set", "override a field of the generated schema, as `PATH=VALUE`, where the path is its JSON keys "+
+		"separated by dots and the value is JSON or a string (repeatable)")
+	customResources := fs.Bool("custom-resources", false, "emit resources as custom resources, rather than "+
+		"components, unless they're marked `//pschema:component`")
+	descriptionDedent := fs.Bool("description-dedent", false, "remove the indentation that all lines of a code "+
+		"block in a description share")
+	descriptionWrap := fs.Int("description-wrap", 0, "wrap the paragraphs and list items of descriptions at the "+
+		"given number of columns, rather than joining each onto one line")
+	descriptionTrim := fs.Bool("description-trim", false, "remove whitespace from the ends of descriptions' lines")
+	var descriptionDrop listFlag
+	fs.Var(&descriptionDrop, "description-drop", "leave doc comment lines matching the given `REGEX`, such as "+
+		"`^nolint\\b` for linter directives, out of descriptions (repeatable)")
+	convertExamples := fs.String("convert-examples", "", "convert the examples in resources' descriptions into "+
+		"each -example-languages language they're missing with the given `CONVERTER`: `pulumi`, to convert YAML "+
+		"examples with `pulumi convert`, or a command, which is passed the languages to convert from and to, and "+
+		"the example on its stdin")
+	languageDescriptions := fs.String("language-descriptions", "", "merge language-specific descriptions of the "+
+		"package and its resources into their `language` sections from the given YAML or JSON file")
+	exampleLanguages := fs.String("example-languages", strings.Join(mkschema.ExampleLanguages, ","),
+		"the comma-separated languages, in order, that -convert-examples converts examples into")
+
+	return func(name, pkg string) mkschema.Options {
+		var buildFlags []string
+		if *mod != "" {
+			buildFlags = append(buildFlags, "-mod="+*mod)
+		}
+		var env []string
+		for _, v := range []struct{ key, dir string }{{"GOCACHE", *goCache}, {"GOMODCACHE", *goModCache}} {
+			if v.dir != "" {
+				// The go command requires these to be absolute.
+				abs, err := filepath.Abs(v.dir)
+				if err != nil {
+					log.Fatalf("error: %s", err.Error())
+				}
+				env = append(env, v.key+"="+abs)
+			}
+		}
+
+		// Metadata flags override whatever's in the config file, which overrides the project file, field by field.
+		// Language flags override individual fields of the config file's language sections.
+		var metadata mkschema.Metadata
+		if *project != "" {
+			meta, err := mkschema.ReadProjectMetadata(*project)
+			if err != nil {
+				log.Fatalf("error: %s", err.Error())
+			}
+			overrideMetadata(&metadata, meta)
+		}
+		if *config != "" {
+			meta, err := mkschema.ReadMetadata(*config)
+			if err != nil {
+				log.Fatalf("error: %s", err.Error())
+			}
+			overrideMetadata(&metadata, meta)
+		}
+		var annots mkschema.Annotations
+		if *annotations != "" {
+			a, err := mkschema.ReadAnnotations(*annotations)
+			if err != nil {
+				log.Fatalf("error: %s", err.Error())
+			}
+			annots = a
+		}
+		var renamed map[string]string
+		if *renames != "" {
+			r, err := mkschema.ReadRenames(*renames)
+			if err != nil {
+				log.Fatalf("error: %s", err.Error())
+			}
+			renamed = r
+		}
+		switch *versionFrom {
+		case "":
+		case "git":
+			if *hermetic {
+				log.Fatalf("error: -version-from git can't be used with -hermetic, which reads only its inputs")
+			}
+			v, err := mkschema.GitVersion(context.Background(), *dir)
+			if err != nil {
+				log.Fatalf("error: %s", err.Error())
+			}
+			metadata.Version = v
+		default:
+			log.Fatalf("error: unknown -version-from source %q", *versionFrom)
+		}
+		overrideMetadata(&metadata, mkschema.Metadata{
+			Version:           *version,
+			Description:       *description,
+			License:           *license,
+			Repository:        *repository,
+			Homepage:          *homepage,
+			LogoURL:           *logoURL,
+			PluginDownloadURL: *pluginDownloadURL,
+			Keywords:          keywords,
+			ModuleFormat:      *moduleFormat,
+			Suppress:          suppress,
+			CustomResources:   *customResources,
+		})
+		languages(&metadata.Language)
+
+		// Examples are converted, and overrides are applied last of all, to the generated schema itself.
+		var hooks []mkschema.Hook
+		if *convertExamples != "" {
+			convert := mkschema.PulumiConvert
+			if *convertExamples != "pulumi" {
+				command := strings.Fields(*convertExamples)
+				convert = mkschema.CommandConverter(command[0], command[1:]...)
+			}
+			hooks = append(hooks, mkschema.ExampleHook(convert, strings.Split(*exampleLanguages, ",")))
+		}
+		if *languageDescriptions != "" {
+			descs, err := mkschema.ReadLanguageDescriptions(*languageDescriptions)
+			if err != nil {
+				log.Fatalf("error: %s", err.Error())
+			}
+			hooks = append(hooks, mkschema.LanguageDescriptionsHook(descs))
+		}
+		if len(sets) > 0 {
+			hook, err := mkschema.SetHook(sets)
+			if err != nil {
+				log.Fatalf("error: %s", err.Error())
+			}
+			hooks = append(hooks, hook)
+		}
+
+		var names, onlyTokens []string
+		if *nameTags != "" {
+			names = strings.Split(*nameTags, ",")
+		}
+		if *only != "" {
+			onlyTokens = strings.Split(*only, ",")
+		}
+
+		return mkschema.Options{
+			Name:           name,
+			Package:        pkg,
+			Dir:            *dir,
+			BuildFlags:     buildFlags,
+			Env:            env,
+			Files:          files,
+			Hermetic:       *hermetic,
+			Modules:        modules,
+			ModulePolicy:   mkschema.ModulePolicy(*modulePolicy),
+			Mappings:       mappings,
+			ResolveRefs:    *resolveRefs,
+			Annotations:    annots,
+			Renames:        renamed,
+			NameTags:       names,
+			AutoSecret:     *autoSecret,
+			Metadata:       metadata,
+			Strict:         *strict,
+			BestEffort:     *bestEffort,
+			IncludePreview: *includePreview,
+			SkipGenerated:  *skipGenerated,
+			PruneTypes:     *prune,
+			Only:           onlyTokens,
+			Descriptions: mkschema.DescriptionOptions{
+				Dedent:            *descriptionDedent,
+				Wrap:              *descriptionWrap,
+				TrimTrailingSpace: *descriptionTrim,
+				DropLines:         descriptionDrop,
+			},
+			CacheDir: *cacheDir,
+			Hooks:    hooks,
+		}
+	}
+}
+
+// mapFlag is a repeatable flag of `KEY=VALUE` pairs.
+type mapFlag map[string]string
+
+func (m mapFlag) String() string {
+	var pairs []string
+	for k, v := range m {
+		pairs = append(pairs, k+"="+v)
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (m mapFlag) Set(s string) error {
+	eq := strings.Index(s, "=")
+	if eq <= 0 {
+		return errors.Errorf("expected KEY=VALUE, got '%s'", s)
+	}
+	m[s[:eq]] = s[eq+1:]
+	return nil
+}
+
+// listFlag is a repeatable flag that accumulates its values in order.
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
 
-	fmt.Printf("%s\n", string(b))
+func (l *listFlag) Set(s string) error {
+	*l = append(*l, s)
+	return nil
 }
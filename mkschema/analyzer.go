@@ -0,0 +1,53 @@
+package mkschema
+
+import (
+	"context"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// Analyzer runs the schema annotation checks, such as for properties that are missing names, are marked optional
+// but aren't pointers, or have types the schema can't represent, as a go/analysis pass. This lets component authors
+// see the same diagnostics as the generator in `go vet` and their editors.
+var Analyzer = &analysis.Analyzer{
+	Name: "pschema",
+	Doc:  "check Pulumi schema annotations on struct fields",
+	Run:  runAnalyzer,
+}
+
+func runAnalyzer(pass *analysis.Pass) (interface{}, error) {
+	g := &generator{
+		Name:       pass.Pkg.Name(),
+		Fset:       pass.Fset,
+		Pkg:        pass.Pkg,
+		Files:      pass.Files,
+		Info:       pass.TypesInfo,
+		BestEffort: true, // report every problem, rather than stopping at the first.
+		noWarnings: true, // warnings, such as for missing descriptions, are matters of taste for vet.
+		Resources:  make(map[string]*Type),
+		Types:      make(map[string]*Type),
+		Enums:      make(map[string]*Enum),
+		Diagnostics: func(d Diagnostic) {
+			// Only report problems with properties. Untagged types and fields are none of the schema's business,
+			// and go vet is run over plenty of packages that aren't meant for schema generation at all.
+			if d.Field != "" && d.at.IsValid() {
+				pass.Report(analysisDiagnostic(d))
+			}
+		},
+	}
+	return nil, g.GatherPackageSchema(context.Background())
+}
+
+// analysisDiagnostic converts a diagnostic into one for go/analysis, carrying its fix, if it has one, so that editors
+// can offer to apply it.
+func analysisDiagnostic(d Diagnostic) analysis.Diagnostic {
+	result := analysis.Diagnostic{Pos: d.at, Category: d.Code, Message: d.Message}
+	if len(d.Fixes) > 0 {
+		fix := analysis.SuggestedFix{Message: d.Suggestion}
+		for _, e := range d.Fixes {
+			fix.TextEdits = append(fix.TextEdits, analysis.TextEdit{Pos: e.pos, End: e.end, NewText: []byte(e.NewText)})
+		}
+		result.SuggestedFixes = []analysis.SuggestedFix{fix}
+	}
+	return result
+}
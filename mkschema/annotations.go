@@ -0,0 +1,162 @@
+package mkschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"go/types"
+	"io/ioutil"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// Annotations attach schema options to Go types and fields by fully qualified name, for declarations that can't carry
+// tags of their own, such as generated structs, or those copied from a third party.
+type Annotations struct {
+	// Types maps fully qualified Go type names, like `github.com/org/pkg.Type`, to their annotations.
+	Types map[string]TypeAnnotation `json:"types,omitempty"`
+}
+
+// TypeAnnotation annotates a Go type. Only the types gathered from the target package have descriptions and fields,
+// but any type may have a token, which references to it use.
+type TypeAnnotation struct {
+	Token       string `json:"token,omitempty"`       // the schema token to emit the type as, in place of its default.
+	Description string `json:"description,omitempty"` // the description, in place of the type's doc comment.
+	// Fields maps the names of a struct's Go fields to their annotations.
+	Fields map[string]FieldAnnotation `json:"fields,omitempty"`
+}
+
+// FieldAnnotation annotates a Go struct field as its tags would, putting it in the schema even if it has none. Its
+// name takes the place of the field's `pulumi:"<name>"` tag, and its options are added to those of its `pschema` tag.
+type FieldAnnotation struct {
+	Name        string `json:"name,omitempty"`        // the property name.
+	Optional    bool   `json:"optional,omitempty"`    // true if the property is optional.
+	Replaces    bool   `json:"replaces,omitempty"`    // true if changing the property replaces the resource.
+	In          bool   `json:"in,omitempty"`          // true if the property is an input, but not an output.
+	Out         bool   `json:"out,omitempty"`         // true if the property is an output, but not an input.
+	Ref         string `json:"ref,omitempty"`         // the type the property references, as with `ref=`.
+	Example     string `json:"example,omitempty"`     // an example of the property's value, for its docs.
+	Secret      bool   `json:"secret,omitempty"`      // true if the property's values are secret.
+	Description string `json:"description,omitempty"` // the description, in place of the field's doc comment.
+}
+
+// ReadAnnotations reads annotations from a YAML, or JSON, file, whose `types` key maps fully qualified Go type names
+// to their `token`, `description`, and `fields`, which map Go field names to their `name`, `description`, and
+// options: `optional`, `replaces`, `in`, `out`, `secret`, `ref`, and `example`. Unknown keys are rejected, so that
+// typos don't go unnoticed.
+func ReadAnnotations(path string) (Annotations, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Annotations{}, err
+	}
+
+	// Decode the YAML generically and re-encode it as JSON, as ReadMetadata does, to reject unknown keys.
+	var doc interface{}
+	if err = yaml.Unmarshal(b, &doc); err != nil {
+		return Annotations{}, errors.Wrapf(err, "parsing annotations file %s", path)
+	}
+	if b, err = json.Marshal(jsonValue(doc)); err != nil {
+		return Annotations{}, errors.Wrapf(err, "parsing annotations file %s", path)
+	}
+	var a Annotations
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err = dec.Decode(&a); err != nil {
+		return Annotations{}, errors.Wrapf(err, "parsing annotations file %s", path)
+	}
+	return a, nil
+}
+
+// clone returns a deep copy of the annotations.
+func (a Annotations) clone() Annotations {
+	if a.Types == nil {
+		return a
+	}
+	types := make(map[string]TypeAnnotation, len(a.Types))
+	for name, t := range a.Types {
+		if t.Fields != nil {
+			fields := make(map[string]FieldAnnotation, len(t.Fields))
+			for f, fa := range t.Fields {
+				fields[f] = fa
+			}
+			t.Fields = fields
+		}
+		types[name] = t
+	}
+	return Annotations{Types: types}
+}
+
+// apply returns a field's tag options with the annotation's added.
+func (a FieldAnnotation) apply(opts PropertyOptions) PropertyOptions {
+	if a.Name != "" {
+		opts.Name = a.Name
+	}
+	if a.Ref != "" {
+		opts.Ref = a.Ref
+	}
+	if a.Example != "" {
+		opts.Example = a.Example
+	}
+	opts.Optional = opts.Optional || a.Optional
+	opts.Replaces = opts.Replaces || a.Replaces
+	opts.In = opts.In || a.In
+	opts.Out = opts.Out || a.Out
+	opts.Secret = opts.Secret || a.Secret
+	return opts
+}
+
+// typeAnnotation returns the annotation of a Go type, if it has one.
+func (g *generator) typeAnnotation(t *types.TypeName) (TypeAnnotation, bool) {
+	a, has := g.Annotations.Types[qualifiedName(t)]
+	return a, has
+}
+
+// fieldAnnotation returns the annotation of a field of a Go struct type, if it has one.
+func (g *generator) fieldAnnotation(t *types.TypeName, fld *types.Var) (FieldAnnotation, bool) {
+	a, has := g.Annotations.Types[qualifiedName(t)].Fields[fld.Name()]
+	return a, has
+}
+
+// checkAnnotations checks that the annotated types and fields of the target package exist, and that tokens are
+// well-formed, so that a typo in the annotations file doesn't silently go unused.
+func (g *generator) checkAnnotations() error {
+	prefix := g.Pkg.Path() + "."
+	for _, name := range sortedKeys(g.Annotations.Types) {
+		a := g.Annotations.Types[name]
+		if a.Token != "" {
+			if parts := strings.Split(a.Token, ":"); len(parts) != 3 || parts[0] != g.Name || parts[2] == "" {
+				return errors.Errorf("annotated type %s has token %q, which isn't of the form `%s:<module>:<name>`",
+					name, a.Token, g.Name)
+			}
+		}
+		if !strings.HasPrefix(name, prefix) || strings.Contains(name[len(prefix):], ".") {
+			if a.Description != "" || len(a.Fields) > 0 {
+				return errors.Errorf("annotated type %s isn't in package %s, so only its token can be annotated",
+					name, g.Pkg.Path())
+			}
+			continue
+		}
+		t, ok := g.Pkg.Scope().Lookup(name[len(prefix):]).(*types.TypeName)
+		if !ok {
+			return errors.Errorf("annotated type %s isn't declared in package %s", name, g.Pkg.Path())
+		}
+		s, _ := t.Type().Underlying().(*types.Struct)
+		for _, field := range sortedKeys(a.Fields) {
+			if !hasField(s, field) {
+				return errors.Errorf("annotated field %s.%s isn't declared", name, field)
+			}
+		}
+	}
+	return nil
+}
+
+// hasField returns true if a struct, which may be nil, declares a field of the given name.
+func hasField(s *types.Struct, name string) bool {
+	for i := 0; s != nil && i < s.NumFields(); i++ {
+		if s.Field(i).Name() == name {
+			return true
+		}
+	}
+	return false
+}
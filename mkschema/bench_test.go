@@ -0,0 +1,99 @@
+package mkschema
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// The size of the synthetic package that generation is benchmarked over.
+var (
+	benchResources = flag.Int("bench.resources", 100, "the number of resources in the synthetic package")
+	benchTypes     = flag.Int("bench.types", 1000, "the number of complex types in the synthetic package")
+	benchFields    = flag.Int("bench.fields", 20, "the number of fields in each resource and type")
+)
+
+func BenchmarkGenerate(b *testing.B) {
+	benchmarkGenerate(b, false)
+}
+
+func BenchmarkGenerateCached(b *testing.B) {
+	benchmarkGenerate(b, true)
+}
+
+// benchmarkGenerate benchmarks generating a large synthetic package, from scratch or from the cache.
+func benchmarkGenerate(b *testing.B, cached bool) {
+	opts := Options{Name: "bench", Package: "./" + filepath.ToSlash(synthesizeBench(b))}
+	if cached {
+		opts.CacheDir = b.TempDir()
+	}
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Generate(ctx, opts); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// synthesizeBench writes the synthetic package beneath the package's directory, so that it resolves within this
+// module, and returns its directory. The leading underscore keeps the go command's `./...` patterns from ever
+// picking it up. The fields cycle through every kind of property type, and refer to the complex types, as real
+// packages do.
+func synthesizeBench(b *testing.B) string {
+	if *benchResources < 0 || *benchFields < 0 {
+		b.Fatal("-bench.resources and -bench.fields cannot be negative")
+	} else if *benchTypes < 1 {
+		b.Fatal("-bench.types must be at least 1, since the synthetic fields refer to complex types")
+	}
+	dir, err := ioutil.TempDir(".", "_mkschema-bench")
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { os.RemoveAll(dir) })
+
+	var src strings.Builder
+	src.WriteString("package bench\n\nimport \"github.com/pulumi/pulumi/sdk/v3/go/pulumi\"\n")
+	for i := 0; i < *benchResources; i++ {
+		fmt.Fprintf(&src, "\n// Resource%d is a synthetic resource.\ntype Resource%d struct {\n", i, i)
+		src.WriteString("\tpulumi.ResourceState\n\n")
+		writeBenchFields(&src, i)
+		src.WriteString("}\n")
+	}
+	for i := 0; i < *benchTypes; i++ {
+		fmt.Fprintf(&src, "\n// Type%d is a synthetic complex type.\ntype Type%d struct {\n", i, i)
+		writeBenchFields(&src, i)
+		src.WriteString("}\n")
+	}
+	if err = ioutil.WriteFile(filepath.Join(dir, "bench.go"), []byte(src.String()), 0644); err != nil {
+		b.Fatal(err)
+	}
+	return dir
+}
+
+func writeBenchFields(src *strings.Builder, i int) {
+	for j := 0; j < *benchFields; j++ {
+		ref := (i + j + 1) % *benchTypes
+		fmt.Fprintf(src, "\t// Field%d is a synthetic property.\n", j)
+		switch j % 6 {
+		case 0:
+			fmt.Fprintf(src, "\tField%d string `pulumi:\"field%d\"`\n", j, j)
+		case 1:
+			fmt.Fprintf(src, "\tField%d *int `pulumi:\"field%d\" pschema:\"optional\"`\n", j, j)
+		case 2:
+			fmt.Fprintf(src, "\tField%d []string `pulumi:\"field%d\"`\n", j, j)
+		case 3:
+			fmt.Fprintf(src, "\tField%d map[string]string `pulumi:\"field%d\"`\n", j, j)
+		case 4:
+			fmt.Fprintf(src, "\tField%d *Type%d `pulumi:\"field%d\" pschema:\"optional\"`\n", j, ref, j)
+		case 5:
+			fmt.Fprintf(src, "\tField%d map[string][]Type%d `pulumi:\"field%d\"`\n", j, ref, j)
+		}
+	}
+}
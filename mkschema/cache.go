@@ -0,0 +1,240 @@
+package mkschema
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"hash"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"golang.org/x/tools/go/packages"
+)
+
+// cacheVersion is bumped whenever the generator's output may change for the same inputs, so that stale entries
+// written by older versions are never used.
+const cacheVersion = 13
+
+// cacheEntry is the result of a generation, before any hooks ran, as stored on disk.
+type cacheEntry struct {
+	Schema      *schema.PackageSpec `json:"schema"`
+	Diagnostics []Diagnostic        `json:"diagnostics,omitempty"`
+	Failures    []cachedFailure     `json:"failures,omitempty"`
+}
+
+type cachedFailure struct {
+	Type  string `json:"type"`
+	Field string `json:"field,omitempty"`
+	Err   string `json:"err"`
+}
+
+// cacheKey computes the key for generating with the given options: a hash of the options that affect the output
+// along with the contents of the target package's files, of its module's go.mod, go.sum, and vendor/modules.txt
+// (if it vendors its dependencies), and of the go.work and go.work.sum of any workspace it's in. Finding the files
+// only requires listing the package, which is far cheaper than loading and type-checking it.
+//
+// Other modules are identified by those files alone, so edits to the sources of dependencies replaced with local
+// directories, or of sibling modules in a workspace, aren't noticed; clear the cache after making them.
+func cacheKey(ctx context.Context, opts Options) (string, error) {
+	inputs, err := listCacheInputs(ctx, opts)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	h.Write(inputs.Config)
+	for _, file := range append(append([]string(nil), inputs.PkgFiles...), inputs.ModFiles...) {
+		if err = hashFile(h, file); err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// cacheInputs are everything a generation's output depends on, as listed by listCacheInputs.
+type cacheInputs struct {
+	Config   []byte   // the options that affect the output, serialized.
+	PkgFiles []string // the target package's files, sorted.
+	ModFiles []string // the module and workspace files that pin the package's dependencies, which may not exist.
+}
+
+// listCacheInputs lists everything that generating with the given options depends on.
+func listCacheInputs(ctx context.Context, opts Options) (*cacheInputs, error) {
+	pkgs, err := packages.Load(&packages.Config{
+		Context:    ctx,
+		Dir:        opts.Dir,
+		BuildFlags: opts.BuildFlags,
+		Env:        goEnv(opts.goCommandEnv()),
+		Mode:       packages.NeedName | packages.NeedFiles | packages.NeedModule,
+	}, opts.Package)
+	if err != nil {
+		return nil, err
+	} else if len(pkgs) != 1 {
+		return nil, errors.Errorf("expected one Go package for %v, got %d", opts.Package, len(pkgs))
+	}
+	pkg := pkgs[0]
+
+	// Lint rules' checks can't be hashed, so a rule that changes what it checks must change its code, or severity.
+	rules, err := lintRules(opts.LintRules)
+	if err != nil {
+		return nil, err
+	}
+	var lint []string
+	for _, r := range rules {
+		lint = append(lint, r.Code+"="+r.Severity.String())
+	}
+
+	config, err := json.Marshal(struct {
+		Version        int
+		Name           string
+		Package        string
+		Modules        map[string]string
+		ModulePolicy   ModulePolicy
+		Mappings       map[string]string
+		ResolveRefs    bool
+		Annotations    Annotations
+		Renames        map[string]string
+		NameTags       []string
+		AutoSecret     bool
+		Metadata       Metadata
+		BuildVersion   string
+		Strict         bool
+		BestEffort     bool
+		IncludePreview bool
+		SkipGenerated  bool
+		Prune          bool
+		Only           []string
+		BuildFlags     []string
+		Env            []string
+		Descriptions   DescriptionOptions
+		LintRules      []string
+	}{cacheVersion, opts.Name, pkg.PkgPath, opts.Modules, opts.ModulePolicy, opts.Mappings, opts.ResolveRefs,
+		opts.Annotations, opts.Renames, opts.NameTags, opts.AutoSecret, opts.Metadata, BuildVersion, opts.Strict,
+		opts.BestEffort, opts.IncludePreview, opts.SkipGenerated, opts.PruneTypes, opts.Only, opts.BuildFlags, opts.Env,
+		opts.Descriptions, lint})
+	if err != nil {
+		return nil, err
+	}
+	inputs := &cacheInputs{Config: config, PkgFiles: append([]string(nil), pkg.GoFiles...)}
+	if len(pkg.GoFiles) > 0 {
+		// Resources' examples are taken from the package's tests, and their long-form docs from its docs directory.
+		dir := filepath.Dir(pkg.GoFiles[0])
+		for _, pattern := range []string{"*_test.go", filepath.Join(docsDir, "*.md")} {
+			files, err := filepath.Glob(filepath.Join(dir, pattern))
+			if err != nil {
+				return nil, err
+			}
+			inputs.PkgFiles = append(inputs.PkgFiles, files...)
+		}
+	}
+	sort.Strings(inputs.PkgFiles)
+
+	if pkg.Module != nil && pkg.Module.GoMod != "" {
+		root := filepath.Dir(pkg.Module.GoMod)
+		inputs.ModFiles = append(inputs.ModFiles, pkg.Module.GoMod, filepath.Join(root, "go.sum"),
+			filepath.Join(root, "vendor", "modules.txt"))
+	}
+	if work, err := goWorkFile(ctx, opts.Dir); err != nil {
+		return nil, err
+	} else if work != "" {
+		inputs.ModFiles = append(inputs.ModFiles, work, work+".sum")
+	}
+	return inputs, nil
+}
+
+// hashFile writes a file's name and contents to h. A file that doesn't exist hashes as if it were empty.
+func hashFile(h hash.Hash, file string) error {
+	b, err := ioutil.ReadFile(file)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	h.Write([]byte(file + "\x00"))
+	h.Write(b)
+	h.Write([]byte{0})
+	return nil
+}
+
+// goWorkFile returns the path to the go.work file in effect for the given directory, or "" if there isn't one.
+func goWorkFile(ctx context.Context, dir string) (string, error) {
+	cmd := exec.CommandContext(ctx, "go", "env", "GOWORK")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", errors.Wrapf(err, "finding go.work")
+	}
+	if work := strings.TrimSpace(string(out)); work != "off" {
+		return work, nil
+	}
+	return "", nil
+}
+
+// readCache returns the cache entry with the given key, or nil if there isn't a usable one.
+func readCache(dir, key string) *cacheEntry {
+	b, err := ioutil.ReadFile(filepath.Join(dir, key+".json"))
+	if err != nil {
+		return nil
+	}
+	var entry cacheEntry
+	if err = json.Unmarshal(b, &entry); err != nil || entry.Schema == nil {
+		return nil // a corrupt entry is simply a miss; it'll be overwritten.
+	}
+	return &entry
+}
+
+// writeCache stores a cache entry, or any other JSON value, under the given key. The entry is written to a temporary
+// file and renamed into place, so that concurrent runs never see a partially written entry.
+func writeCache(dir, key string, entry interface{}) error {
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(dir, key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(dir, key+".json"))
+}
+
+// newCacheEntry records a generated schema, and the diagnostics and failures found along the way, for caching.
+func newCacheEntry(spec *schema.PackageSpec, diags []Diagnostic, failures []Failure) *cacheEntry {
+	entry := &cacheEntry{Schema: spec, Diagnostics: diags}
+	for _, f := range failures {
+		entry.Failures = append(entry.Failures, cachedFailure{Type: f.Type, Field: f.Field, Err: f.Err.Error()})
+	}
+	return entry
+}
+
+// replay sends the entry's diagnostics to the sink, as a fresh run would have, and returns its schema along with a
+// *PartialError if anything was skipped.
+func (e *cacheEntry) replay(sink DiagnosticSink) (*schema.PackageSpec, error) {
+	if sink != nil {
+		for _, d := range e.Diagnostics {
+			sink(d)
+		}
+	}
+	if len(e.Failures) == 0 {
+		return e.Schema, nil
+	}
+	partial := &PartialError{}
+	for _, f := range e.Failures {
+		partial.Failures = append(partial.Failures, Failure{Type: f.Type, Field: f.Field, Err: errors.New(f.Err)})
+	}
+	return e.Schema, partial
+}
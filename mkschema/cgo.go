@@ -0,0 +1,66 @@
+package mkschema
+
+import (
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// cgoError is returned when Go packages can't be loaded because cgo can't run, such as for want of a C toolchain,
+// which type-checking a package that imports "C", or any that depends on one, otherwise requires.
+type cgoError struct {
+	reason string // the go command's explanation, such as that the C compiler wasn't found.
+}
+
+func (e *cgoError) Error() string {
+	return "cgo can't run: " + e.reason
+}
+
+// packageErrors returns an error for the errors found while loading Go packages, which is a *cgoError if any of them
+// are because cgo couldn't run.
+func packageErrors(errs []string) error {
+	for _, err := range errs {
+		if !strings.Contains(err, "cgo: ") && !strings.Contains(err, "could not import C") {
+			continue
+		}
+		// The go command's own explanation is more helpful than the type checker's complaint about package C.
+		for _, e := range errs {
+			for _, line := range strings.Split(e, "\n") {
+				if strings.HasPrefix(line, "cgo: ") {
+					return &cgoError{reason: strings.TrimPrefix(line, "cgo: ")}
+				}
+			}
+		}
+		return &cgoError{reason: err}
+	}
+	return errors.Errorf("parsing Go files: %s", strings.Join(errs, "; "))
+}
+
+// loadWithoutCgo calls load with the go command's environment and, if it fails because cgo can't run, calls it again
+// with cgo disabled, since a schema never needs compiled C: files that import "C" are left out, as the go command
+// does for a build without cgo. Cgo is only disabled if CGO_ENABLED isn't set explicitly, which is respected. It
+// returns the reason that cgo was disabled, if it was.
+func loadWithoutCgo(env []string, load func(env []string) error) (string, error) {
+	err := load(env)
+	var cerr *cgoError
+	if !errors.As(err, &cerr) || cgoExplicit(env) {
+		return "", err
+	}
+	if err = load(append(append([]string(nil), env...), "CGO_ENABLED=0")); err != nil {
+		return "", errors.Wrapf(err, "%s, and loading without cgo failed; install a C toolchain, or set CC to one",
+			cerr.Error())
+	}
+	return cerr.reason, nil
+}
+
+// cgoExplicit returns true if CGO_ENABLED is set in the go command's extra environment, or in the process's.
+func cgoExplicit(env []string) bool {
+	for _, v := range env {
+		if strings.HasPrefix(v, "CGO_ENABLED=") {
+			return true
+		}
+	}
+	_, has := os.LookupEnv("CGO_ENABLED")
+	return has
+}
@@ -0,0 +1,271 @@
+package mkschema
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+)
+
+// ChangeKind classifies a change between two versions of a schema, for release notes.
+type ChangeKind int
+
+const (
+	// ChangeBreaking is a change that breaks existing programs, such as a removed resource or property, a property
+	// whose type changed, or a newly required input.
+	ChangeBreaking ChangeKind = iota
+	// ChangeNewResource is a new resource.
+	ChangeNewResource
+	// ChangeNewFunction is a new function.
+	ChangeNewFunction
+	// ChangeNewType is a new object type or enum.
+	ChangeNewType
+	// ChangeNewProperty is a new, optional property of an existing resource, type, or function.
+	ChangeNewProperty
+	// ChangeNewEnumValue is a new value of an existing enum.
+	ChangeNewEnumValue
+	// ChangeDeprecation is a newly deprecated resource, function, or property.
+	ChangeDeprecation
+)
+
+// heading returns the changelog heading under which changes of this kind are listed.
+func (k ChangeKind) heading() string {
+	switch k {
+	case ChangeBreaking:
+		return "Breaking changes"
+	case ChangeNewResource:
+		return "New resources"
+	case ChangeNewFunction:
+		return "New functions"
+	case ChangeNewType:
+		return "New types"
+	case ChangeNewProperty:
+		return "New properties"
+	case ChangeNewEnumValue:
+		return "New enum values"
+	case ChangeDeprecation:
+		return "Deprecations"
+	default:
+		return fmt.Sprintf("ChangeKind(%d)", int(k))
+	}
+}
+
+// Change is a change between two versions of a schema.
+type Change struct {
+	Kind     ChangeKind // the kind of change.
+	Token    string     // the token of the resource, type, or function that changed.
+	Property string     // the property that changed, if the change was specific to one.
+	Message  string     // a human-readable description of the change, in markdown.
+}
+
+func (c Change) String() string {
+	return c.Message
+}
+
+// Changes classifies the changes between an old and a new version of a schema, sorted by kind, token, and property.
+func Changes(old, new *schema.PackageSpec) []Change {
+	var changes []Change
+	add := func(kind ChangeKind, tok, prop, format string, args ...interface{}) {
+		changes = append(changes, Change{Kind: kind, Token: tok, Property: prop, Message: fmt.Sprintf(format, args...)})
+	}
+
+	// A resource that has disappeared may have been renamed, in which case its new resource aliases it.
+	renames := make(map[string]string)
+	for tok, r := range new.Resources {
+		for _, alias := range r.Aliases {
+			if alias.Type != nil {
+				renames[*alias.Type] = tok
+			}
+		}
+	}
+	for tok, r := range old.Resources {
+		nr, has := new.Resources[tok]
+		switch {
+		case !has && renames[tok] != "":
+			add(ChangeBreaking, tok, "", "Resource `%s` was renamed to `%s`", tok, renames[tok])
+		case !has:
+			add(ChangeBreaking, tok, "", "Resource `%s` was removed", tok)
+		default:
+			if r.DeprecationMessage == "" && nr.DeprecationMessage != "" {
+				add(ChangeDeprecation, tok, "", "Resource `%s` is deprecated: %s", tok, nr.DeprecationMessage)
+			}
+			diffProperties(add, "resource", tok, "", r.Properties, nr.Properties, r.Required, nr.Required)
+			diffProperties(add, "resource", tok, "input ", r.InputProperties, nr.InputProperties,
+				r.RequiredInputs, nr.RequiredInputs)
+		}
+	}
+	for tok := range new.Resources {
+		if _, has := old.Resources[tok]; !has {
+			add(ChangeNewResource, tok, "", "Resource `%s`", tok)
+		}
+	}
+
+	for tok, f := range old.Functions {
+		nf, has := new.Functions[tok]
+		if !has {
+			add(ChangeBreaking, tok, "", "Function `%s` was removed", tok)
+			continue
+		}
+		if f.DeprecationMessage == "" && nf.DeprecationMessage != "" {
+			add(ChangeDeprecation, tok, "", "Function `%s` is deprecated: %s", tok, nf.DeprecationMessage)
+		}
+		for _, obj := range []struct {
+			kind     string
+			old, new *schema.ObjectTypeSpec
+		}{{"input ", f.Inputs, nf.Inputs}, {"output ", f.Outputs, nf.Outputs}} {
+			var o, n schema.ObjectTypeSpec
+			if obj.old != nil {
+				o = *obj.old
+			}
+			if obj.new != nil {
+				n = *obj.new
+			}
+			diffProperties(add, "function", tok, obj.kind, o.Properties, n.Properties, o.Required, n.Required)
+		}
+	}
+	for tok := range new.Functions {
+		if _, has := old.Functions[tok]; !has {
+			add(ChangeNewFunction, tok, "", "Function `%s`", tok)
+		}
+	}
+
+	for tok, t := range old.Types {
+		nt, has := new.Types[tok]
+		switch {
+		case !has:
+			add(ChangeBreaking, tok, "", "Type `%s` was removed", tok)
+		case len(t.Enum) > 0 || len(nt.Enum) > 0:
+			diffEnumValues(add, tok, t, nt)
+		default:
+			diffProperties(add, "type", tok, "", t.Properties, nt.Properties, t.Required, nt.Required)
+		}
+	}
+	for tok := range new.Types {
+		if _, has := old.Types[tok]; !has {
+			add(ChangeNewType, tok, "", "Type `%s`", tok)
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		ci, cj := changes[i], changes[j]
+		if ci.Kind != cj.Kind {
+			return ci.Kind < cj.Kind
+		} else if ci.Token != cj.Token {
+			return ci.Token < cj.Token
+		} else if ci.Property != cj.Property {
+			return ci.Property < cj.Property
+		}
+		return ci.Message < cj.Message
+	})
+
+	// A resource property that changed as both an input and an output is only listed once.
+	var deduped []Change
+	for i, c := range changes {
+		if i == 0 || c != changes[i-1] {
+			deduped = append(deduped, c)
+		}
+	}
+	return deduped
+}
+
+// diffProperties classifies the changes to the properties of a resource, type, or function.
+func diffProperties(add func(kind ChangeKind, tok, prop, format string, args ...interface{}), kind, tok, role string,
+	old, new map[string]schema.PropertySpec, oldRequired, newRequired []string) {
+	wasRequired, isRequired := make(map[string]bool), make(map[string]bool)
+	for _, name := range oldRequired {
+		wasRequired[name] = true
+	}
+	for _, name := range newRequired {
+		isRequired[name] = true
+	}
+
+	for name, p := range old {
+		np, has := new[name]
+		switch {
+		case !has:
+			add(ChangeBreaking, tok, name, "Property `%s` of %s `%s` was removed", name, kind, tok)
+		case !reflect.DeepEqual(p.TypeSpec, np.TypeSpec):
+			add(ChangeBreaking, tok, name, "The type of property `%s` of %s `%s` changed", name, kind, tok)
+		case role == "input " && !wasRequired[name] && isRequired[name]:
+			add(ChangeBreaking, tok, name, "Input `%s` of %s `%s` is now required", name, kind, tok)
+		case p.DeprecationMessage == "" && np.DeprecationMessage != "":
+			add(ChangeDeprecation, tok, name, "Property `%s` of %s `%s` is deprecated: %s", name, kind, tok,
+				np.DeprecationMessage)
+		}
+	}
+	for name := range new {
+		if _, has := old[name]; has {
+			continue
+		}
+		if role == "input " && isRequired[name] {
+			add(ChangeBreaking, tok, name, "New required input `%s` of %s `%s`", name, kind, tok)
+		} else {
+			label := "Property"
+			if role != "" {
+				label = strings.ToUpper(role[:1]) + role[1:] + "property"
+			}
+			add(ChangeNewProperty, tok, name, "%s `%s` of %s `%s`", label, name, kind, tok)
+		}
+	}
+}
+
+// diffEnumValues classifies the changes to the values of an enum.
+func diffEnumValues(add func(kind ChangeKind, tok, prop, format string, args ...interface{}), tok string,
+	old, new schema.ComplexTypeSpec) {
+	if old.Type != new.Type {
+		add(ChangeBreaking, tok, "", "The type of enum `%s` changed from %s to %s", tok, old.Type, new.Type)
+		return
+	}
+	has := func(values []schema.EnumValueSpec, v schema.EnumValueSpec) bool {
+		for _, e := range values {
+			if reflect.DeepEqual(e.Value, v.Value) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, v := range old.Enum {
+		if !has(new.Enum, v) {
+			add(ChangeBreaking, tok, enumValueName(v), "Value `%s` of enum `%s` was removed", enumValueName(v), tok)
+		}
+	}
+	for _, v := range new.Enum {
+		if !has(old.Enum, v) {
+			add(ChangeNewEnumValue, tok, enumValueName(v), "Value `%s` of enum `%s`", enumValueName(v), tok)
+		}
+	}
+}
+
+func enumValueName(v schema.EnumValueSpec) string {
+	if v.Name != "" {
+		return v.Name
+	}
+	return fmt.Sprint(v.Value)
+}
+
+// Changelog renders the changes between an old and a new version of a schema as a markdown CHANGELOG section, with
+// a list of changes under a heading for each kind, suitable for pasting into release notes. The section is titled
+// with title, if it isn't empty.
+func Changelog(old, new *schema.PackageSpec, title string) string {
+	var b strings.Builder
+	if title != "" {
+		fmt.Fprintf(&b, "## %s\n\n", title)
+	}
+	changes := Changes(old, new)
+	if len(changes) == 0 {
+		b.WriteString("No changes to the schema.\n")
+		return b.String()
+	}
+	for i, c := range changes {
+		if i == 0 || c.Kind != changes[i-1].Kind {
+			if i > 0 {
+				b.WriteString("\n")
+			}
+			fmt.Fprintf(&b, "### %s\n\n", c.Kind.heading())
+		}
+		fmt.Fprintf(&b, "- %s\n", c.Message)
+	}
+	return b.String()
+}
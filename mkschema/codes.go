@@ -0,0 +1,144 @@
+package mkschema
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+)
+
+// rule is a check made while gathering a package, whose diagnostics carry its stable code, so that they can be
+// suppressed by it.
+type rule struct {
+	code string // the code, such as MKS001, which never changes meaning once assigned.
+	text string // what the rule requires, as shown in diagnostics.
+}
+
+// The rules, by code. Codes are never reused, so that suppressions keep meaning what they meant.
+var (
+	ruleSchemaTypes            = rule{"MKS001", "schema types must be structs, or enums of primitive types"}
+	ruleNamedProperties        = rule{"MKS002", "tagged fields must name their property"}
+	ruleResourceOutputs        = rule{"MKS003", "only resource properties may be outputs"}
+	ruleResourceReplaces       = rule{"MKS004", "only resource properties may force replacement"}
+	ruleOptionalPointers       = rule{"MKS005", "optional properties must be pointers"}
+	rulePropertyTypes          = rule{"MKS006", "property types must be representable in the schema"}
+	ruleStrictTags             = rule{"MKS007", "in strict mode, exported fields of schema types must be tagged"}
+	rulePackageDirectives      = rule{"MKS008", "package directives must be key=value pairs of known keys"}
+	ruleUntaggedFields         = rule{"MKS009", "exported fields of schema types should be tagged"}
+	ruleDocumentedTypes        = rule{"MKS010", "schema types should be documented"}
+	ruleSpecificTypes          = rule{"MKS011", "property types should be specific"}
+	ruleWellFormedTags         = rule{"MKS012", "field tags must be well-formed"}
+	ruleWellFormedDescriptions = rule{"MKS013", "descriptions should be well-formed markdown"}
+	ruleSummaries              = rule{"MKS014", "descriptions should start with a short summary"}
+	ruleTypeDirectives         = rule{"MKS015", "type directives must suit the types they mark"}
+	ruleSecretNames            = rule{"MKS016", "properties named like secrets should be secret"}
+	ruleExternalRefVersions    = rule{"MKS017", "references to other packages should be pinned to versions"}
+	ruleExternalRefs           = rule{"MKS018", "references to other packages must resolve"}
+)
+
+// nolintDirective is the comment that suppresses diagnostics, by code, on the line it's on or the declaration it
+// documents, as in `//pschema:nolint MKS009,MKS011`. Without codes, it suppresses every diagnostic there but errors,
+// which must be suppressed by code.
+const nolintDirective = "//pschema:nolint"
+
+// suppressed returns true if a diagnostic is suppressed, either by its code in the package's metadata, or by a
+// nolint directive on its line, in the doc comment of its declaration, or in that of the type it was found in.
+func (g *generator) suppressed(d Diagnostic) bool {
+	if d.Code == "" {
+		return false
+	}
+	for _, code := range g.Metadata.Suppress {
+		if code == d.Code {
+			return true
+		}
+	}
+	if !d.Pos.IsValid() {
+		return false
+	}
+
+	if g.nolint == nil {
+		g.nolint = indexNolint(g.Fset, g.Files)
+	}
+	bare := d.Severity != SeverityError
+	if nolintMatches(g.nolint[d.Pos.Filename][d.Pos.Line], d.Code, bare) {
+		return true
+	}
+	if node, has := g.typeNodes[d.Type]; has && node.Doc != nil {
+		return nolintMatches(nolintCodes(node.Doc), d.Code, bare)
+	}
+	return false
+}
+
+// indexNolint indexes the nolint directives in the given files by file and by the line they apply to: their own, for
+// trailing comments, and that of the declaration they document, for doc comments.
+func indexNolint(fset *token.FileSet, files []*ast.File) map[string]map[int][][]string {
+	index := make(map[string]map[int][][]string)
+	add := func(pos token.Pos, directives [][]string) {
+		if len(directives) == 0 {
+			return
+		}
+		p := fset.Position(pos)
+		if index[p.Filename] == nil {
+			index[p.Filename] = make(map[int][][]string)
+		}
+		index[p.Filename][p.Line] = append(index[p.Filename][p.Line], directives...)
+	}
+	for _, file := range files {
+		for _, group := range file.Comments {
+			for _, c := range group.List {
+				add(c.Pos(), nolintCodes(&ast.CommentGroup{List: []*ast.Comment{c}}))
+			}
+		}
+		ast.Inspect(file, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.GenDecl:
+				if n.Doc != nil && !n.Lparen.IsValid() {
+					add(n.Pos(), nolintCodes(n.Doc))
+				}
+			case *ast.TypeSpec:
+				if n.Doc != nil {
+					add(n.Pos(), nolintCodes(n.Doc))
+				}
+			case *ast.Field:
+				if n.Doc != nil {
+					add(n.Pos(), nolintCodes(n.Doc))
+				}
+			}
+			return true
+		})
+	}
+	return index
+}
+
+// nolintCodes returns the codes of each nolint directive in a comment group. A directive without codes has an empty
+// list of them.
+func nolintCodes(group *ast.CommentGroup) [][]string {
+	var directives [][]string
+	for _, c := range group.List {
+		if !strings.HasPrefix(c.Text, nolintDirective) {
+			continue
+		}
+		rest := c.Text[len(nolintDirective):]
+		if rest != "" && rest[0] != ' ' && rest[0] != '\t' {
+			continue
+		}
+		codes := strings.FieldsFunc(rest, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' })
+		directives = append(directives, append([]string{}, codes...))
+	}
+	return directives
+}
+
+// nolintMatches returns true if any of the given directives suppresses the code, including any without codes, if bare
+// directives may suppress it.
+func nolintMatches(directives [][]string, code string, bare bool) bool {
+	for _, codes := range directives {
+		if len(codes) == 0 && bare {
+			return true
+		}
+		for _, c := range codes {
+			if c == code {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,153 @@
+package mkschema
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"golang.org/x/tools/go/packages"
+)
+
+// ConstructMismatch records a place where the schema and the component's Construct implementation disagree.
+type ConstructMismatch struct {
+	Resource string // the resource token whose property is mismatched.
+	Property string // the schema property name.
+	Field    string // the Go field backing the property.
+	Pos      string // the position of the Go field's declaration.
+	Reason   string // a description of the mismatch.
+}
+
+func (m ConstructMismatch) String() string {
+	return fmt.Sprintf("%s: %s property '%s' (field %s) %s", m.Pos, m.Resource, m.Property, m.Field, m.Reason)
+}
+
+// CheckConstruct generates the schema for the target package and then cross-checks it against implPkg, the Go
+// package that implements the component's Construct. Every input property must be read somewhere in the
+// implementation, and every output property must be written, otherwise the schema has drifted from the code.
+func CheckConstruct(ctx context.Context, opts Options,
+	implPkg string) (*schema.PackageSpec, []ConstructMismatch, error) {
+	spec, _, mismatches, err := CheckConstructModel(ctx, opts, implPkg)
+	return spec, mismatches, err
+}
+
+// CheckConstructModel checks the Construct implementation as CheckConstruct does, and also returns the model the
+// schema was gathered from, reconciled with the final schema, as GenerateModel does.
+func CheckConstructModel(ctx context.Context, opts Options,
+	implPkg string) (*schema.PackageSpec, *Model, []ConstructMismatch, error) {
+	if opts.BestEffort {
+		return nil, nil, nil, errors.New("checking the Construct implementation requires a complete schema")
+	}
+
+	var extraPkgs []string
+	if implPkg != opts.Package {
+		extraPkgs = append(extraPkgs, implPkg)
+	}
+	g, err := loadGenerator(ctx, opts, extraPkgs...)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if err = g.gather(ctx); err != nil {
+		return nil, nil, nil, err
+	}
+
+	impl := findPackage(g.Packages, g.Dir, implPkg)
+	if impl == nil {
+		return nil, nil, nil, errors.Errorf("missing Go implementation package %v", implPkg)
+	}
+	mismatches := g.checkConstruct(impl)
+	m := g.Model()
+	spec := m.Schema()
+	if err = runHooks(ctx, spec, opts.Hooks); err != nil {
+		return nil, nil, nil, err
+	}
+	return spec, m.Reconcile(spec), mismatches, nil
+}
+
+// checkConstruct reports resource inputs the implementation never consumes and outputs it never produces.
+func (g *generator) checkConstruct(impl *packages.Package) []ConstructMismatch {
+	// First, walk the implementation and record which fields are read and which are written.
+	reads, writes := fieldUses(impl)
+
+	// Next, check these against the Go fields that back each of the resources' properties.
+	var mismatches []ConstructMismatch
+	for _, r := range g.Model().Resources {
+		if !r.IsComponent {
+			continue // only components are constructed.
+		}
+		for _, p := range r.Properties {
+			if !p.Options.Out && !reads[p.Field] {
+				mismatches = append(mismatches, ConstructMismatch{
+					Resource: r.Token,
+					Property: p.Name,
+					Field:    p.Field.Name(),
+					Pos:      g.diag(p.Field),
+					Reason:   "is a schema input but is never read by the implementation",
+				})
+			}
+			if !p.Options.In && !writes[p.Field] {
+				mismatches = append(mismatches, ConstructMismatch{
+					Resource: r.Token,
+					Property: p.Name,
+					Field:    p.Field.Name(),
+					Pos:      g.diag(p.Field),
+					Reason:   "is a schema output but is never written by the implementation",
+				})
+			}
+		}
+	}
+	return mismatches
+}
+
+// fieldUses walks a package's syntax and returns the sets of struct fields that are read and written. A field is
+// written if it's the target of a plain assignment or a key in a composite literal; any other use is a read.
+func fieldUses(pkg *packages.Package) (map[*types.Var]bool, map[*types.Var]bool) {
+	reads := make(map[*types.Var]bool)
+	writes := make(map[*types.Var]bool)
+	assigned := make(map[ast.Expr]bool)
+
+	fieldOf := func(e ast.Expr) *types.Var {
+		if sel, ok := e.(*ast.SelectorExpr); ok {
+			if selection, ok := pkg.TypesInfo.Selections[sel]; ok && selection.Kind() == types.FieldVal {
+				return selection.Obj().(*types.Var)
+			}
+		}
+		return nil
+	}
+
+	for _, file := range pkg.Syntax {
+		ast.Inspect(file, func(n ast.Node) bool {
+			switch e := n.(type) {
+			case *ast.AssignStmt:
+				for _, lhs := range e.Lhs {
+					if v := fieldOf(lhs); v != nil {
+						writes[v] = true
+						if e.Tok == token.ASSIGN {
+							assigned[lhs] = true // a plain store, not a read-modify-write.
+						}
+					}
+				}
+			case *ast.CompositeLit:
+				for _, elt := range e.Elts {
+					if kv, ok := elt.(*ast.KeyValueExpr); ok {
+						if key, ok := kv.Key.(*ast.Ident); ok {
+							if v, ok := pkg.TypesInfo.Uses[key].(*types.Var); ok && v.IsField() {
+								writes[v] = true
+							}
+						}
+					}
+				}
+			case *ast.SelectorExpr:
+				if v := fieldOf(e); v != nil && !assigned[e] {
+					reads[v] = true
+				}
+			}
+			return true
+		})
+	}
+
+	return reads, writes
+}
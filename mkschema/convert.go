@@ -0,0 +1,318 @@
+package mkschema
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/types"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// ConversionHelpers generates the source of a Go file, to be added to the model's package, that converts between
+// Pulumi property maps, such as the inputs of a Check or Construct request, and the package's annotated structs. For
+// each gathered resource and type T, it declares:
+//
+//	func (v *T) UnmarshalProperties(pm resource.PropertyMap) (secrets, unknowns []string, err error)
+//	func (v *T) MarshalProperties(secrets []string) resource.PropertyMap
+//
+// Unmarshaling unwraps secret values, returning the paths of the properties that held them so that they can be marked
+// secret again when marshaling, and leaves the fields of unknown values unset, returning their paths. Missing
+// required properties, other than outputs, and values of the wrong type are errors. Marshaling omits unset optional
+// properties. Fields whose types can't be converted, such as references to resources or to types from other packages,
+// are skipped, and listed in the helper's doc comment.
+func (m *Model) ConversionHelpers() ([]byte, error) {
+	c := &converter{gathered: make(map[*types.TypeName]bool)}
+	all := append(append([]*Type(nil), m.Resources...), m.Types...)
+	for _, t := range m.Types {
+		c.gathered[t.Object] = true
+	}
+	for _, t := range all {
+		c.pkg = t.Object.Pkg()
+	}
+	if c.pkg == nil {
+		return nil, errors.Errorf("package %s has no resources or types to convert", m.Package)
+	}
+
+	for _, t := range all {
+		c.unmarshalType(t)
+		c.marshalType(t)
+	}
+
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "// Code generated by pulumi-mkschema; DO NOT EDIT.\n\npackage %s\n", c.pkg.Name())
+	fmt.Fprintf(&src, "\nimport (\n\t\"fmt\"\n\t\"strings\"\n\n\t%q\n)\n",
+		"github.com/pulumi/pulumi/sdk/v3/go/common/resource")
+	src.Write(c.buf.Bytes())
+	src.WriteString(conversionSupport)
+
+	out, err := format.Source(src.Bytes())
+	if err != nil {
+		return nil, errors.Wrapf(err, "formatting conversion helpers")
+	}
+	return out, nil
+}
+
+// converter generates conversion helpers.
+type converter struct {
+	buf      bytes.Buffer
+	pkg      *types.Package           // the package being converted.
+	gathered map[*types.TypeName]bool // the gathered complex types, which are converted as nested objects.
+}
+
+func (c *converter) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&c.buf, format, args...)
+}
+
+// convertible returns whether values of a type can be converted.
+func (c *converter) convertible(t types.Type) bool {
+	switch t := t.(type) {
+	case *types.Basic:
+		return t.Info()&(types.IsBoolean|types.IsNumeric|types.IsString) != 0 && t.Info()&types.IsComplex == 0
+	case *types.Interface:
+		return t.Empty()
+	case *types.Pointer:
+		return c.convertible(t.Elem())
+	case *types.Slice:
+		return c.convertible(t.Elem())
+	case *types.Map:
+		return c.convertible(t.Key()) && c.convertible(t.Elem())
+	case *types.Named:
+		if _, isStruct := t.Underlying().(*types.Struct); isStruct {
+			return c.gathered[t.Obj()]
+		}
+		_, isBasic := t.Underlying().(*types.Basic)
+		return isBasic && t.Obj().Pkg() == c.pkg && c.convertible(t.Underlying())
+	}
+	return false
+}
+
+// skipped returns the names of the fields of a type that can't be converted.
+func (c *converter) skipped(t *Type) []string {
+	var names []string
+	for _, p := range t.Properties {
+		if !c.convertible(p.Field.Type()) {
+			names = append(names, p.Field.Name())
+		}
+	}
+	return names
+}
+
+func (c *converter) docSkipped(t *Type) {
+	if skipped := c.skipped(t); len(skipped) > 0 {
+		c.printf("//\n// These fields can't be converted, and are skipped: %v.\n", skipped)
+	}
+}
+
+func (c *converter) unmarshalType(t *Type) {
+	name := t.Object.Name()
+	c.printf("\n// UnmarshalProperties sets the fields of a %s from a property map. It returns the paths of the "+
+		"properties\n// that held secret values, which are unwrapped, and of those whose values are unknown, "+
+		"which are left unset.\n", name)
+	c.docSkipped(t)
+	c.printf("func (v *%s) UnmarshalProperties(pm resource.PropertyMap) (secrets, unknowns []string, err error) {\n"+
+		"\tvar r mkschemaProperties\n\tv.unmarshalProperties(\"\", pm, &r)\n\treturn r.result()\n}\n", name)
+
+	c.printf("\nfunc (v *%s) unmarshalProperties(path string, pm resource.PropertyMap, "+
+		"r *mkschemaProperties) {\n", name)
+	for _, p := range t.Properties {
+		if !c.convertible(p.Field.Type()) {
+			continue
+		}
+		c.printf("\tif pv, has := pm[%q]; has && !pv.IsNull() {\n\tpath := mkschemaPath(path, %q)\n", p.Name, p.Name)
+		c.unmarshalValue("v."+p.Field.Name(), p.Field.Type(), "pv", 0)
+		if p.Options.Optional || p.Options.Out {
+			c.printf("\t}\n")
+		} else {
+			c.printf("\t} else {\n\t\tr.missing(mkschemaPath(path, %q))\n\t}\n", p.Name)
+		}
+	}
+	c.printf("}\n")
+}
+
+// unmarshalValue generates the code that sets dst, of type t, from the property value in the variable pv, whose
+// path is in the variable path. Nested values use variables suffixed by their depth.
+func (c *converter) unmarshalValue(dst string, t types.Type, pv string, depth int) {
+	c.printf("\tif %s, ok := r.unwrap(path, %s); ok {\n", pv, pv)
+	c.unmarshalKnownValue(dst, t, pv, depth)
+	c.printf("\t}\n")
+}
+
+// unmarshalKnownValue is unmarshalValue for a property value that has already been unwrapped.
+func (c *converter) unmarshalKnownValue(dst string, t types.Type, pv string, depth int) {
+	d := strconv.Itoa(depth)
+	switch ut := t.Underlying().(type) {
+	case *types.Basic:
+		var is, value, want string
+		switch {
+		case ut.Info()&types.IsBoolean != 0:
+			is, value, want = "IsBool", "BoolValue", "a bool"
+		case ut.Info()&types.IsNumeric != 0:
+			is, value, want = "IsNumber", "NumberValue", "a number"
+		default:
+			is, value, want = "IsString", "StringValue", "a string"
+		}
+		c.printf("\tif !%s.%s() {\n\t\tr.typeError(path, %q, %s)\n\t} else {\n\t\t%s = %s(%s.%s())\n\t}\n",
+			pv, is, want, pv, dst, types.TypeString(t, c.qualifier), pv, value)
+	case *types.Interface:
+		c.printf("\t%s = %s.Mappable()\n", dst, pv)
+	case *types.Pointer:
+		c.printf("\tvar e%s %s\n", d, types.TypeString(ut.Elem(), c.qualifier))
+		c.unmarshalKnownValue("e"+d, ut.Elem(), pv, depth+1)
+		c.printf("\t%s = &e%s\n", dst, d)
+	case *types.Slice:
+		c.printf("\tif !%s.IsArray() {\n\t\tr.typeError(path, \"an array\", %s)\n\t} else {\n", pv, pv)
+		c.printf("\ts%s := make(%s, len(%s.ArrayValue()))\n", d, types.TypeString(t, c.qualifier), pv)
+		c.printf("\tfor i%s, pv%s := range %s.ArrayValue() {\n", d, d, pv)
+		c.printf("\tpath := fmt.Sprintf(\"%%s[%%d]\", path, i%s)\n", d)
+		c.unmarshalValue(fmt.Sprintf("s%s[i%s]", d, d), ut.Elem(), "pv"+d, depth+1)
+		c.printf("\t}\n\t%s = s%s\n\t}\n", dst, d)
+	case *types.Map:
+		c.printf("\tif !%s.IsObject() {\n\t\tr.typeError(path, \"an object\", %s)\n\t} else {\n", pv, pv)
+		c.printf("\tm%s := make(%s, len(%s.ObjectValue()))\n", d, types.TypeString(t, c.qualifier), pv)
+		c.printf("\tfor k%s, pv%s := range %s.ObjectValue() {\n", d, d, pv)
+		c.printf("\tpath := mkschemaPath(path, string(k%s))\n", d)
+		c.printf("\tvar e%s %s\n", d, types.TypeString(ut.Elem(), c.qualifier))
+		c.unmarshalValue("e"+d, ut.Elem(), "pv"+d, depth+1)
+		c.printf("\tm%s[%s(k%s)] = e%s\n\t}\n\t%s = m%s\n\t}\n", d, types.TypeString(ut.Key(), c.qualifier), d, d,
+			dst, d)
+	case *types.Struct:
+		c.printf("\tif !%s.IsObject() {\n\t\tr.typeError(path, \"an object\", %s)\n\t} else {\n", pv, pv)
+		c.printf("\t%s.unmarshalProperties(path, %s.ObjectValue(), r)\n\t}\n", dst, pv)
+	}
+}
+
+func (c *converter) marshalType(t *Type) {
+	name := t.Object.Name()
+	c.printf("\n// MarshalProperties returns the fields of a %s as a property map, omitting unset optional "+
+		"properties.\n// The values of the properties at the given paths, as returned by UnmarshalProperties, are "+
+		"marked secret.\n", name)
+	c.docSkipped(t)
+	c.printf("func (v *%s) MarshalProperties(secrets []string) resource.PropertyMap {\n"+
+		"\tsecret := make(map[string]bool, len(secrets))\n\tfor _, s := range secrets {\n\t\tsecret[s] = true\n\t}\n"+
+		"\treturn v.marshalProperties(\"\", secret)\n}\n", name)
+
+	c.printf("\nfunc (v *%s) marshalProperties(path string, secret map[string]bool) resource.PropertyMap {\n", name)
+	c.printf("\tpm := resource.PropertyMap{}\n")
+	for _, p := range t.Properties {
+		ft := p.Field.Type()
+		if !c.convertible(ft) {
+			continue
+		}
+		src := "v." + p.Field.Name()
+		if ptr, isPtr := ft.(*types.Pointer); isPtr {
+			c.printf("\tif %s != nil {\n", src)
+			src, ft = "(*"+src+")", ptr.Elem()
+		} else {
+			c.printf("\t{\n")
+		}
+		c.printf("\tpath := mkschemaPath(path, %q)\n", p.Name)
+		c.marshalValue(fmt.Sprintf("pm[%q]", p.Name), src, ft, 0)
+		c.printf("\t}\n")
+	}
+	c.printf("\treturn pm\n}\n")
+}
+
+// marshalValue generates the code that sets dst to the property value of src, of type t, whose path is in the
+// variable path. Nested values use variables suffixed by their depth.
+func (c *converter) marshalValue(dst, src string, t types.Type, depth int) {
+	d := strconv.Itoa(depth)
+	switch ut := t.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case ut.Info()&types.IsBoolean != 0:
+			c.printf("\t%s = resource.NewBoolProperty(bool(%s))\n", dst, src)
+		case ut.Info()&types.IsNumeric != 0:
+			c.printf("\t%s = resource.NewNumberProperty(float64(%s))\n", dst, src)
+		default:
+			c.printf("\t%s = resource.NewStringProperty(string(%s))\n", dst, src)
+		}
+	case *types.Interface:
+		c.printf("\t%s = resource.NewPropertyValue(%s)\n", dst, src)
+	case *types.Pointer:
+		c.printf("\tif %s == nil {\n\t\t%s = resource.NewNullProperty()\n\t} else {\n", src, dst)
+		c.marshalValue(dst, "(*"+src+")", ut.Elem(), depth)
+		c.printf("\t}\n")
+		return // the element was already marked secret, if need be.
+	case *types.Slice:
+		c.printf("\ta%s := make([]resource.PropertyValue, len(%s))\n", d, src)
+		c.printf("\tfor i%s, e%s := range %s {\n\tpath := fmt.Sprintf(\"%%s[%%d]\", path, i%s)\n", d, d, src, d)
+		c.marshalValue(fmt.Sprintf("a%s[i%s]", d, d), "e"+d, ut.Elem(), depth+1)
+		c.printf("\t}\n\t%s = resource.NewArrayProperty(a%s)\n", dst, d)
+	case *types.Map:
+		c.printf("\to%s := resource.PropertyMap{}\n", d)
+		c.printf("\tfor k%s, e%s := range %s {\n\tpath := mkschemaPath(path, string(k%s))\n", d, d, src, d)
+		c.marshalValue(fmt.Sprintf("o%s[resource.PropertyKey(k%s)]", d, d), "e"+d, ut.Elem(), depth+1)
+		c.printf("\t}\n\t%s = resource.NewObjectProperty(o%s)\n", dst, d)
+	case *types.Struct:
+		c.printf("\t%s = resource.NewObjectProperty(%s.marshalProperties(path, secret))\n", dst, src)
+	}
+	c.printf("\tif secret[path] {\n\t\t%s = resource.MakeSecret(%s)\n\t}\n", dst, dst)
+}
+
+// qualifier writes the types of the package being converted unqualified; they're the only named types converted.
+func (c *converter) qualifier(pkg *types.Package) string {
+	if pkg == c.pkg {
+		return ""
+	}
+	return pkg.Name()
+}
+
+// conversionSupport is the support code shared by the generated conversion helpers.
+const conversionSupport = `
+// mkschemaProperties accumulates what's found while unmarshaling a property map.
+type mkschemaProperties struct {
+	secrets, unknowns, errs []string
+}
+
+// unwrap unwraps a secret or output value, recording its path if it's secret or unknown. It returns false if the
+// value is unknown.
+func (r *mkschemaProperties) unwrap(path string, pv resource.PropertyValue) (resource.PropertyValue, bool) {
+	for {
+		switch {
+		case pv.IsSecret():
+			r.secrets = append(r.secrets, path)
+			pv = pv.SecretValue().Element
+		case pv.IsOutput():
+			o := pv.OutputValue()
+			if o.Secret {
+				r.secrets = append(r.secrets, path)
+			}
+			if !o.Known {
+				r.unknowns = append(r.unknowns, path)
+				return pv, false
+			}
+			pv = o.Element
+		case pv.IsComputed():
+			r.unknowns = append(r.unknowns, path)
+			return pv, false
+		default:
+			return pv, true
+		}
+	}
+}
+
+func (r *mkschemaProperties) typeError(path, want string, pv resource.PropertyValue) {
+	r.errs = append(r.errs, fmt.Sprintf("%s: expected %s, got %s", path, want, pv.TypeString()))
+}
+
+func (r *mkschemaProperties) missing(path string) {
+	r.errs = append(r.errs, fmt.Sprintf("%s: missing required property", path))
+}
+
+func (r *mkschemaProperties) result() (secrets, unknowns []string, err error) {
+	if len(r.errs) > 0 {
+		err = fmt.Errorf("%s", strings.Join(r.errs, "; "))
+	}
+	return r.secrets, r.unknowns, err
+}
+
+// mkschemaPath returns the path of a property nested within the one at the given path.
+func mkschemaPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+`
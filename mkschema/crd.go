@@ -0,0 +1,290 @@
+package mkschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"gopkg.in/yaml.v2"
+)
+
+// CRDOptions select what CRDSchema converts from Kubernetes CustomResourceDefinitions.
+type CRDOptions struct {
+	// Name is the Pulumi package's name, which defaults to the first label of the first definition's group, as in
+	// `example` for `example.com`.
+	Name string
+	// Version is the version of each definition to convert, which defaults to the one it's stored as.
+	Version string
+	// Kinds are the kinds of the definitions to convert. If there are none, every definition is converted.
+	Kinds []string
+}
+
+// crd is a CustomResourceDefinition, of apiextensions.k8s.io/v1, or, for its top-level schema, v1beta1.
+type crd struct {
+	Kind string  `json:"kind"`
+	Spec crdSpec `json:"spec"`
+}
+
+// crdSpec is the specification of a CustomResourceDefinition.
+type crdSpec struct {
+	Group      string         `json:"group"`
+	Names      crdNames       `json:"names"`
+	Versions   []crdVersion   `json:"versions"`
+	Validation *crdValidation `json:"validation"` // the schema of every version, in v1beta1.
+}
+
+// crdNames are the names of a custom resource.
+type crdNames struct {
+	Kind string `json:"kind"`
+}
+
+// crdVersion is a version of a custom resource.
+type crdVersion struct {
+	Name    string         `json:"name"`
+	Storage bool           `json:"storage"`
+	Schema  *crdValidation `json:"schema"`
+}
+
+// crdValidation holds a custom resource's schema.
+type crdValidation struct {
+	OpenAPIV3Schema *openAPISchema `json:"openAPIV3Schema"`
+}
+
+// openAPISchema is the subset of an OpenAPI v3 schema that Kubernetes allows for custom resources.
+type openAPISchema struct {
+	Type                 string                    `json:"type"`
+	Description          string                    `json:"description"`
+	Properties           map[string]*openAPISchema `json:"properties"`
+	AdditionalProperties json.RawMessage           `json:"additionalProperties"` // a schema, or a boolean.
+	Items                *openAPISchema            `json:"items"`
+	Required             []string                  `json:"required"`
+	Default              interface{}               `json:"default"`
+	IntOrString          bool                      `json:"x-kubernetes-int-or-string"`
+}
+
+// CRDSchema converts the Kubernetes CustomResourceDefinitions in a YAML, or JSON, stream, such as an operator's
+// bundle, to a Pulumi package schema, to be scaffolded into annotated Go structs by Import, as a start on components
+// wrapping the operator's custom resources. Documents of other kinds are skipped.
+//
+// Each definition's OpenAPI v3 schema becomes a component resource named after its kind, whose properties are those
+// of the custom resource other than `apiVersion`, `kind`, and `metadata`, such as `spec`, and, as an output only,
+// `status`. Each object with properties becomes an object type named after the resource and the properties it's
+// nested in, such as `CertificateSpecSecretTemplate`; objects with only additional properties become maps, and those
+// with neither, such as those preserving unknown fields, become `pulumi.json#/Any`.
+func CRDSchema(b []byte, opts CRDOptions) (*schema.PackageSpec, error) {
+	var crds []crd
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	for {
+		var doc interface{}
+		if err := dec.Decode(&doc); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, errors.Wrap(err, "parsing CustomResourceDefinitions")
+		}
+		// The YAML is re-encoded as JSON, as ReadMetadata does, to be decoded by the definitions' JSON names.
+		j, err := json.Marshal(jsonValue(doc))
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing CustomResourceDefinitions")
+		}
+		var def crd
+		if err = json.Unmarshal(j, &def); err != nil {
+			return nil, errors.Wrap(err, "parsing CustomResourceDefinitions")
+		}
+		if def.Kind == "CustomResourceDefinition" && crdSelected(def.Spec.Names.Kind, opts.Kinds) {
+			crds = append(crds, def)
+		}
+	}
+	if len(crds) == 0 {
+		return nil, errors.New("found no CustomResourceDefinitions to convert")
+	}
+
+	name := opts.Name
+	if name == "" {
+		name = crds[0].Spec.Group
+		if dot := strings.IndexByte(name, '.'); dot != -1 {
+			name = name[:dot]
+		}
+	}
+	c := &crdConverter{
+		spec:   &schema.PackageSpec{Name: name, Resources: map[string]schema.ResourceSpec{}},
+		tokens: &scaffoldTokens{pkg: name, taken: make(map[string]bool)},
+	}
+	// The resources' names are claimed before any of their types', which are named after them.
+	tokens := make([]string, len(crds))
+	for i, def := range crds {
+		tokens[i] = c.tokens.claim(goName(def.Spec.Names.Kind))
+	}
+	for i, def := range crds {
+		if err := c.resource(tokens[i], def, opts.Version); err != nil {
+			return nil, errors.Wrapf(err, "converting CustomResourceDefinition %s", def.Spec.Names.Kind)
+		}
+	}
+	return c.spec, nil
+}
+
+// crdSelected returns true if a kind is one of the given ones, or if there are none.
+func crdSelected(kind string, kinds []string) bool {
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return len(kinds) == 0
+}
+
+// crdConverter holds the state of a CRDSchema conversion.
+type crdConverter struct {
+	spec   *schema.PackageSpec
+	tokens *scaffoldTokens
+}
+
+// resource declares a component resource of the given token from a version of a CustomResourceDefinition, or from
+// its stored version, if none is given.
+func (c *crdConverter) resource(tok string, def crd, version string) error {
+	var v *crdVersion
+	for i := range def.Spec.Versions {
+		if version == def.Spec.Versions[i].Name || version == "" && def.Spec.Versions[i].Storage {
+			v = &def.Spec.Versions[i]
+		}
+	}
+	if v == nil {
+		if version == "" {
+			return errors.New("no version is marked as the stored one")
+		}
+		return errors.Errorf("no version %s is defined", version)
+	}
+	validation := v.Schema
+	if validation == nil {
+		validation = def.Spec.Validation
+	}
+	if validation == nil || validation.OpenAPIV3Schema == nil {
+		return errors.Errorf("version %s has no OpenAPI v3 schema", v.Name)
+	}
+
+	name := tok[strings.LastIndex(tok, ":")+1:]
+	s := validation.OpenAPIV3Schema
+	res := schema.ResourceSpec{
+		ObjectTypeSpec: schema.ObjectTypeSpec{
+			Description: fmt.Sprintf("%s wraps the %s custom resource of %s/%s.", name, def.Spec.Names.Kind,
+				def.Spec.Group, v.Name),
+			Type:       "object",
+			Properties: map[string]schema.PropertySpec{},
+		},
+		InputProperties: map[string]schema.PropertySpec{},
+		IsComponent:     true,
+	}
+	if s.Description != "" {
+		res.Description += "\n\n" + s.Description
+	}
+	required := stringSet(s.Required)
+	for _, prop := range sortedKeys(s.Properties) {
+		if prop == "apiVersion" || prop == "kind" || prop == "metadata" {
+			continue
+		}
+		spec, err := c.property(name, prop, s.Properties[prop])
+		if err != nil {
+			return errors.Wrapf(err, "property %s", prop)
+		}
+		res.Properties[prop] = spec
+		if required[prop] {
+			res.Required = append(res.Required, prop)
+		}
+		if prop != "status" {
+			res.InputProperties[prop] = spec
+			if required[prop] {
+				res.RequiredInputs = append(res.RequiredInputs, prop)
+			}
+		}
+	}
+	c.spec.Resources[tok] = res
+	return nil
+}
+
+// property converts the schema of a property of the resource or type of the given name, declaring object types named
+// after both for any objects within it.
+func (c *crdConverter) property(parent, prop string, s *openAPISchema) (schema.PropertySpec, error) {
+	t, err := c.typ(parent+goName(prop), "the "+prop+" property of "+parent, s)
+	if err != nil {
+		return schema.PropertySpec{}, err
+	}
+	spec := schema.PropertySpec{TypeSpec: t, Description: s.Description}
+	switch s.Default.(type) {
+	case string, bool, float64:
+		spec.Default = s.Default
+	}
+	return spec, nil
+}
+
+// typ converts a schema, declaring object types of the given name, described as what they are, for any objects within
+// it.
+func (c *crdConverter) typ(name, what string, s *openAPISchema) (schema.TypeSpec, error) {
+	if s == nil {
+		return schema.TypeSpec{Ref: "pulumi.json#/Any"}, nil
+	} else if s.IntOrString {
+		return schema.TypeSpec{OneOf: []schema.TypeSpec{{Type: "integer"}, {Type: "string"}}}, nil
+	}
+	switch s.Type {
+	case "string", "integer", "number", "boolean":
+		return schema.TypeSpec{Type: s.Type}, nil
+	case "array":
+		items, err := c.typ(name, "an item of "+what, s.Items)
+		if err != nil {
+			return schema.TypeSpec{}, err
+		}
+		return schema.TypeSpec{Type: "array", Items: &items}, nil
+	case "object", "":
+		if len(s.Properties) > 0 {
+			tok, err := c.objectType(name, what, s)
+			if err != nil {
+				return schema.TypeSpec{}, err
+			}
+			return schema.TypeSpec{Ref: "#/types/" + tok}, nil
+		}
+		var additional *openAPISchema
+		if len(s.AdditionalProperties) > 0 && json.Unmarshal(s.AdditionalProperties, &additional) == nil &&
+			additional != nil {
+			elem, err := c.typ(name, "a value of "+what, additional)
+			if err != nil {
+				return schema.TypeSpec{}, err
+			}
+			return schema.TypeSpec{Type: "object", AdditionalProperties: &elem}, nil
+		}
+		return schema.TypeSpec{Ref: "pulumi.json#/Any"}, nil
+	}
+	return schema.TypeSpec{}, errors.Errorf("unknown type %q", s.Type)
+}
+
+// objectType declares an object type of the given name from an object's schema, returning its token. Without a
+// description, it's described as what it is, as in "the spec property of Certificate".
+func (c *crdConverter) objectType(name, what string, s *openAPISchema) (string, error) {
+	tok := c.tokens.claim(name)
+	name = tok[strings.LastIndex(tok, ":")+1:]
+	obj := schema.ObjectTypeSpec{
+		Description: s.Description,
+		Type:        "object",
+		Properties:  map[string]schema.PropertySpec{},
+	}
+	if obj.Description == "" {
+		obj.Description = name + " is " + what + "."
+	}
+	required := stringSet(s.Required)
+	for _, prop := range sortedKeys(s.Properties) {
+		spec, err := c.property(name, prop, s.Properties[prop])
+		if err != nil {
+			return "", errors.Wrapf(err, "property %s", prop)
+		}
+		obj.Properties[prop] = spec
+		if required[prop] {
+			obj.Required = append(obj.Required, prop)
+		}
+	}
+	if c.spec.Types == nil {
+		c.spec.Types = map[string]schema.ComplexTypeSpec{}
+	}
+	c.spec.Types[tok] = schema.ComplexTypeSpec{ObjectTypeSpec: obj}
+	return tok, nil
+}
@@ -0,0 +1,184 @@
+package mkschema
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"strings"
+)
+
+// fieldDefaults returns the defaults of a struct's fields, indexed by field name, as set to constant values by the
+// struct's `Defaults` method, or by a default variable of it, so that the schema's defaults are the ones its
+// runtime code applies. The method's take precedence.
+func (g *generator) fieldDefaults(t *types.TypeName) map[string]interface{} {
+	if defaults, has := g.defaults[t]; has {
+		return defaults
+	}
+	defaults := make(map[string]interface{})
+	if g.Info != nil {
+		g.varDefaults(t, defaults)
+		g.methodDefaults(t, defaults)
+	}
+	if g.defaults == nil {
+		g.defaults = make(map[*types.TypeName]map[string]interface{})
+	}
+	g.defaults[t] = defaults
+	return defaults
+}
+
+// varDefaults records the defaults of the fields of a struct that are given constant values by the composite literal
+// that initializes its default variable, as in `var DefaultBucketArgs = BucketArgs{Region: "us-west-2"}`.
+func (g *generator) varDefaults(t *types.TypeName, defaults map[string]interface{}) {
+	v := g.defaultsVar(t)
+	if v == nil {
+		return
+	}
+	for _, file := range g.Files {
+		for _, decl := range file.Decls {
+			gdecl, ok := decl.(*ast.GenDecl)
+			if !ok || gdecl.Tok != token.VAR {
+				continue
+			}
+			for _, spec := range gdecl.Specs {
+				vspec := spec.(*ast.ValueSpec)
+				for i, name := range vspec.Names {
+					if g.Info.Defs[name] != v || i >= len(vspec.Values) {
+						continue
+					}
+					value := vspec.Values[i]
+					if addr, ok := value.(*ast.UnaryExpr); ok && addr.Op == token.AND {
+						value = addr.X
+					}
+					lit, ok := value.(*ast.CompositeLit)
+					if !ok {
+						return
+					}
+					for _, elt := range lit.Elts {
+						kv, ok := elt.(*ast.KeyValueExpr)
+						if !ok {
+							continue
+						}
+						key, ok := kv.Key.(*ast.Ident)
+						if !ok {
+							continue
+						}
+						if c := g.constExpr(kv.Value, nil); c != nil {
+							defaults[key.Name] = constantValue(c)
+						}
+					}
+					return
+				}
+			}
+		}
+	}
+}
+
+// defaultsVar returns a struct's default variable, if it has one: the package-level variable of it named `Default`
+// followed by its name, or by its name less an `Args` suffix, as in `DefaultBucket` for `BucketArgs`.
+func (g *generator) defaultsVar(t *types.TypeName) *types.Var {
+	scope := g.Pkg.Scope()
+	for _, name := range []string{"Default" + t.Name(), "Default" + strings.TrimSuffix(t.Name(), "Args")} {
+		if v, ok := scope.Lookup(name).(*types.Var); ok && isStructOf(v.Type(), t) {
+			return v
+		}
+	}
+	return nil
+}
+
+// defaultsMethod returns a struct's `Defaults` method, if it has one declared in the target package.
+func (g *generator) defaultsMethod(t *types.TypeName) *types.Func {
+	obj, _, _ := types.LookupFieldOrMethod(types.NewPointer(t.Type()), false, g.Pkg, "Defaults")
+	if method, ok := obj.(*types.Func); ok && method.Pkg() == g.Pkg {
+		return method
+	}
+	return nil
+}
+
+// methodDefaults records the defaults of the fields of a struct that are assigned constant values by its `Defaults`
+// method, which runtime code calls to fill in unset fields, such as `a.Region = "us-west-2"`. A pointer field may be
+// assigned the address of a local variable holding a constant, or the result of a helper that returns a pointer to a
+// constant, as in `a.Region = pulumi.StringRef("us-west-2")`. Assignments of anything else aren't defaults the schema
+// can carry, and are left alone.
+func (g *generator) methodDefaults(t *types.TypeName, defaults map[string]interface{}) {
+	method := g.defaultsMethod(t)
+	if method == nil {
+		return
+	}
+	for _, file := range g.Files {
+		for _, decl := range file.Decls {
+			fdecl, ok := decl.(*ast.FuncDecl)
+			if !ok || fdecl.Body == nil || g.Info.Defs[fdecl.Name] != method {
+				continue
+			}
+
+			// Walk the method's statements in order, recording local variables' constant values along the way.
+			locals := make(map[types.Object]constant.Value)
+			ast.Inspect(fdecl.Body, func(n ast.Node) bool {
+				switch n := n.(type) {
+				case *ast.ValueSpec:
+					for i, name := range n.Names {
+						if i < len(n.Values) {
+							if c := g.constExpr(n.Values[i], locals); c != nil {
+								locals[g.Info.Defs[name]] = c
+							}
+						}
+					}
+				case *ast.AssignStmt:
+					if len(n.Lhs) != len(n.Rhs) {
+						return true
+					}
+					for i, lhs := range n.Lhs {
+						c := g.constExpr(n.Rhs[i], locals)
+						switch lhs := lhs.(type) {
+						case *ast.Ident:
+							if obj := g.Info.ObjectOf(lhs); obj != nil && c != nil {
+								locals[obj] = c
+							}
+						case *ast.SelectorExpr:
+							sel, ok := g.Info.Selections[lhs]
+							if ok && sel.Kind() == types.FieldVal && len(sel.Index()) == 1 &&
+								isStructOf(sel.Recv(), t) && c != nil {
+								defaults[sel.Obj().Name()] = constantValue(c)
+							}
+						}
+					}
+				}
+				return true
+			})
+			return
+		}
+	}
+}
+
+// constExpr returns the constant value of an expression, if it has one: a constant expression, the address of a local
+// variable holding a constant, or a call of a helper that returns a pointer to its one, constant, argument.
+func (g *generator) constExpr(expr ast.Expr, locals map[types.Object]constant.Value) constant.Value {
+	if tv, has := g.Info.Types[expr]; has && tv.Value != nil {
+		return tv.Value
+	}
+	switch e := expr.(type) {
+	case *ast.ParenExpr:
+		return g.constExpr(e.X, locals)
+	case *ast.UnaryExpr:
+		if id, ok := e.X.(*ast.Ident); ok && e.Op == token.AND {
+			return locals[g.Info.ObjectOf(id)]
+		}
+	case *ast.CallExpr:
+		if _, isPtr := g.Info.TypeOf(e).(*types.Pointer); isPtr && len(e.Args) == 1 {
+			if tv, has := g.Info.Types[e.Args[0]]; has && tv.Value != nil {
+				return tv.Value
+			}
+		}
+	}
+	return nil
+}
+
+// isStructOf returns true if a type is the given named type, or a pointer to it.
+func isStructOf(typ types.Type, t *types.TypeName) bool {
+	if ptr, ok := typ.(*types.Pointer); ok {
+		typ = ptr.Elem()
+	}
+	named, ok := typ.(*types.Named)
+	return ok && named.Obj() == t
+}
@@ -0,0 +1,332 @@
+package mkschema
+
+import (
+	"fmt"
+	"go/token"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Severity classifies a diagnostic.
+type Severity int
+
+const (
+	// SeverityError is a problem that fails generation.
+	SeverityError Severity = iota
+	// SeverityWarning is a problem that doesn't fail generation, such as a missing description, or one that was
+	// skipped over in best-effort mode.
+	SeverityWarning
+	// SeverityNote is information that may help explain the schema, which never fails generation.
+	SeverityNote
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	case SeverityNote:
+		return "note"
+	default:
+		return fmt.Sprintf("severity(%d)", int(s))
+	}
+}
+
+// Color returns the ANSI SGR parameters that terminals highlight the severity with: bold red for errors, bold
+// yellow for warnings, and bold cyan for notes.
+func (s Severity) Color() string {
+	switch s {
+	case SeverityError:
+		return "1;31"
+	case SeverityWarning:
+		return "1;33"
+	default:
+		return "1;36"
+	}
+}
+
+// Colorize wraps s in the given ANSI SGR parameters, so that terminals highlight it.
+func Colorize(params, s string) string {
+	return "\x1b[" + params + "m" + s + "\x1b[0m"
+}
+
+// Diagnostic is a structured report of a problem found while gathering a package.
+type Diagnostic struct {
+	Severity Severity       // how serious the problem is.
+	Pos      token.Position // the position in the Go source, if known.
+	Type     string         // the Go type the problem was found in.
+	Field    string         // the Go field the problem was found in, if it was specific to one.
+	Message  string         // a description of the problem, without its position.
+	End      token.Position // the end of the offending source, such as a field name or tag, if known.
+	Rule     string         // the rule that was broken, such as "optional properties must be pointers", if any.
+	Code     string         // the rule's stable code, such as MKS005, by which it can be suppressed, if any.
+	// Suggestion is how the problem might be fixed, such as "make the field a pointer", if known.
+	Suggestion string
+	// Fixes are the edits to the source that fix the problem mechanically, if there are any; see ApplyFixes.
+	Fixes []Edit
+
+	at token.Pos // the position within the generator's file set, if known.
+}
+
+func (d Diagnostic) String() string {
+	if d.Pos.IsValid() {
+		return fmt.Sprintf("%s: %s:%d,%d: %s", d.Severity, d.Pos.Filename, d.Pos.Line, d.Pos.Column, d.Message)
+	}
+	return fmt.Sprintf("%s: %s", d.Severity, d.Message)
+}
+
+// Render renders the diagnostic for a terminal, as compilers do: its message, followed by the offending source line
+// with a caret under the offending field or tag, the rule that was broken, and how to fix it, if known. The source is
+// read from disk; if it can't be, only the position is shown.
+func (d Diagnostic) Render() string {
+	return d.render(false)
+}
+
+// RenderColor renders the diagnostic as Render does, highlighted with ANSI colors by its severity.
+func (d Diagnostic) RenderColor() string {
+	return d.render(true)
+}
+
+func (d Diagnostic) render(color bool) string {
+	style := func(params, s string) string {
+		if !color {
+			return s
+		}
+		return Colorize(params, s)
+	}
+	const frame = "1;34" // the arrow, gutter, and rule are bold blue.
+
+	var b strings.Builder
+	label := d.Severity.String()
+	if d.Code != "" {
+		label += "[" + d.Code + "]"
+	}
+	fmt.Fprintf(&b, "%s%s", style(d.Severity.Color(), label), style("1", ": "+d.Message))
+	if !d.Pos.IsValid() {
+		if d.Rule != "" {
+			fmt.Fprintf(&b, "\n  %s rule: %s", style(frame, "="), d.Rule)
+		}
+		if d.Suggestion != "" {
+			fmt.Fprintf(&b, "\n  %s help: %s", style(frame, "="), d.Suggestion)
+		}
+		return b.String()
+	}
+
+	// The arrow, snippet, and rule are lined up with the gutter holding the line number.
+	gutter := strconv.Itoa(d.Pos.Line)
+	pad := strings.Repeat(" ", len(gutter))
+	fmt.Fprintf(&b, "\n%s%s %s:%d:%d", pad, style(frame, "-->"), d.Pos.Filename, d.Pos.Line, d.Pos.Column)
+	if line, ok := sourceLine(d.Pos.Filename, d.Pos.Line); ok {
+		// Expand tabs, so that the caret lines up however wide the terminal shows them, and underline up to the end
+		// of the offending source, or of the line if it continues beyond it.
+		start, end := d.Pos.Column-1, len(line)
+		if d.End.IsValid() && d.End.Filename == d.Pos.Filename && d.End.Line == d.Pos.Line {
+			end = d.End.Column - 1
+		}
+		if start > len(line) {
+			start = len(line)
+		}
+		if end > len(line) {
+			end = len(line)
+		}
+		prefix := expandTabs(line[:start], 0)
+		underlined := expandTabs(line[start:end], len(prefix))
+		width := len(underlined)
+		if width == 0 {
+			width = 1
+		}
+
+		fmt.Fprintf(&b, "\n%s", style(frame, pad+" |"))
+		fmt.Fprintf(&b, "\n%s %s", style(frame, gutter+" |"), expandTabs(line, 0))
+		fmt.Fprintf(&b, "\n%s %s%s", style(frame, pad+" |"), strings.Repeat(" ", len(prefix)),
+			style(d.Severity.Color(), strings.Repeat("^", width)))
+	}
+	if d.Rule != "" {
+		fmt.Fprintf(&b, "\n%s %s rule: %s", pad, style(frame, "="), d.Rule)
+	}
+	if d.Suggestion != "" {
+		fmt.Fprintf(&b, "\n%s %s help: %s", pad, style(frame, "="), d.Suggestion)
+	}
+	return b.String()
+}
+
+// sourceLine returns the given 1-based line of a file, without its line ending.
+func sourceLine(file string, line int) (string, bool) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return "", false
+	}
+	lines := strings.Split(string(b), "\n")
+	if line < 1 || line > len(lines) {
+		return "", false
+	}
+	return strings.TrimRight(lines[line-1], "\r"), true
+}
+
+// expandTabs replaces the tabs in s with spaces, up to the next multiple of four columns, given that s starts at the
+// given column.
+func expandTabs(s string, col int) string {
+	if !strings.Contains(s, "\t") {
+		return s
+	}
+	var b strings.Builder
+	for _, r := range s {
+		if r == '\t' {
+			n := 4 - col%4
+			b.WriteString(strings.Repeat(" ", n))
+			col += n
+			continue
+		}
+		b.WriteRune(r)
+		col++
+	}
+	return b.String()
+}
+
+// DiagnosticSink receives diagnostics as soon as they're found, rather than once generation is over, for instance to
+// give live feedback in an editor or to report progress through a large package. It is called synchronously.
+type DiagnosticSink func(d Diagnostic)
+
+// posError is an error attributed to a position in the Go source.
+type posError struct {
+	Pos        token.Position
+	End        token.Position
+	Msg        string
+	Rule       string
+	Code       string
+	Suggestion string
+	Fixes      []Edit
+
+	at token.Pos
+}
+
+func (e *posError) Error() string {
+	return fmt.Sprintf("%s:%d,%d: %s", e.Pos.Filename, e.Pos.Line, e.Pos.Column, e.Msg)
+}
+
+// errorf creates an error attributed to the position of the given Go element.
+func (g *generator) errorf(elem goPos, format string, args ...interface{}) error {
+	return g.ruleErrorf(elem, rule{}, format, args...)
+}
+
+// ruleErrorf creates an error attributed to the position of the given Go element, for breaking the given rule. The
+// element's extent is that of its syntax, if it's a syntax node, or else of its name.
+func (g *generator) ruleErrorf(elem goPos, r rule, format string, args ...interface{}) error {
+	end := token.NoPos
+	switch e := elem.(type) {
+	case interface{ End() token.Pos }:
+		end = e.End()
+	case interface{ Name() string }:
+		end = elem.Pos() + token.Pos(len(e.Name()))
+	}
+	return &posError{
+		Pos:  g.Fset.Position(elem.Pos()),
+		End:  g.Fset.Position(end),
+		Msg:  fmt.Sprintf(format, args...),
+		Rule: r.text,
+		Code: r.code,
+		at:   elem.Pos(),
+	}
+}
+
+// report sends a problem with the given type, or a field within it, to the diagnostic sink, and records it so that
+// the caller can skip it and carry on. In best-effort mode, the problem is recorded as a failure; otherwise, it's
+// recorded as an error, to fail with once everything has been gathered, so that every problem is found in one run.
+// A suppressed problem isn't sent, but it's still recorded as a failure, since whatever it's in is skipped all the
+// same, so that the schema isn't missing anything without a *PartialError saying so.
+func (g *generator) report(typ, field string, err error) {
+	d := newDiagnostic(SeverityError, typ, field, err)
+	if g.suppressed(d) {
+		g.Failures = append(g.Failures, Failure{Type: typ, Field: field, Err: err})
+		return
+	}
+	if g.BestEffort {
+		d.Severity = SeverityWarning
+	}
+	if g.Diagnostics != nil {
+		g.Diagnostics(d)
+	}
+
+	if g.BestEffort {
+		g.Failures = append(g.Failures, Failure{Type: typ, Field: field, Err: err})
+	} else {
+		g.errors = append(g.errors, d)
+	}
+}
+
+// warn sends a problem that doesn't stop the type or field from being gathered, such as a missing description, to
+// the diagnostic sink as a warning.
+func (g *generator) warn(typ, field string, err error) {
+	if d := newDiagnostic(SeverityWarning, typ, field, err); g.Diagnostics != nil && !g.noWarnings && !g.suppressed(d) {
+		g.Diagnostics(d)
+	}
+}
+
+// newDiagnostic creates a diagnostic for a problem with the given type, or a field within it.
+func newDiagnostic(severity Severity, typ, field string, err error) Diagnostic {
+	d := Diagnostic{Severity: severity, Type: typ, Field: field, Message: err.Error()}
+	var perr *posError
+	if errors.As(err, &perr) {
+		d.Pos, d.End, d.Message, d.Rule, d.at = perr.Pos, perr.End, perr.Msg, perr.Rule, perr.at
+		d.Code, d.Suggestion, d.Fixes = perr.Code, perr.Suggestion, perr.Fixes
+	}
+	return d
+}
+
+// ErrorList is returned when gathering a package found errors, outside of best-effort mode. All of the errors are
+// gathered before failing, as a compiler does, rather than stopping at the first.
+type ErrorList struct {
+	Diagnostics []Diagnostic // the errors, in the order they were found.
+}
+
+// Error lists every error, sorted by position, so that those in the same file are grouped together.
+func (e *ErrorList) Error() string {
+	diags := e.Sorted()
+	var b strings.Builder
+	if len(diags) == 1 {
+		b.WriteString("found 1 error:")
+	} else {
+		fmt.Fprintf(&b, "found %d errors:", len(diags))
+	}
+	for i, d := range diags {
+		if i == 0 || d.Pos.Filename != diags[i-1].Pos.Filename {
+			b.WriteString("\n")
+		}
+		msg := d.Message
+		if d.Pos.IsValid() {
+			msg = fmt.Sprintf("%s:%d,%d: %s", d.Pos.Filename, d.Pos.Line, d.Pos.Column, d.Message)
+		} else if d.Type != "" {
+			msg = fmt.Sprintf("%s: %s", d.Type, d.Message)
+		}
+		b.WriteString("\n    " + msg)
+	}
+	return b.String()
+}
+
+// Sorted returns the errors sorted by position, so that those in the same file are grouped together.
+func (e *ErrorList) Sorted() []Diagnostic {
+	diags := append([]Diagnostic(nil), e.Diagnostics...)
+	sort.SliceStable(diags, func(i, j int) bool {
+		pi, pj := diags[i].Pos, diags[j].Pos
+		if pi.Filename != pj.Filename {
+			return pi.Filename < pj.Filename
+		} else if pi.Line != pj.Line {
+			return pi.Line < pj.Line
+		}
+		return pi.Column < pj.Column
+	})
+	return diags
+}
+
+// checkErrors returns an *ErrorList if any errors were reported.
+func (g *generator) checkErrors() error {
+	if len(g.errors) == 0 {
+		return nil
+	}
+	return &ErrorList{Diagnostics: g.errors}
+}
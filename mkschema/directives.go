@@ -0,0 +1,145 @@
+package mkschema
+
+import (
+	"go/ast"
+	"go/types"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// typeDirectivePrefix starts a directive in a type's doc comment that sets one of the type's options, as in
+// `//pschema:internal`. Go leaves directives out of the doc comment's text, and so out of the type's description.
+const typeDirectivePrefix = "//pschema:"
+
+// hasTypeDirective returns true if a type's doc comment has the given directive, such as `internal` for
+// `//pschema:internal`.
+func hasTypeDirective(node *ast.TypeSpec, name string) bool {
+	_, has := typeDirective(node, name)
+	return has
+}
+
+// typeDirective returns the argument of the given directive in a type's doc comment, as in `compute/instance` for
+// `//pschema:module compute/instance`, and whether the doc comment has the directive at all.
+func typeDirective(node *ast.TypeSpec, name string) (string, bool) {
+	if node == nil || node.Doc == nil {
+		return "", false
+	}
+	for _, c := range node.Doc.List {
+		if rest := strings.TrimPrefix(c.Text, typeDirectivePrefix+name); rest != c.Text &&
+			(rest == "" || unicode.IsSpace(rune(rest[0]))) {
+			return strings.TrimSpace(rest), true
+		}
+	}
+	return "", false
+}
+
+// isInternal returns true if a type in the target package is marked `//pschema:internal`: it's an implementation
+// detail, kept out of the schema, whose properties are inlined into the structs that embed it.
+func (g *generator) isInternal(t *types.TypeName) bool {
+	if t.Pkg() != g.Pkg {
+		return false
+	}
+	node, err := g.getTypeNode(t)
+	return err == nil && hasTypeDirective(node, "internal")
+}
+
+// embeddedInternal returns the internal struct type a field embeds, if it embeds one, directly or by pointer.
+func (g *generator) embeddedInternal(fld *types.Var) (*types.TypeName, *types.Struct) {
+	if !fld.Anonymous() {
+		return nil, nil
+	}
+	t := fld.Type()
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	if named, ok := t.(*types.Named); ok && g.isInternal(named.Obj()) {
+		if s, ok := named.Underlying().(*types.Struct); ok {
+			return named.Obj(), s
+		}
+	}
+	return nil, nil
+}
+
+// gatherInternalSchemas gathers the properties of an internal struct, to be inlined into one that embeds it, which is
+// a resource if isRes is true. Problems with them are reported against the internal struct, where they'd be fixed.
+func (g *generator) gatherInternalSchemas(t *types.TypeName, s *types.Struct, isRes bool) ([]*Property, error) {
+	node, err := g.getTypeNode(t)
+	if err != nil {
+		return nil, err
+	}
+	if g.inlining == nil {
+		g.inlining = make(map[*types.TypeName]bool)
+	}
+	g.inlining[t] = true
+	defer delete(g.inlining, t)
+	return g.gatherPropertySchemas(node, t, s, isRes)
+}
+
+// isResourceNode returns true if a struct is a resource: either it embeds pulumi.ResourceState, or it's marked
+// `//pschema:resource`, for component state that's shared with code that can't have it embed the resource state.
+func isResourceNode(node *ast.TypeSpec, t *types.TypeName, s *types.Struct) bool {
+	return IsResource(t, s) || hasTypeDirective(node, "resource")
+}
+
+// isComponent returns true if a type, which is a resource if isRes is true, is a component resource: either it's
+// marked `//pschema:component`, or resources are components by default and it isn't marked `//pschema:custom`.
+func (g *generator) isComponent(node *ast.TypeSpec, isRes bool) (bool, error) {
+	component, custom := hasTypeDirective(node, "component"), hasTypeDirective(node, "custom")
+	switch {
+	case !isRes && (component || custom):
+		err := g.ruleErrorf(node.Name, ruleTypeDirectives, "%v is marked as a component or custom resource, but "+
+			"isn't a resource", node.Name.Name)
+		return false, suggest(err, "embed pulumi.ResourceState, or mark it `//pschema:resource`")
+	case component && custom:
+		return false, g.ruleErrorf(node.Name, ruleTypeDirectives, "%v is marked as both a component and a custom "+
+			"resource", node.Name.Name)
+	}
+	return isRes && (component || !custom && !g.Metadata.CustomResources), nil
+}
+
+// excluded returns an error explaining why a type in the target package is left out of the schema, so that properties
+// can't refer to it, or nil if it isn't: it's internal, it's in preview and preview types weren't asked for, or it's
+// declared in a generated file and those are being skipped.
+func (g *generator) excluded(t *types.TypeName) error {
+	if t.Pkg() != g.Pkg {
+		return nil
+	}
+	node, err := g.getTypeNode(t)
+	if err != nil {
+		return nil
+	}
+	switch {
+	case hasTypeDirective(node, "internal"):
+		if _, isStruct := t.Type().Underlying().(*types.Struct); isStruct {
+			return errors.Errorf("%v is internal, so it isn't in the schema; embed it to inline its properties "+
+				"instead", t.Name())
+		}
+		return errors.Errorf("%v is internal, so it isn't in the schema", t.Name())
+	case !g.Preview && hasTypeDirective(node, "preview"):
+		return errors.Errorf("%v is in preview, so it isn't in the schema without -include-preview; only other "+
+			"preview types may refer to it", t.Name())
+	case g.SkipGenerated && g.isGenerated(t.Pos()):
+		return errors.Errorf("%v is declared in a generated file, so it isn't in the schema with -skip-generated",
+			t.Name())
+	}
+	return nil
+}
+
+// previewDescription prepends a notice that a type, or a resource if isRes is true, is experimental to its
+// description, if it's marked `//pschema:preview`.
+func (g *generator) previewDescription(node *ast.TypeSpec, isRes bool, description string) string {
+	if !hasTypeDirective(node, "preview") {
+		return description
+	}
+	kind := "type"
+	if isRes {
+		kind = "resource"
+	}
+	notice := "> **Preview:** this " + kind + " is experimental, and may change or be removed in a future release."
+	if description == "" {
+		return notice
+	}
+	return notice + "\n\n" + description
+}
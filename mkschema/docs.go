@@ -0,0 +1,83 @@
+package mkschema
+
+import (
+	"go/ast"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// docsDir is the directory, beside a package's source, of the long-form docs of its resources, each in a markdown
+// file named after the resource's Go type, as in docs/Bucket.md.
+const docsDir = "docs"
+
+// packageDir returns the directory of the package being gathered, or "" if it has no files.
+func (g *generator) packageDir() string {
+	if len(g.Files) == 0 {
+		return ""
+	}
+	return filepath.Dir(g.Fset.Position(g.Files[0].Pos()).Filename)
+}
+
+// resourceDocsFile returns the path of the long-form docs of the resource with the given Go type name, whether or not
+// they exist.
+func (g *generator) resourceDocsFile(name string) string {
+	return filepath.Join(g.packageDir(), docsDir, name+".md")
+}
+
+// resourceDocs returns the long-form docs of the resource with the given Go type name, which are "" if it has none.
+func (g *generator) resourceDocs(name string) (string, error) {
+	if g.packageDir() == "" || !g.mayRead(g.resourceDocsFile(name)) {
+		return "", nil
+	}
+	b, err := ioutil.ReadFile(g.resourceDocsFile(name))
+	if os.IsNotExist(err) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	return g.normalizeDescription(strings.TrimSpace(strings.ReplaceAll(string(b), "\r\n", "\n"))), nil
+}
+
+// appendDocs appends long-form docs to a resource's description, as a paragraph of their own.
+func appendDocs(description, docs string) string {
+	switch {
+	case docs == "":
+		return description
+	case description == "":
+		return docs
+	default:
+		return description + "\n\n" + docs
+	}
+}
+
+// markerPrefix starts a marker comment, which sets a schema option from a field's doc comment rather than its tag, as
+// in `// +pulumi:example=us-west-2`. Markers are left out of descriptions.
+const markerPrefix = "+pulumi:"
+
+// marker returns the value of the given marker in a doc comment, and whether it has the marker at all.
+func marker(doc *ast.CommentGroup, name string) (string, bool) {
+	for _, c := range doc.List {
+		text := strings.TrimSpace(strings.TrimPrefix(c.Text, "//"))
+		if strings.HasPrefix(text, markerPrefix+name+"=") {
+			return strings.TrimSpace(text[len(markerPrefix+name+"="):]), true
+		}
+	}
+	return "", false
+}
+
+// appendPropertyExample appends an example of a property's value to its description, as a paragraph of its own.
+func appendPropertyExample(description, example string) string {
+	if example == "" {
+		return description
+	}
+	fence := "`"
+	for strings.Contains(example, fence) {
+		fence += "`"
+	}
+	if strings.HasPrefix(example, "`") || strings.HasSuffix(example, "`") {
+		example = " " + example + " "
+	}
+	return appendDocs(description, "Example: "+fence+example+fence)
+}
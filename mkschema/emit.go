@@ -0,0 +1,125 @@
+package mkschema
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+)
+
+// EmitterPluginPrefix is the prefix of executables on the PATH that are discovered as emitters. An emitter named
+// `docs` that isn't registered in-process is run as `pulumi-mkschema-emit-docs`.
+const EmitterPluginPrefix = "pulumi-mkschema-emit-"
+
+// Emitter produces extra artifacts, such as docs, SDK stubs, or manifests, from a generated schema, writing them
+// underneath the given output directory.
+type Emitter interface {
+	Emit(ctx context.Context, spec *schema.PackageSpec, outDir string) error
+}
+
+// EmitterFunc adapts an ordinary function into an Emitter.
+type EmitterFunc func(ctx context.Context, spec *schema.PackageSpec, outDir string) error
+
+func (f EmitterFunc) Emit(ctx context.Context, spec *schema.PackageSpec, outDir string) error {
+	return f(ctx, spec, outDir)
+}
+
+var (
+	registeredEmittersLock sync.Mutex
+	registeredEmitters     = make(map[string]Emitter)
+)
+
+// RegisterEmitter registers an emitter under the given name, so that it may be requested by name. Like
+// RegisterHook, this is meant to be called from init functions of plugins linked into a custom build of the tool.
+func RegisterEmitter(name string, emitter Emitter) {
+	registeredEmittersLock.Lock()
+	defer registeredEmittersLock.Unlock()
+	registeredEmitters[name] = emitter
+}
+
+// RegisteredEmitters returns the sorted names of all emitters registered in this process.
+func RegisteredEmitters() []string {
+	registeredEmittersLock.Lock()
+	defer registeredEmittersLock.Unlock()
+	var names []string
+	for name := range registeredEmitters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LookupEmitter finds the emitter with the given name. Emitters registered in-process take precedence; otherwise
+// the name is resolved to an executable plugin on the PATH.
+func LookupEmitter(name string) (Emitter, error) {
+	registeredEmittersLock.Lock()
+	emitter, has := registeredEmitters[name]
+	registeredEmittersLock.Unlock()
+	if has {
+		return emitter, nil
+	}
+
+	path, err := exec.LookPath(EmitterPluginPrefix + name)
+	if err != nil {
+		return nil, errors.Errorf("unknown emitter '%s': not registered, and no %s%s plugin found on the PATH",
+			name, EmitterPluginPrefix, name)
+	}
+	return execEmitter{Name: name, Path: path}, nil
+}
+
+// Emit runs each of the named emitters, in order, on the schema, stopping at the first failure.
+func Emit(ctx context.Context, spec *schema.PackageSpec, names []string, outDir string) error {
+	// Resolve everything up front, so that a misspelled emitter doesn't leave behind half of the artifacts.
+	emitters := make([]Emitter, len(names))
+	for i, name := range names {
+		emitter, err := LookupEmitter(name)
+		if err != nil {
+			return err
+		}
+		emitters[i] = emitter
+	}
+
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return errors.Wrapf(err, "creating output directory")
+	}
+	for i, emitter := range emitters {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := emitter.Emit(ctx, spec, outDir); err != nil {
+			return errors.Wrapf(err, "running emitter %s", names[i])
+		}
+	}
+	return nil
+}
+
+// execEmitter is an emitter implemented by an external executable. The plugin receives the schema as JSON on its
+// stdin and the output directory as its sole argument; anything it prints is included in failures.
+type execEmitter struct {
+	Name string
+	Path string
+}
+
+func (e execEmitter) Emit(ctx context.Context, spec *schema.PackageSpec, outDir string) error {
+	b, err := json.Marshal(spec)
+	if err != nil {
+		return errors.Wrapf(err, "serializing schema to JSON")
+	}
+
+	cmd := exec.CommandContext(ctx, e.Path, outDir)
+	cmd.Stdin = bytes.NewReader(b)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return errors.Wrapf(err, "%s: %s", e.Path, msg)
+		}
+		return errors.Wrapf(err, "%s", e.Path)
+	}
+	return nil
+}
@@ -0,0 +1,310 @@
+package emitters
+
+import (
+	"bytes"
+	"context"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+
+	"github.com/pulumi/pulumi-mkschema/mkschema"
+)
+
+func init() {
+	mkschema.RegisterEmitter("docs", mkschema.EmitterFunc(emitDocs))
+}
+
+// emitDocs generates registry-style markdown docs into the `docs` directory: an index of the package's resources and
+// functions, and a page for each of them that describes its inputs, outputs, and the object types they use.
+//
+// Pulumi's own docs generator isn't used because the published module is missing its page templates, which are
+// generated as part of Pulumi's build.
+func emitDocs(ctx context.Context, spec *schema.PackageSpec, outDir string) error {
+	pkg, err := bindSchema(spec)
+	if err != nil {
+		return err
+	}
+
+	files := map[string][]byte{}
+	index := docsIndex{Package: pkg}
+	for _, r := range pkg.Resources {
+		page := newDocsPage(pkg, r.Token, r.Comment, r.InputProperties, r.Properties)
+		if err = renderDocs(files, page.Path, "resource", page); err != nil {
+			return err
+		}
+		index.Resources = append(index.Resources, page)
+	}
+	for _, f := range pkg.Functions {
+		var inputs, outputs []*schema.Property
+		if f.Inputs != nil {
+			inputs = f.Inputs.Properties
+		}
+		if f.Outputs != nil {
+			outputs = f.Outputs.Properties
+		}
+		page := newDocsPage(pkg, f.Token, f.Comment, inputs, outputs)
+		if err = renderDocs(files, page.Path, "function", page); err != nil {
+			return err
+		}
+		index.Functions = append(index.Functions, page)
+	}
+	if err = renderDocs(files, "_index.md", "index", index); err != nil {
+		return err
+	}
+	return writeFiles(filepath.Join(outDir, "docs"), files)
+}
+
+// docsIndex is the package's landing page.
+type docsIndex struct {
+	Package   *schema.Package
+	Resources []*docsPage
+	Functions []*docsPage
+}
+
+// docsPage is the page for a single resource or function.
+type docsPage struct {
+	Name        string                 // the name of the resource or function, without its package or module.
+	Token       string                 // the resource or function's token.
+	Path        string                 // the page's path, relative to the docs directory.
+	Description string                 // the resource or function's description.
+	Inputs      []*schema.Property     // the resource's input properties, or the function's arguments.
+	Outputs     []*schema.Property     // the resource's output properties, or the function's results.
+	Types       []*schema.ObjectType   // the object types used by the inputs and outputs, sorted by token.
+	Enums       []*schema.EnumType     // the enum types used by the inputs and outputs, sorted by token.
+	typeNames   map[schema.Type]string // the names of the object and enum types, for cross-referencing.
+}
+
+func newDocsPage(pkg *schema.Package, token, description string, inputs, outputs []*schema.Property) *docsPage {
+	name := token[strings.LastIndex(token, ":")+1:]
+	page := &docsPage{
+		Name:        name,
+		Token:       token,
+		Path:        path.Join(pkg.TokenToModule(token), strings.ToLower(name), "_index.md"),
+		Description: description,
+		Inputs:      inputs,
+		Outputs:     outputs,
+		typeNames:   map[schema.Type]string{},
+	}
+	for _, props := range [][]*schema.Property{inputs, outputs} {
+		for _, p := range props {
+			page.addTypes(p.Type)
+		}
+	}
+	sort.Slice(page.Types, func(i, j int) bool { return page.Types[i].Token < page.Types[j].Token })
+	sort.Slice(page.Enums, func(i, j int) bool { return page.Enums[i].Token < page.Enums[j].Token })
+	return page
+}
+
+// addTypes records the object and enum types referenced by t, transitively.
+func (p *docsPage) addTypes(t schema.Type) {
+	switch t := t.(type) {
+	case *schema.InputType:
+		p.addTypes(t.ElementType)
+	case *schema.OptionalType:
+		p.addTypes(t.ElementType)
+	case *schema.ArrayType:
+		p.addTypes(t.ElementType)
+	case *schema.MapType:
+		p.addTypes(t.ElementType)
+	case *schema.UnionType:
+		for _, e := range t.ElementTypes {
+			p.addTypes(e)
+		}
+	case *schema.ObjectType:
+		if t.IsInputShape() {
+			t = t.PlainShape
+		}
+		if _, has := p.typeNames[t]; has {
+			return
+		}
+		p.typeNames[t] = t.Token[strings.LastIndex(t.Token, ":")+1:]
+		p.Types = append(p.Types, t)
+		for _, prop := range t.Properties {
+			p.addTypes(prop.Type)
+		}
+	case *schema.EnumType:
+		if _, has := p.typeNames[t]; !has {
+			p.typeNames[t] = t.Token[strings.LastIndex(t.Token, ":")+1:]
+			p.Enums = append(p.Enums, t)
+		}
+	}
+}
+
+// TypeString renders a property's type, linking to the page's sections for object and enum types.
+func (p *docsPage) TypeString(t schema.Type) string {
+	switch t := t.(type) {
+	case *schema.InputType:
+		return p.TypeString(t.ElementType)
+	case *schema.OptionalType:
+		return p.TypeString(t.ElementType)
+	case *schema.ArrayType:
+		return "List<" + p.TypeString(t.ElementType) + ">"
+	case *schema.MapType:
+		return "Map<" + p.TypeString(t.ElementType) + ">"
+	case *schema.UnionType:
+		elems := make([]string, len(t.ElementTypes))
+		for i, e := range t.ElementTypes {
+			elems[i] = p.TypeString(e)
+		}
+		return strings.Join(elems, " | ")
+	case *schema.ObjectType:
+		if t.IsInputShape() {
+			t = t.PlainShape
+		}
+		return p.typeLink(t)
+	case *schema.EnumType:
+		return p.typeLink(t)
+	case *schema.ResourceType:
+		return t.Token
+	default:
+		return t.String()
+	}
+}
+
+func (p *docsPage) typeLink(t schema.Type) string {
+	name := p.typeNames[t]
+	return "[" + name + "](#" + strings.ToLower(name) + ")"
+}
+
+// TypeName returns the short name of an object or enum type.
+func (p *docsPage) TypeName(t schema.Type) string {
+	return p.typeNames[t]
+}
+
+// docsTemplates are the templates for the docs' pages.
+var docsTemplates = template.Must(template.New("docs").Funcs(template.FuncMap{
+	"cell": func(s string) string {
+		return strings.ReplaceAll(strings.ReplaceAll(strings.TrimSpace(s), "\n", " "), "|", `\|`)
+	},
+	"props": func(page *docsPage, props []*schema.Property) interface{} {
+		return struct {
+			Page       *docsPage
+			Properties []*schema.Property
+		}{page, props}
+	},
+}).Parse(`
+{{- define "properties" -}}
+{{- $page := .Page -}}
+| Name | Type | Required | Description |
+| ---- | ---- | -------- | ----------- |
+{{ range .Properties -}}
+| ` + "`{{.Name}}`" + ` | {{$page.TypeString .Type}} | {{if .IsRequired}}yes{{else}}no{{end}} | {{cell .Comment}} |
+{{ end -}}
+{{- end -}}
+
+{{- define "supporting" -}}
+{{- $page := . -}}
+{{- if or .Types .Enums}}
+## Supporting Types
+{{ range .Types}}
+### {{$page.TypeName .}}
+
+{{if .Comment}}{{.Comment}}
+
+{{end -}}
+{{template "properties" (props $page .Properties)}}
+{{- end -}}
+{{range .Enums}}
+### {{$page.TypeName .}}
+
+{{if .Comment}}{{.Comment}}
+
+{{end -}}
+| Name | Value | Description |
+| ---- | ----- | ----------- |
+{{ range .Elements -}}
+| {{.Name}} | ` + "`{{.Value}}`" + ` | {{cell .Comment}} |
+{{ end -}}
+{{- end -}}
+{{- end -}}
+{{- end -}}
+
+{{- define "resource" -}}
+---
+title: {{.Name}}
+---
+
+# {{.Name}}
+
+{{if .Description}}{{.Description}}
+
+{{end -}}
+Type token: ` + "`{{.Token}}`" + `
+{{if .Inputs}}
+## Inputs
+
+{{template "properties" (props . .Inputs)}}
+{{- end}}
+{{- if .Outputs}}
+## Outputs
+
+All input properties are also available as outputs.
+
+{{template "properties" (props . .Outputs)}}
+{{- end}}
+{{- template "supporting" .}}
+{{- end -}}
+
+{{- define "function" -}}
+---
+title: {{.Name}}
+---
+
+# {{.Name}}
+
+{{if .Description}}{{.Description}}
+
+{{end -}}
+Type token: ` + "`{{.Token}}`" + `
+{{if .Inputs}}
+## Arguments
+
+{{template "properties" (props . .Inputs)}}
+{{- end}}
+{{- if .Outputs}}
+## Results
+
+{{template "properties" (props . .Outputs)}}
+{{- end}}
+{{- template "supporting" .}}
+{{- end -}}
+
+{{- define "index" -}}
+---
+title: {{.Package.Name}}
+---
+
+# {{.Package.Name}}
+
+{{if .Package.Description}}{{.Package.Description}}
+
+{{end -}}
+{{- if .Resources -}}
+## Resources
+
+{{range .Resources}}- [{{.Name}}]({{.Path}})
+{{end}}
+{{- end}}
+{{- if .Functions}}{{if .Resources}}
+{{end}}## Functions
+
+{{range .Functions}}- [{{.Name}}]({{.Path}})
+{{end}}
+{{- end}}
+{{- end -}}
+`))
+
+// renderDocs renders a page with the named template into files.
+func renderDocs(files map[string][]byte, path, name string, data interface{}) error {
+	var buf bytes.Buffer
+	if err := docsTemplates.ExecuteTemplate(&buf, name, data); err != nil {
+		return errors.Wrapf(err, "generating docs for %s", path)
+	}
+	files[path] = buf.Bytes()
+	return nil
+}
@@ -0,0 +1,292 @@
+package emitters
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/blang/semver"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/pkg/errors"
+	dotnetgen "github.com/pulumi/pulumi/pkg/v3/codegen/dotnet"
+	gogen "github.com/pulumi/pulumi/pkg/v3/codegen/go"
+	"github.com/pulumi/pulumi/pkg/v3/codegen/hcl2/syntax"
+	nodejsgen "github.com/pulumi/pulumi/pkg/v3/codegen/nodejs"
+	"github.com/pulumi/pulumi/pkg/v3/codegen/pcl"
+	pythongen "github.com/pulumi/pulumi/pkg/v3/codegen/python"
+	"github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+
+	"github.com/pulumi/pulumi-mkschema/mkschema"
+)
+
+func init() {
+	mkschema.RegisterEmitter("examples", mkschema.EmitterFunc(emitExamples))
+}
+
+// programGenerators generate an example program, as files keyed by their paths, in each language that Pulumi's program
+// generators support. YAML programs are rendered directly, since there is no generator for them.
+var programGenerators = map[string]func(program *pcl.Program) (map[string][]byte, hcl.Diagnostics, error){
+	"csharp":     dotnetgen.GenerateProgram,
+	"go":         gogen.GenerateProgram,
+	"python":     pythongen.GenerateProgram,
+	"typescript": nodejsgen.GenerateProgram,
+}
+
+// emitExamples generates a minimal example program for each resource, in each language, into the `examples`
+// directory, as `examples/MODULE/NAME/LANGUAGE/...`. The examples set every input property to a placeholder of the
+// right type, so that they show the resource's shape, and are suitable for embedding in docs.
+func emitExamples(ctx context.Context, spec *schema.PackageSpec, outDir string) error {
+	// Program generators look up the properties a program sets among the resource's inputs, so a resource with no
+	// separate inputs takes its properties as inputs. The C# generator also requires the package to have a C#
+	// section, even an empty one.
+	copied := *spec
+	copied.Resources = make(map[string]schema.ResourceSpec, len(spec.Resources))
+	for tok, res := range spec.Resources {
+		if len(res.InputProperties) == 0 {
+			res.InputProperties = res.Properties
+		}
+		copied.Resources[tok] = res
+	}
+	copied.Language = map[string]schema.RawMessage{"csharp": schema.RawMessage("{}")}
+	for lang, info := range spec.Language {
+		copied.Language[lang] = info
+	}
+	pkg, err := bindSchema(&copied)
+	if err != nil {
+		return err
+	}
+
+	files := map[string][]byte{}
+	for _, r := range pkg.Resources {
+		example := exampleResource{Name: camelCase(r.Token[strings.LastIndex(r.Token, ":")+1:]), Token: r.Token}
+		for _, p := range r.InputProperties {
+			if v, ok := exampleValue(p.Type); ok {
+				example.Properties = append(example.Properties, exampleProperty{p.Name, v})
+			}
+		}
+
+		dir := path.Join(pkg.TokenToModule(r.Token), strings.ToLower(r.Token[strings.LastIndex(r.Token, ":")+1:]))
+		program, err := example.bind(pkg)
+		if err != nil {
+			return errors.Wrapf(err, "binding example for %s", r.Token)
+		}
+		for lang, gen := range programGenerators {
+			generated, err := generateProgram(gen, program)
+			if err != nil {
+				return errors.Wrapf(err, "generating %s example for %s", lang, r.Token)
+			}
+			for name, contents := range generated {
+				files[path.Join(dir, lang, name)] = contents
+			}
+		}
+		files[path.Join(dir, "yaml", "Pulumi.yaml")] = example.yaml(pkg.Name)
+	}
+	return writeFiles(filepath.Join(outDir, "examples"), files)
+}
+
+// generateProgram runs a program generator, turning any error diagnostics, or panic, into an error.
+func generateProgram(gen func(program *pcl.Program) (map[string][]byte, hcl.Diagnostics, error),
+	program *pcl.Program) (files map[string][]byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Errorf("%v", r)
+		}
+	}()
+	files, diags, err := gen(program)
+	if err == nil && diags.HasErrors() {
+		err = diags
+	}
+	return files, err
+}
+
+// exampleResource is a resource to declare in an example program.
+type exampleResource struct {
+	Name       string            // the resource's name in the program.
+	Token      string            // the resource's type token.
+	Properties []exampleProperty // the resource's inputs.
+}
+
+// exampleProperty is a property set in an example program. Its value is a string, float64, bool, []interface{}, or
+// []exampleProperty, for a map.
+type exampleProperty struct {
+	Name  string
+	Value interface{}
+}
+
+// exampleValue returns a placeholder value of the given type, if there is a sensible one. Only primitives, and arrays
+// and maps of them, are filled in: Pulumi's program generators don't yet handle object and enum types reliably in
+// every language, and it's better for an example to leave a property out than not to compile.
+func exampleValue(t schema.Type) (interface{}, bool) {
+	switch t := t.(type) {
+	case *schema.InputType:
+		return exampleValue(t.ElementType)
+	case *schema.OptionalType:
+		return exampleValue(t.ElementType)
+	case *schema.ArrayType:
+		if e, ok := exampleValue(t.ElementType); ok {
+			return []interface{}{e}, true
+		}
+	case *schema.MapType:
+		if e, ok := exampleValue(t.ElementType); ok {
+			return []exampleProperty{{"key", e}}, true
+		}
+	case *schema.UnionType:
+		for _, e := range t.ElementTypes {
+			if v, ok := exampleValue(e); ok {
+				return v, true
+			}
+		}
+	case *schema.TokenType:
+		if t.UnderlyingType != nil {
+			return exampleValue(t.UnderlyingType)
+		}
+	}
+
+	switch t {
+	case schema.StringType:
+		return "example", true
+	case schema.IntType, schema.NumberType:
+		return float64(1), true
+	case schema.BoolType:
+		return true, true
+	}
+	return nil, false
+}
+
+// bind binds the example as a Pulumi program, against the already-bound package.
+func (r exampleResource) bind(pkg *schema.Package) (*pcl.Program, error) {
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "resource %s %q {\n", r.Name, r.Token)
+	for _, p := range r.Properties {
+		fmt.Fprintf(&src, "    %s = ", hclKey(p.Name))
+		writeHCLValue(&src, p.Value, "    ")
+		src.WriteString("\n")
+	}
+	src.WriteString("}\n")
+
+	parser := syntax.NewParser()
+	if err := parser.ParseFile(&src, "example.pp"); err != nil {
+		return nil, err
+	}
+	if parser.Diagnostics.HasErrors() {
+		return nil, parser.Diagnostics
+	}
+	program, diags, err := pcl.BindProgram(parser.Files, pcl.Loader(packageLoader{pkg}))
+	if err == nil && diags.HasErrors() {
+		err = diags
+	}
+	return program, err
+}
+
+// packageLoader loads the one package being emitted, rather than resolving it from a provider plugin.
+type packageLoader struct {
+	pkg *schema.Package
+}
+
+func (l packageLoader) LoadPackage(name string, version *semver.Version) (*schema.Package, error) {
+	if name != l.pkg.Name {
+		return nil, errors.Errorf("example programs can only use package %s, not %s", l.pkg.Name, name)
+	}
+	return l.pkg, nil
+}
+
+// writeHCLValue writes an example value as an HCL expression.
+func writeHCLValue(w *bytes.Buffer, v interface{}, indent string) {
+	switch v := v.(type) {
+	case string:
+		fmt.Fprintf(w, "%q", v)
+	case float64:
+		w.WriteString(strconv.FormatFloat(v, 'f', -1, 64))
+	case bool:
+		w.WriteString(strconv.FormatBool(v))
+	case []interface{}:
+		w.WriteString("[")
+		for i, e := range v {
+			if i > 0 {
+				w.WriteString(", ")
+			}
+			writeHCLValue(w, e, indent)
+		}
+		w.WriteString("]")
+	case []exampleProperty:
+		w.WriteString("{\n")
+		for _, p := range v {
+			fmt.Fprintf(w, "%s    %s = ", indent, hclKey(p.Name))
+			writeHCLValue(w, p.Value, indent+"    ")
+			w.WriteString("\n")
+		}
+		w.WriteString(indent + "}")
+	}
+}
+
+// hclKey returns an object key, quoted unless it's a valid identifier.
+func hclKey(name string) string {
+	if hclsyntax.ValidIdentifier(name) {
+		return name
+	}
+	return strconv.Quote(name)
+}
+
+// yaml renders the example as a Pulumi YAML program.
+func (r exampleResource) yaml(project string) []byte {
+	var w bytes.Buffer
+	fmt.Fprintf(&w, "name: %s-example\nruntime: yaml\nresources:\n  %s:\n    type: %s\n", project, r.Name, r.Token)
+	if len(r.Properties) > 0 {
+		w.WriteString("    properties:\n")
+		writeYAMLProperties(&w, r.Properties, "      ")
+	}
+	return w.Bytes()
+}
+
+func writeYAMLProperties(w *bytes.Buffer, props []exampleProperty, indent string) {
+	for _, p := range props {
+		fmt.Fprintf(w, "%s%s:", indent, yamlKey(p.Name))
+		writeYAMLValue(w, p.Value, indent)
+	}
+}
+
+// yamlKey returns a mapping key, quoted unless it's a valid identifier.
+func yamlKey(name string) string {
+	if hclsyntax.ValidIdentifier(name) && !strings.Contains(name, "-") {
+		return name
+	}
+	return strconv.Quote(name)
+}
+
+// writeYAMLValue writes an example value, following a key or list item marker, as YAML.
+func writeYAMLValue(w *bytes.Buffer, v interface{}, indent string) {
+	switch v := v.(type) {
+	case []interface{}:
+		w.WriteString("\n")
+		for _, e := range v {
+			fmt.Fprintf(w, "%s  -", indent)
+			writeYAMLValue(w, e, indent+"  ")
+		}
+	case []exampleProperty:
+		if len(v) == 0 {
+			w.WriteString(" {}\n")
+			return
+		}
+		w.WriteString("\n")
+		writeYAMLProperties(w, v, indent+"  ")
+	case string:
+		fmt.Fprintf(w, " %s\n", strconv.Quote(v))
+	default:
+		w.WriteString(" ")
+		writeHCLValue(w, v, indent)
+		w.WriteString("\n")
+	}
+}
+
+// camelCase lower-cases the first letter of a name.
+func camelCase(name string) string {
+	if name == "" {
+		return name
+	}
+	return strings.ToLower(name[:1]) + name[1:]
+}
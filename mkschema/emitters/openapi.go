@@ -0,0 +1,177 @@
+package emitters
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+
+	"github.com/pulumi/pulumi-mkschema/mkschema"
+)
+
+func init() {
+	mkschema.RegisterEmitter("openapi", mkschema.EmitterFunc(emitOpenAPI))
+}
+
+// openAPIVersion is the version of OpenAPI that the openapi emitter's documents conform to.
+const openAPIVersion = "3.0.3"
+
+// openAPIDocument is an OpenAPI document declaring only components, without any paths.
+type openAPIDocument struct {
+	OpenAPI    string            `json:"openapi"`
+	Info       openAPIInfo       `json:"info"`
+	Paths      map[string]string `json:"paths"`
+	Components openAPIComponents `json:"components"`
+}
+
+// openAPIInfo describes the package an OpenAPI document was exported from.
+type openAPIInfo struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// openAPIComponents are the reusable schemas of an OpenAPI document, by name.
+type openAPIComponents struct {
+	Schemas map[string]*openAPISchema `json:"schemas"`
+}
+
+// openAPISchema is an OpenAPI schema object. Secret properties are marked with an `x-pulumi-secret` extension, since
+// OpenAPI has no notion of them.
+type openAPISchema struct {
+	Ref                  string                    `json:"$ref,omitempty"`
+	Type                 string                    `json:"type,omitempty"`
+	Description          string                    `json:"description,omitempty"`
+	Properties           map[string]*openAPISchema `json:"properties,omitempty"`
+	Required             []string                  `json:"required,omitempty"`
+	Items                *openAPISchema            `json:"items,omitempty"`
+	AdditionalProperties *openAPISchema            `json:"additionalProperties,omitempty"`
+	OneOf                []*openAPISchema          `json:"oneOf,omitempty"`
+	AllOf                []*openAPISchema          `json:"allOf,omitempty"`
+	Enum                 []interface{}             `json:"enum,omitempty"`
+	Default              interface{}               `json:"default,omitempty"`
+	Deprecated           bool                      `json:"deprecated,omitempty"`
+	Secret               bool                      `json:"x-pulumi-secret,omitempty"`
+}
+
+// emitOpenAPI writes `openapi.json`, an OpenAPI 3 document whose components are the package's object and enum types,
+// and the input and output shapes of its resources and functions, so that platforms outside of Pulumi can validate
+// payloads and generate clients. Each type's component is named after its token, with its colons and slashes made
+// dots, as in `mypkg.storage.Bucket`, and each resource's and function's shapes after its token, suffixed with
+// `Inputs` or `Outputs`.
+//
+// References to the built-in archive, asset, and `Any` types, to resources, and to other packages' types can't be
+// resolved within the document, so they accept any value at all.
+func emitOpenAPI(ctx context.Context, spec *schema.PackageSpec, outDir string) error {
+	version := spec.Version
+	if version == "" {
+		version = "0.0.0" // OpenAPI requires one.
+	}
+	doc := openAPIDocument{
+		OpenAPI:    openAPIVersion,
+		Info:       openAPIInfo{Title: spec.Name, Description: spec.Description, Version: version},
+		Paths:      map[string]string{},
+		Components: openAPIComponents{Schemas: map[string]*openAPISchema{}},
+	}
+	add := func(name string, s *openAPISchema) error {
+		if _, has := doc.Components.Schemas[name]; has {
+			return errors.Errorf("OpenAPI component %s is declared more than once", name)
+		}
+		doc.Components.Schemas[name] = s
+		return nil
+	}
+
+	for tok, t := range spec.Types {
+		s := &openAPISchema{Description: t.Description}
+		if len(t.Enum) > 0 {
+			s.Type = t.Type
+			for _, e := range t.Enum {
+				s.Enum = append(s.Enum, e.Value)
+			}
+		} else {
+			s = openAPIObject(t.Description, t.Properties, t.Required)
+		}
+		if err := add(openAPIName(tok), s); err != nil {
+			return err
+		}
+	}
+	for tok, r := range spec.Resources {
+		if err := add(openAPIName(tok)+"Inputs", openAPIObject(r.Description, r.InputProperties,
+			r.RequiredInputs)); err != nil {
+			return err
+		}
+		if err := add(openAPIName(tok)+"Outputs", openAPIObject(r.Description, r.Properties, r.Required)); err != nil {
+			return err
+		}
+	}
+	for tok, f := range spec.Functions {
+		for suffix, obj := range map[string]*schema.ObjectTypeSpec{"Inputs": f.Inputs, "Outputs": f.Outputs} {
+			if obj == nil {
+				continue
+			}
+			if err := add(openAPIName(tok)+suffix, openAPIObject(f.Description, obj.Properties,
+				obj.Required)); err != nil {
+				return err
+			}
+		}
+	}
+
+	b, err := json.MarshalIndent(doc, "", "    ")
+	if err != nil {
+		return errors.Wrap(err, "marshaling OpenAPI document")
+	}
+	return writeFiles(outDir, map[string][]byte{"openapi.json": append(b, '\n')})
+}
+
+// openAPIName returns the name of the component for a token, with its colons and slashes, which component names
+// can't have, made dots.
+func openAPIName(tok string) string {
+	return strings.NewReplacer(":", ".", "/", ".").Replace(tok)
+}
+
+// openAPIObject returns the schema of an object with the given properties.
+func openAPIObject(description string, props map[string]schema.PropertySpec, required []string) *openAPISchema {
+	s := &openAPISchema{Type: "object", Description: description, Required: required}
+	if len(props) > 0 {
+		s.Properties = make(map[string]*openAPISchema, len(props))
+	}
+	for name, p := range props {
+		ps := openAPITypeSchema(p.TypeSpec)
+		if ps.Ref != "" && (p.Description != "" || p.DeprecationMessage != "" || p.Secret) {
+			// Siblings of a reference are ignored, so it's wrapped to keep them.
+			ps = &openAPISchema{AllOf: []*openAPISchema{ps}}
+		}
+		ps.Description, ps.Default = p.Description, p.Default
+		ps.Deprecated, ps.Secret = p.DeprecationMessage != "", p.Secret
+		s.Properties[name] = ps
+	}
+	return s
+}
+
+// openAPITypeSchema returns the schema of a type.
+func openAPITypeSchema(t schema.TypeSpec) *openAPISchema {
+	switch {
+	case strings.HasPrefix(t.Ref, "#/types/"):
+		return &openAPISchema{Ref: "#/components/schemas/" + openAPIName(strings.TrimPrefix(t.Ref, "#/types/"))}
+	case t.Ref != "":
+		return &openAPISchema{}
+	case len(t.OneOf) > 0:
+		s := &openAPISchema{}
+		for _, alt := range t.OneOf {
+			s.OneOf = append(s.OneOf, openAPITypeSchema(alt))
+		}
+		return s
+	}
+	s := &openAPISchema{Type: t.Type} // the schema's types are named as OpenAPI's are.
+	switch {
+	case t.Type == "array" && t.Items != nil:
+		s.Items = openAPITypeSchema(*t.Items)
+	case t.Type == "array":
+		s.Items = &openAPISchema{}
+	case t.Type == "object" && t.AdditionalProperties != nil:
+		s.AdditionalProperties = openAPITypeSchema(*t.AdditionalProperties)
+	}
+	return s
+}
@@ -0,0 +1,92 @@
+// Package emitters contains the emitters built into the pulumi-mkschema tool. Importing it, even with a blank import,
+// registers them with mkschema.RegisterEmitter.
+package emitters
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	dotnetgen "github.com/pulumi/pulumi/pkg/v3/codegen/dotnet"
+	gogen "github.com/pulumi/pulumi/pkg/v3/codegen/go"
+	nodejsgen "github.com/pulumi/pulumi/pkg/v3/codegen/nodejs"
+	pythongen "github.com/pulumi/pulumi/pkg/v3/codegen/python"
+	"github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+
+	"github.com/pulumi/pulumi-mkschema/mkschema"
+)
+
+// generatorName is the tool name that Pulumi's code generators stamp into the files they generate.
+const generatorName = "pulumi-mkschema"
+
+// SDKLanguages are the languages that SDKs can be emitted for. The emitter for each is named `sdk-<language>`.
+var SDKLanguages = []string{"dotnet", "go", "nodejs", "python"}
+
+// languages are the language plugins used to bind schemas, so that their language-specific sections are understood.
+var languages = map[string]schema.Language{
+	"csharp": dotnetgen.Importer,
+	"go":     gogen.Importer,
+	"nodejs": nodejsgen.Importer,
+	"python": pythongen.Importer,
+}
+
+// sdkGenerators generate the files of an SDK for a bound package, keyed by their paths.
+var sdkGenerators = map[string]func(pkg *schema.Package) (map[string][]byte, error){
+	"dotnet": func(pkg *schema.Package) (map[string][]byte, error) {
+		return dotnetgen.GeneratePackage(generatorName, pkg, nil)
+	},
+	"go": func(pkg *schema.Package) (map[string][]byte, error) {
+		return gogen.GeneratePackage(generatorName, pkg)
+	},
+	"nodejs": func(pkg *schema.Package) (map[string][]byte, error) {
+		return nodejsgen.GeneratePackage(generatorName, pkg, nil)
+	},
+	"python": func(pkg *schema.Package) (map[string][]byte, error) {
+		return pythongen.GeneratePackage(generatorName, pkg, nil)
+	},
+}
+
+func init() {
+	for _, lang := range SDKLanguages {
+		mkschema.RegisterEmitter("sdk-"+lang, sdkEmitter(lang))
+	}
+}
+
+// sdkEmitter returns an emitter that generates the given language's SDK into the `sdk/<language>` directory.
+func sdkEmitter(lang string) mkschema.Emitter {
+	return mkschema.EmitterFunc(func(ctx context.Context, spec *schema.PackageSpec, outDir string) error {
+		pkg, err := bindSchema(spec)
+		if err != nil {
+			return err
+		}
+		files, err := sdkGenerators[lang](pkg)
+		if err != nil {
+			return errors.Wrapf(err, "generating %s SDK", lang)
+		}
+		return writeFiles(filepath.Join(outDir, "sdk", lang), files)
+	})
+}
+
+// bindSchema binds a schema, checking it for errors along the way, as Pulumi's code generators require.
+func bindSchema(spec *schema.PackageSpec) (*schema.Package, error) {
+	pkg, err := schema.ImportSpec(*spec, languages)
+	if err != nil {
+		return nil, errors.Wrapf(err, "binding schema")
+	}
+	return pkg, nil
+}
+
+// writeFiles writes out a set of generated files, keyed by their paths relative to dir.
+func writeFiles(dir string, files map[string][]byte) error {
+	for path, contents := range files {
+		path = filepath.Join(dir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, contents, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
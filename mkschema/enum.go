@@ -0,0 +1,175 @@
+package mkschema
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+)
+
+// Enum is an enum type gathered from a named Go type with a primitive underlying type, whose values are the constants
+// of that type declared in the same package.
+type Enum struct {
+	Token       string          // the schema token for this enum.
+	Object      *types.TypeName // the Go type declaration this was gathered from.
+	Pos         token.Position  // the position of the Go type declaration.
+	Description string          // the description, taken from the Go doc comment.
+	Type        string          // the schema type of the enum's values: string, integer, number, or boolean.
+	Values      []*EnumValue    // the enum's values, in Go declaration order.
+}
+
+// EnumValue is a value of an enum, gathered from a Go constant.
+type EnumValue struct {
+	Name               string         // the schema name: the constant's name, less any prefix of the enum's name.
+	Const              *types.Const   // the Go constant this value was gathered from.
+	Pos                token.Position // the position of the Go constant declaration.
+	Value              interface{}    // the value itself.
+	Description        string         // the description, taken from the constant's doc comment.
+	DeprecationMessage string         // why the value is deprecated, from its doc comment.
+}
+
+// ComplexTypeSpec returns the schema for the enum.
+func (e *Enum) ComplexTypeSpec() schema.ComplexTypeSpec {
+	spec := schema.ComplexTypeSpec{
+		ObjectTypeSpec: schema.ObjectTypeSpec{Type: e.Type, Description: e.Description},
+	}
+	for _, v := range e.Values {
+		spec.Enum = append(spec.Enum, schema.EnumValueSpec{Name: v.Name, Value: v.Value, Description: v.Description,
+			DeprecationMessage: v.DeprecationMessage})
+	}
+	return spec
+}
+
+// enumConsts returns the constants of a named type declared in the target package, in declaration order; if there
+// are any, the type is gathered as an enum. Constants with the same value as an earlier one are aliases, and are left
+// out.
+func (g *generator) enumConsts(t *types.TypeName) []*types.Const {
+	if t.Pkg() != g.Pkg {
+		return nil
+	}
+	if g.enumConstants == nil {
+		g.enumConstants = make(map[*types.TypeName][]*types.Const)
+		scope := g.Pkg.Scope()
+		for _, name := range scope.Names() {
+			if c, ok := scope.Lookup(name).(*types.Const); ok {
+				if named, ok := c.Type().(*types.Named); ok {
+					g.enumConstants[named.Obj()] = append(g.enumConstants[named.Obj()], c)
+				}
+			}
+		}
+		for _, cs := range g.enumConstants {
+			sort.Slice(cs, func(i, j int) bool { return cs[i].Pos() < cs[j].Pos() })
+		}
+	}
+
+	var consts []*types.Const
+	for _, c := range g.enumConstants[t] {
+		alias := false
+		for _, prior := range consts {
+			alias = alias || constant.Compare(prior.Val(), token.EQL, c.Val())
+		}
+		if !alias {
+			consts = append(consts, c)
+		}
+	}
+	return consts
+}
+
+// gatherEnumSchema interprets a named Go type with a primitive underlying type as an enum, if it has any constants.
+func (g *generator) gatherEnumSchema(node *ast.TypeSpec, t *types.TypeName, b *types.Basic) error {
+	name := t.Name()
+	if _, has := g.Enums[name]; has {
+		return nil
+	}
+	consts := g.enumConsts(t)
+	if len(consts) == 0 {
+		return g.ruleErrorf(node.Name, ruleSchemaTypes,
+			"%v is an illegal underlying type: %v; declare constants of type %v to make it an enum", b,
+			reflect.TypeOf(b), name)
+	}
+	typ, err := g.analyzeSchemaType(b, PropertyOptions{})
+	if err != nil {
+		return g.ruleErrorf(node.Name, ruleSchemaTypes, "enum %v has an illegal underlying type: %v", name, err)
+	}
+
+	enum := &Enum{
+		Token:  g.defaultType(name),
+		Object: t,
+		Pos:    g.Fset.Position(t.Pos()),
+		Type:   typ.Type,
+	}
+	if node.Doc != nil {
+		enum.Description = g.cleanComment(node.Doc)
+	}
+	if a, _ := g.typeAnnotation(t); a.Description != "" {
+		enum.Description = g.normalizeDescription(a.Description)
+	}
+	for _, c := range consts {
+		v := &EnumValue{Name: strings.TrimPrefix(c.Name(), name), Const: c, Pos: g.Fset.Position(c.Pos()),
+			Value: constantValue(c.Val())}
+		if v.Name == c.Name() || !ast.IsExported(v.Name) || !unicode.IsLetter([]rune(v.Name)[0]) {
+			v.Name = c.Name()
+		}
+		if doc := g.constDoc(c.Name()); doc != nil {
+			v.Description, v.DeprecationMessage = g.commentDoc(doc)
+			g.lintDescription(c, name, "", "enum value "+c.Name(), v.Description)
+		}
+		enum.Values = append(enum.Values, v)
+	}
+	g.Enums[name] = enum
+	if enum.Description == "" {
+		g.warnUndocumented(node, name)
+	}
+	enum.Description = g.previewDescription(node, false, enum.Description)
+	g.lintDescription(node.Name, name, "", "type "+name, enum.Description)
+	return nil
+}
+
+// constDoc returns the doc comment of a package-level constant, or its line comment if it has no doc comment.
+func (g *generator) constDoc(name string) *ast.CommentGroup {
+	for _, file := range g.Files {
+		for _, decl := range file.Decls {
+			gdecl, ok := decl.(*ast.GenDecl)
+			if !ok || gdecl.Tok != token.CONST {
+				continue
+			}
+			for _, spec := range gdecl.Specs {
+				vs := spec.(*ast.ValueSpec)
+				for _, ident := range vs.Names {
+					if ident.Name != name {
+						continue
+					}
+					switch {
+					case vs.Doc != nil:
+						return vs.Doc
+					case vs.Comment != nil:
+						return vs.Comment
+					case len(gdecl.Specs) == 1:
+						return gdecl.Doc
+					}
+					return nil
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// constantValue converts a constant's value to the Go value emitted into the schema. Numbers are float64s, whether
+// integers or not, as they are once a schema is decoded from JSON.
+func constantValue(v constant.Value) interface{} {
+	switch v.Kind() {
+	case constant.Bool:
+		return constant.BoolVal(v)
+	case constant.String:
+		return constant.StringVal(v)
+	}
+	f, _ := constant.Float64Val(v)
+	return f
+}
@@ -0,0 +1,92 @@
+package mkschema
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/types"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// EnumHelpers generates the source of a Go file, to be added to the model's package, with helpers for each gathered
+// enum E, so that an implementation can check its inputs against exactly the values published in the schema:
+//
+//	var EValues = []E{...}
+//	func (v E) Validate() error
+//	func (v E) String() string
+//	func ParseE(s string) (E, error)
+//
+// Validate returns an error unless v is one of the enum's values. String returns the schema name of v's value, and
+// ParseE accepts either the schema name or the value, as a string, of one of the enum's values.
+func (m *Model) EnumHelpers() ([]byte, error) {
+	if len(m.Enums) == 0 {
+		return nil, errors.Errorf("package %s has no enums to generate helpers for", m.Package)
+	}
+
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "// Code generated by pulumi-mkschema; DO NOT EDIT.\n\npackage %s\n\nimport \"fmt\"\n",
+		m.Enums[0].Object.Pkg().Name())
+	for _, e := range m.Enums {
+		writeEnumHelpers(&src, e)
+	}
+
+	out, err := format.Source(src.Bytes())
+	if err != nil {
+		return nil, errors.Wrapf(err, "formatting enum helpers")
+	}
+	return out, nil
+}
+
+func writeEnumHelpers(w *bytes.Buffer, e *Enum) {
+	name := e.Object.Name()
+	basic := e.Object.Type().Underlying().(*types.Basic).Name()
+	verb := "%v"
+	if e.Type == "string" {
+		verb = "%q"
+	}
+
+	consts := make([]string, len(e.Values))
+	values := make([]string, len(e.Values))
+	for i, v := range e.Values {
+		consts[i] = v.Const.Name()
+		values[i] = fmt.Sprintf(verb, v.Value)
+	}
+
+	fmt.Fprintf(w, "\n// %sValues are the values of %s published in the schema.\n", name, name)
+	fmt.Fprintf(w, "var %sValues = []%s{%s}\n", name, name, strings.Join(consts, ", "))
+
+	fmt.Fprintf(w, "\n// Validate returns an error unless v is one of the values of %s published in the schema.\n",
+		name)
+	fmt.Fprintf(w, "func (v %s) Validate() error {\n\tswitch v {\n\tcase %s:\n\t\treturn nil\n\t}\n", name,
+		strings.Join(consts, ", "))
+	msg := fmt.Sprintf("invalid %s %s: must be one of %s", name, verb,
+		strings.ReplaceAll(strings.Join(values, ", "), "%", "%%"))
+	fmt.Fprintf(w, "\treturn fmt.Errorf(%s, %s(v))\n}\n", strconv.Quote(msg), basic)
+
+	fmt.Fprintf(w, "\n// String returns the schema name of v's value.\n")
+	fmt.Fprintf(w, "func (v %s) String() string {\n\tswitch v {\n", name)
+	for _, v := range e.Values {
+		fmt.Fprintf(w, "\tcase %s:\n\t\treturn %q\n", v.Const.Name(), v.Name)
+	}
+	fmt.Fprintf(w, "\t}\n\treturn fmt.Sprintf(\"%s(%s)\", %s(v))\n}\n", name, verb, basic)
+
+	fmt.Fprintf(w, "\n// Parse%s parses a %s from the schema name or value of one of its values.\n", name, name)
+	fmt.Fprintf(w, "func Parse%s(s string) (v %s, err error) {\n\tswitch s {\n", name, name)
+	seen := make(map[string]bool)
+	for _, v := range e.Values {
+		var cases []string
+		for _, s := range []string{v.Name, fmt.Sprint(v.Value)} {
+			if !seen[s] {
+				seen[s] = true
+				cases = append(cases, strconv.Quote(s))
+			}
+		}
+		if len(cases) > 0 {
+			fmt.Fprintf(w, "\tcase %s:\n\t\treturn %s, nil\n", strings.Join(cases, ", "), v.Const.Name())
+		}
+	}
+	fmt.Fprintf(w, "\t}\n\treturn v, fmt.Errorf(\"invalid %s %%q\", s)\n}\n", name)
+}
@@ -0,0 +1,173 @@
+package mkschema
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+)
+
+// ExampleLanguages are the languages registry docs show examples in, as named in fenced code blocks, in the order in
+// which they're shown.
+var ExampleLanguages = []string{"typescript", "python", "go", "csharp", "yaml"}
+
+// ExampleConverter converts the source of an example from one language to another, as named in fenced code blocks,
+// such as `yaml` to `typescript`. It returns ErrUnsupportedConversion if it can't convert between the two, in which
+// case the example is left without the language.
+type ExampleConverter func(from, to, code string) (string, error)
+
+// ErrUnsupportedConversion is returned by an ExampleConverter that can't convert between two languages.
+var ErrUnsupportedConversion = errors.New("unsupported conversion")
+
+// ExampleHook returns a hook that adds each example in resources' descriptions, in the `{{% example %}}` blocks that
+// registry docs render, in any of the given languages that it isn't already in, by converting the example's first
+// code block. An example's code blocks are then ordered as the languages are, followed by any others.
+func ExampleHook(convert ExampleConverter, languages []string) Hook {
+	return func(spec *schema.PackageSpec) error {
+		for tok, res := range spec.Resources {
+			description, err := convertExamples(res.Description, convert, languages)
+			if err != nil {
+				return errors.Wrapf(err, "converting examples of %s", tok)
+			}
+			res.Description = description
+			spec.Resources[tok] = res
+		}
+		return nil
+	}
+}
+
+var (
+	exampleBlockPattern = regexp.MustCompile(`(?s)\{\{% example %\}\}\n(.*?)\{\{% /example %\}\}`)
+	exampleCodePattern  = regexp.MustCompile("(?s)```(\\w+)\n(.*?)\n```\n?")
+	yamlRuntimePattern  = regexp.MustCompile(`(?m)^runtime:`)
+)
+
+// convertExamples converts each example in a description into the given languages.
+func convertExamples(description string, convert ExampleConverter, languages []string) (string, error) {
+	var err error
+	converted := exampleBlockPattern.ReplaceAllStringFunc(description, func(block string) string {
+		if err != nil {
+			return block
+		}
+		m := exampleBlockPattern.FindStringSubmatch(block)
+		body := m[1]
+		codes := exampleCodePattern.FindAllStringSubmatchIndex(body, -1)
+		if len(codes) == 0 {
+			return block
+		}
+
+		type code struct{ lang, src string }
+		var all []code
+		has := make(map[string]bool)
+		for _, c := range codes {
+			lang := body[c[2]:c[3]]
+			all, has[lang] = append(all, code{lang, body[c[4]:c[5]]}), true
+		}
+		from := all[0]
+		for _, lang := range languages {
+			if has[lang] {
+				continue
+			}
+			src, cerr := convert(from.lang, lang, from.src)
+			if errors.Is(cerr, ErrUnsupportedConversion) {
+				continue
+			} else if cerr != nil {
+				err = errors.Wrapf(cerr, "converting %s to %s", from.lang, lang)
+				return block
+			}
+			all, has[lang] = append(all, code{lang, strings.Trim(src, "\n")}), true
+		}
+
+		order := func(lang string) int {
+			for i, l := range languages {
+				if l == lang {
+					return i
+				}
+			}
+			return len(languages)
+		}
+		sort.SliceStable(all, func(i, j int) bool { return order(all[i].lang) < order(all[j].lang) })
+
+		// Keep whatever comes before the first code block, such as the example's title, and whatever follows the last.
+		var b strings.Builder
+		b.WriteString("{{% example %}}\n" + body[:codes[0][0]])
+		for _, c := range all {
+			b.WriteString("```" + c.lang + "\n" + c.src + "\n```\n")
+		}
+		b.WriteString(body[codes[len(codes)-1][1]:] + "{{% /example %}}")
+		return b.String()
+	})
+	return converted, err
+}
+
+// pulumiProgramFiles are the files `pulumi convert` writes a program's source to, by language.
+var pulumiProgramFiles = map[string]string{
+	"typescript": "index.ts",
+	"python":     "__main__.py",
+	"go":         "main.go",
+	"csharp":     "Program.cs",
+}
+
+// PulumiConvert is an ExampleConverter that converts YAML examples into the other languages with `pulumi convert`.
+// The CLI loads the package's schema from its provider plugin, which must be installed.
+func PulumiConvert(from, to, code string) (string, error) {
+	file, has := pulumiProgramFiles[to]
+	if from != "yaml" || !has {
+		return "", ErrUnsupportedConversion
+	}
+
+	dir, err := ioutil.TempDir("", "pulumi-mkschema-convert")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+
+	// An example is usually just the program's resources, so it's given a project to be a program.
+	if !yamlRuntimePattern.MatchString(code) {
+		code = "name: example\nruntime: yaml\n" + code
+	}
+	if err = ioutil.WriteFile(filepath.Join(dir, "Pulumi.yaml"), []byte(code), 0600); err != nil {
+		return "", err
+	}
+	out := filepath.Join(dir, "out")
+	cmd := exec.Command("pulumi", "convert", "--from", "yaml", "--language", to, "--out", out, "--generate-only")
+	cmd.Dir = dir
+	if msg, err := cmd.CombinedOutput(); err != nil {
+		return "", errors.Wrapf(err, "pulumi convert: %s", strings.TrimSpace(string(msg)))
+	}
+	b, err := ioutil.ReadFile(filepath.Join(out, file))
+	if err != nil {
+		return "", errors.Wrapf(err, "reading converted program")
+	}
+	return string(b), nil
+}
+
+// CommandConverter returns an ExampleConverter that runs a command to convert examples. The command receives the
+// languages to convert from and to as its last two arguments, and the example's source on its stdin, and prints the
+// converted source. If it prints nothing, it can't convert between the two.
+func CommandConverter(name string, args ...string) ExampleConverter {
+	return func(from, to, code string) (string, error) {
+		cmd := exec.Command(name, append(append([]string(nil), args...), from, to)...)
+		cmd.Stdin = strings.NewReader(code)
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				return "", errors.Wrapf(err, "%s: %s", name, msg)
+			}
+			return "", errors.Wrapf(err, "%s", name)
+		}
+		if strings.TrimSpace(string(out)) == "" {
+			return "", ErrUnsupportedConversion
+		}
+		return string(out), nil
+	}
+}
@@ -0,0 +1,131 @@
+package mkschema
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// example is an Example function from the package's tests, which documents a resource.
+type example struct {
+	Title string // the example's title, from its doc comment or its name's suffix.
+	Code  string // the example's body, as written.
+	File  string // the test file the example is in.
+}
+
+// resourceExamples returns the examples of the resource with the given Go type name: the functions named after it, as
+// in ExampleBucket or ExampleBucket_withTags, in the package's test files. The test files are read once, on first use.
+func (g *generator) resourceExamples(name string) []example {
+	if g.examples == nil {
+		g.examples = make(map[string][]example)
+		if dir := g.packageDir(); dir != "" {
+			files, _ := filepath.Glob(filepath.Join(dir, "*_test.go"))
+			sort.Strings(files)
+			for _, file := range files {
+				if g.mayRead(file) {
+					indexExamples(file, g.examples)
+				}
+			}
+		}
+	}
+	return g.examples[name]
+}
+
+// indexExamples adds the examples in a test file to index, by the name of the type they're examples of. Files that
+// can't be read or parsed are skipped, since go test will report them.
+func indexExamples(file string, index map[string][]example) {
+	src, err := ioutil.ReadFile(file)
+	if err != nil {
+		return
+	}
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, file, src, parser.ParseComments)
+	if err != nil {
+		return
+	}
+	for _, decl := range f.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv != nil || fn.Body == nil || !strings.HasPrefix(fn.Name.Name, "Example") {
+			continue
+		}
+
+		// Examples of methods, as in ExampleBucket_Method, have suffixes that start with a capital, and aren't
+		// examples of the resource itself.
+		typ, suffix := strings.TrimPrefix(fn.Name.Name, "Example"), ""
+		if i := strings.IndexByte(typ, '_'); i != -1 {
+			typ, suffix = typ[:i], typ[i+1:]
+		}
+		if typ == "" || suffix != "" && !unicode.IsLower([]rune(suffix)[0]) {
+			continue
+		}
+
+		title := exampleTitle(suffix)
+		if fn.Doc != nil {
+			title = strings.TrimSuffix(cleanComment(fn.Doc.Text()), ".")
+		}
+		start, end := fset.Position(fn.Body.Lbrace).Offset+1, fset.Position(fn.Body.Rbrace).Offset
+		index[typ] = append(index[typ], example{Title: title, Code: exampleCode(string(src[start:end])), File: file})
+	}
+}
+
+// exampleTitle turns an example's name suffix, such as `withTags`, into a title, such as `With tags`.
+func exampleTitle(suffix string) string {
+	if suffix == "" {
+		return "Basic usage"
+	}
+	var b strings.Builder
+	for i, r := range suffix {
+		switch {
+		case i == 0:
+			b.WriteRune(unicode.ToUpper(r))
+		case r == '_':
+			b.WriteRune(' ')
+		case unicode.IsUpper(r):
+			b.WriteRune(' ')
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// exampleCode cleans up an example's body: its expected output, which is for go test, is removed, and it's dedented.
+func exampleCode(body string) string {
+	lines := strings.Split(strings.Trim(body, "\n"), "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "// Output:") || strings.HasPrefix(trimmed, "// Unordered output:") {
+			lines = lines[:i]
+			break
+		}
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return strings.Join(dedent(lines), "\n")
+}
+
+// appendExamples appends examples to a resource's description, in the section registry docs render examples from.
+func appendExamples(description string, examples []example) string {
+	if len(examples) == 0 {
+		return description
+	}
+	var b strings.Builder
+	if description != "" {
+		b.WriteString(description + "\n\n")
+	}
+	b.WriteString("{{% examples %}}\n## Example Usage\n")
+	for _, e := range examples {
+		fmt.Fprintf(&b, "{{%% example %%}}\n### %s\n\n```go\n%s\n```\n{{%% /example %%}}\n", e.Title, e.Code)
+	}
+	b.WriteString("{{% /examples %}}")
+	return b.String()
+}
@@ -0,0 +1,273 @@
+package mkschema
+
+import (
+	"context"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+
+	"github.com/pulumi/pulumi-mkschema/mkschema/tags"
+)
+
+// Edit is a mechanical change to a Go source file that fixes a diagnostic, such as adding a property name to a tag.
+type Edit struct {
+	Pos     token.Position // the start of the source to replace.
+	End     token.Position // the end of the source to replace; if it's the same as Pos, NewText is inserted.
+	NewText string         // the text to replace the source with.
+
+	pos, end token.Pos // the positions within the generator's file set.
+}
+
+// edit creates an edit replacing the source from pos up to end with text.
+func (g *generator) edit(pos, end token.Pos, text string) Edit {
+	return Edit{Pos: g.Fset.Position(pos), End: g.Fset.Position(end), NewText: text, pos: pos, end: end}
+}
+
+// suggest attaches a suggested fix, and any edits that make it, to an error created by errorf or ruleErrorf.
+func suggest(err error, suggestion string, fixes ...Edit) error {
+	if perr, ok := err.(*posError); ok {
+		perr.Suggestion, perr.Fixes = suggestion, fixes
+	}
+	return err
+}
+
+// tagEdit returns an edit that sets the given key of a field's tag to value, or removes the key if value is empty. If
+// the field has no tag yet, one is added after its type.
+func (g *generator) tagEdit(fld *ast.Field, key, value string) (Edit, bool) {
+	if fld.Tag == nil {
+		if value == "" {
+			return Edit{}, false
+		}
+		tag := key + ":" + strconv.Quote(value)
+		return g.edit(fld.Type.End(), fld.Type.End(), " "+quoteTag(tag, true)), true
+	}
+	tag, err := strconv.Unquote(fld.Tag.Value)
+	if err != nil {
+		return Edit{}, false
+	}
+	return g.edit(fld.Tag.Pos(), fld.Tag.End(), quoteTag(setTagKey(tag, key, value), fld.Tag.Value[0] == '`')), true
+}
+
+// quoteTag quotes a tag as Go source, with backquotes if it was written with them and still can be.
+func quoteTag(tag string, raw bool) string {
+	if raw && !strings.Contains(tag, "`") {
+		return "`" + tag + "`"
+	}
+	return strconv.Quote(tag)
+}
+
+// setTagKey sets the given key of a struct tag to value, or removes it if value is empty, leaving the other keys as
+// they were. A new name key goes first, as is conventional, and any other new key goes last.
+func setTagKey(tag, key, value string) string {
+	var pairs []string
+	found := false
+	for _, pair := range splitTag(tag) {
+		if colon := strings.IndexByte(pair, ':'); colon == -1 || pair[:colon] != key {
+			pairs = append(pairs, pair)
+		} else if !found && value != "" {
+			pairs = append(pairs, key+":"+strconv.Quote(value))
+			found = true
+		}
+	}
+	if !found && value != "" {
+		if key == PropertyNameTag {
+			pairs = append([]string{key + ":" + strconv.Quote(value)}, pairs...)
+		} else {
+			pairs = append(pairs, key+":"+strconv.Quote(value))
+		}
+	}
+	return strings.Join(pairs, " ")
+}
+
+// splitTag splits a struct tag into its `key:"value"` pairs, as reflect.StructTag parses them. Anything after a
+// malformed pair is kept as part of it, so that nothing is lost.
+func splitTag(tag string) []string {
+	var pairs []string
+	for tag = strings.TrimLeft(tag, " "); tag != ""; tag = strings.TrimLeft(tag, " ") {
+		i := 0
+		for i < len(tag) && tag[i] > ' ' && tag[i] != ':' && tag[i] != '"' && tag[i] != 0x7f {
+			i++
+		}
+		if i == 0 || i+1 >= len(tag) || tag[i] != ':' || tag[i+1] != '"' {
+			return append(pairs, tag)
+		}
+		i++
+		for i++; i < len(tag) && tag[i] != '"'; i++ {
+			if tag[i] == '\\' {
+				i++
+			}
+		}
+		if i >= len(tag) {
+			return append(pairs, tag)
+		}
+		pairs = append(pairs, tag[:i+1])
+		tag = tag[i+1:]
+	}
+	return pairs
+}
+
+// removeTagOption removes an option from a `pschema:"..."` tag value.
+func removeTagOption(options, option string) string {
+	split, err := tags.SplitOptions(options)
+	if err != nil {
+		return options
+	}
+	var kept []string
+	for _, o := range split {
+		if o != option && o != "" {
+			kept = append(kept, o)
+		}
+	}
+	return strings.Join(kept, ",")
+}
+
+// propertyName suggests a property name for a Go field, in camel case as Pulumi properties are, such that `ID`
+// becomes `id` and `URLPath` becomes `urlPath`.
+func propertyName(field string) string {
+	runes := []rune(field)
+	n := 0
+	for n < len(runes) && unicode.IsUpper(runes[n]) {
+		n++
+	}
+	if n > 1 && n < len(runes) {
+		n-- // the last capital starts the next word.
+	}
+	for i := 0; i < n; i++ {
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes)
+}
+
+// fieldSyntax returns the syntax declaring a field of a struct type, if it can be found.
+func fieldSyntax(node *ast.TypeSpec, fld goPos) *ast.Field {
+	if s, ok := node.Type.(*ast.StructType); ok {
+		for _, f := range s.Fields.List {
+			for _, name := range f.Names {
+				if name.Pos() == fld.Pos() {
+					return f
+				}
+			}
+			if len(f.Names) == 0 && f.Type.Pos() == fld.Pos() {
+				return f
+			}
+		}
+	}
+	return nil
+}
+
+// Fix gathers the target package and applies the mechanical fixes suggested for its problems, such as adding missing
+// property names to tags, to its source files. Since fixing one problem can reveal another, such as a field that's
+// checked further once it has a name, it repeats until nothing more can be fixed. It returns the diagnostics that were
+// fixed. Problems without a mechanical fix are left alone, to be reported when generating as usual.
+func Fix(ctx context.Context, opts Options) ([]Diagnostic, error) {
+	// Gather in best-effort mode, so that every problem is found, and bypass the cache, whose diagnostics can't be
+	// fixed since they don't refer to the source that was loaded.
+	var fixable []Diagnostic
+	sink := opts.Diagnostics
+	opts.BestEffort, opts.CacheDir, opts.Diagnostics = true, "", func(d Diagnostic) {
+		if len(d.Fixes) > 0 {
+			fixable = append(fixable, d)
+		}
+		if sink != nil {
+			sink(d)
+		}
+	}
+
+	var fixed []Diagnostic
+	for round := 0; round < maxFixRounds; round++ {
+		fixable = nil
+		if _, err := Gather(ctx, opts); err != nil {
+			var partial *PartialError
+			if !errors.As(err, &partial) {
+				return nil, err
+			}
+		}
+		applied, err := ApplyFixes(fixable)
+		if err != nil {
+			return nil, err
+		} else if len(applied) == 0 {
+			break
+		}
+		fixed = append(fixed, applied...)
+	}
+	return fixed, nil
+}
+
+// maxFixRounds bounds how many times Fix gathers the package, in case a fix somehow never settles.
+const maxFixRounds = 10
+
+// ApplyFixes applies the edits that fix the given diagnostics to their source files, which are then formatted as
+// gofmt would, and returns the diagnostics that were fixed. A diagnostic is skipped if any of its edits overlap one
+// that was already applied.
+func ApplyFixes(diags []Diagnostic) ([]Diagnostic, error) {
+	byFile := make(map[string][]Diagnostic)
+	for _, d := range diags {
+		if len(d.Fixes) > 0 {
+			file := d.Fixes[0].Pos.Filename
+			byFile[file] = append(byFile[file], d)
+		}
+	}
+	var fixed []Diagnostic
+	for file, diags := range byFile {
+		applied, err := applyFileFixes(file, diags)
+		if err != nil {
+			return nil, errors.Wrapf(err, "fixing %s", file)
+		}
+		fixed = append(fixed, applied...)
+	}
+	return fixed, nil
+}
+
+func applyFileFixes(file string, diags []Diagnostic) ([]Diagnostic, error) {
+	info, err := os.Stat(file)
+	if err != nil {
+		return nil, err
+	}
+	src, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	// Choose the edits to make, skipping any that overlap, and then make them from the end of the file backwards, so
+	// that the offsets of those yet to be made still hold.
+	var edits []Edit
+	var fixed []Diagnostic
+	overlaps := func(e Edit) bool {
+		for _, o := range edits {
+			if e.Pos.Offset < o.End.Offset && o.Pos.Offset < e.End.Offset || e.Pos.Offset == o.Pos.Offset {
+				return true
+			}
+		}
+		return false
+	}
+	for _, d := range diags {
+		ok := true
+		for _, e := range d.Fixes {
+			if e.Pos.Filename != file || e.End.Offset < e.Pos.Offset || e.End.Offset > len(src) || overlaps(e) {
+				ok = false
+			}
+		}
+		if ok {
+			edits = append(edits, d.Fixes...)
+			fixed = append(fixed, d)
+		}
+	}
+	sort.Slice(edits, func(i, j int) bool { return edits[i].Pos.Offset > edits[j].Pos.Offset })
+	for _, e := range edits {
+		src = append(src[:e.Pos.Offset:e.Pos.Offset], append([]byte(e.NewText), src[e.End.Offset:]...)...)
+	}
+
+	// Realign the struct tags and comments that the edits may have shifted.
+	if formatted, err := format.Source(src); err == nil {
+		src = formatted
+	}
+	return fixed, ioutil.WriteFile(file, src, info.Mode())
+}
@@ -0,0 +1,173 @@
+package mkschema
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+)
+
+// TokenFreeze is a checked-in list of every token a package has published. Once a token is published, it may not
+// disappear from the schema unless the freeze file explicitly marks it as removed or aliases it to a new token;
+// otherwise a simple Go rename would silently replace users' resources.
+//
+// The file is line-oriented. Blank lines and lines starting with `#` are ignored, and every other line is one of:
+//
+//	<token>                     a published token
+//	removed <token>             a previously published token that was deliberately removed
+//	alias <old-token> <token>   a previously published token that was renamed to a new one
+type TokenFreeze struct {
+	Tokens  map[string]bool   // the set of published tokens.
+	Removed map[string]bool   // tokens that have been deliberately removed.
+	Aliases map[string]string // tokens that have been renamed, mapping old to new.
+}
+
+// ReadTokenFreeze parses the token freeze file at the given path.
+func ReadTokenFreeze(path string) (*TokenFreeze, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	freeze := &TokenFreeze{
+		Tokens:  make(map[string]bool),
+		Removed: make(map[string]bool),
+		Aliases: make(map[string]string),
+	}
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		switch fields := strings.Fields(text); {
+		case len(fields) == 1:
+			freeze.Tokens[fields[0]] = true
+		case len(fields) == 2 && fields[0] == "removed":
+			freeze.Removed[fields[1]] = true
+		case len(fields) == 3 && fields[0] == "alias":
+			freeze.Aliases[fields[1]] = fields[2]
+		default:
+			return nil, errors.Errorf("%s:%d: malformed token freeze entry '%s'", path, line, text)
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+	return freeze, nil
+}
+
+// WriteTokenFreeze writes out a token freeze file listing all of the tokens in the given schema. Any alias entries
+// from a prior freeze file are preserved, since they continue to drive the schema's resource aliases.
+func WriteTokenFreeze(path string, spec *schema.PackageSpec, prior *TokenFreeze) error {
+	var b strings.Builder
+	b.WriteString("# Published tokens for package " + spec.Name + ". Do not remove entries by hand; instead, mark\n")
+	b.WriteString("# them as `removed <token>` or `alias <old-token> <new-token>`.\n")
+	for _, tok := range schemaTokens(spec) {
+		b.WriteString(tok + "\n")
+	}
+	if prior != nil {
+		var olds []string
+		for old := range prior.Aliases {
+			olds = append(olds, old)
+		}
+		sort.Strings(olds)
+		for _, old := range olds {
+			fmt.Fprintf(&b, "alias %s %s\n", old, prior.Aliases[old])
+		}
+	}
+	return os.WriteFile(path, []byte(b.String()), 0600)
+}
+
+// Check verifies that every previously published token is still present in the schema, has been removed
+// explicitly, or has been aliased to a token that is present, by the freeze file or by a resource of the schema
+// itself. It returns a list of violations.
+func (f *TokenFreeze) Check(spec *schema.PackageSpec) []string {
+	current := make(map[string]bool)
+	for _, tok := range schemaTokens(spec) {
+		current[tok] = true
+	}
+	for _, res := range spec.Resources {
+		for _, alias := range res.Aliases {
+			if alias.Type != nil {
+				current[*alias.Type] = true
+			}
+		}
+	}
+
+	var violations []string
+	var published []string
+	for tok := range f.Tokens {
+		published = append(published, tok)
+	}
+	sort.Strings(published)
+	for _, tok := range published {
+		if current[tok] || f.Removed[tok] {
+			continue
+		}
+		if alias, has := f.Aliases[tok]; has {
+			if !current[alias] {
+				violations = append(violations,
+					fmt.Sprintf("published token '%s' is aliased to '%s', which does not exist", tok, alias))
+			}
+			continue
+		}
+		violations = append(violations, fmt.Sprintf("published token '%s' has disappeared; "+
+			"mark it `removed` or add an `alias` in the token freeze file", tok))
+	}
+	return violations
+}
+
+// ApplyAliases adds a schema alias to each resource that a freeze file records as having been renamed, so that
+// existing stacks see the rename rather than a replacement.
+func (f *TokenFreeze) ApplyAliases(spec *schema.PackageSpec) {
+	for old, tok := range f.Aliases {
+		res, has := spec.Resources[tok]
+		if !has {
+			continue
+		}
+		aliased := false
+		for _, alias := range res.Aliases {
+			if alias.Type != nil && *alias.Type == old {
+				aliased = true
+				break
+			}
+		}
+		if !aliased {
+			oldTok := old
+			res.Aliases = append(res.Aliases, schema.AliasSpec{Type: &oldTok})
+			sort.Slice(res.Aliases, func(i, j int) bool {
+				return aliasType(res.Aliases[i]) < aliasType(res.Aliases[j])
+			})
+			spec.Resources[tok] = res
+		}
+	}
+}
+
+func aliasType(alias schema.AliasSpec) string {
+	if alias.Type == nil {
+		return ""
+	}
+	return *alias.Type
+}
+
+// schemaTokens returns the sorted list of all resource, type, and function tokens in the schema.
+func schemaTokens(spec *schema.PackageSpec) []string {
+	var toks []string
+	for tok := range spec.Resources {
+		toks = append(toks, tok)
+	}
+	for tok := range spec.Types {
+		toks = append(toks, tok)
+	}
+	for tok := range spec.Functions {
+		toks = append(toks, tok)
+	}
+	sort.Strings(toks)
+	return toks
+}
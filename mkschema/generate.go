@@ -0,0 +1,1378 @@
+// Package mkschema translates annotated Go types into Pulumi package schemas. It is the library behind the
+// pulumi-mkschema tool, for provider build tools and code generators that want to generate schemas in-process.
+package mkschema
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/token"
+	"go/types"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/types/typeutil"
+
+	"github.com/pulumi/pulumi-mkschema/mkschema/tags"
+)
+
+// Options controls how a Go package is translated into a Pulumi package schema.
+type Options struct {
+	// Name is the Pulumi package name to generate (required).
+	Name string
+	// Package is the Go package path, or directory, to gather resource and complex types from (required).
+	Package string
+	// Dir is the directory to resolve Go packages from, which defaults to the current one. Packages resolve within
+	// its enclosing module or, inside of a Go workspace (go.work), within any of the workspace's modules.
+	Dir string
+	// BuildFlags are extra flags passed to the go command when loading packages, such as `-mod=vendor` to load
+	// dependencies from the vendor directory rather than the module cache or network.
+	BuildFlags []string
+	// Env is extra environment for the go command, as `KEY=VALUE` pairs, which take precedence over the process's,
+	// such as a GOFLAGS or GOPROXY of its own.
+	Env []string
+	// Files, if set, are the target package's files, which are parsed and type-checked as the package at Package,
+	// rather than the files the go command finds for it, such as a build rule's declared sources. Examples are only
+	// taken from the `_test.go` files among them, and resources' docs only from the markdown files among them.
+	Files []string
+	// Hermetic keeps the go command from reaching the network, from reading the user's go environment file or
+	// workspace, and from switching toolchains, so that generation can run as a sandboxed build action. Dependencies
+	// must already be in the module cache, or vendored, and the cache directory can't be used.
+	Hermetic bool
+	// Modules maps Go package paths to the schema modules their types belong to. Any package not in the map,
+	// including the target package, defaults to the `index` module, unless the module policy says otherwise.
+	Modules map[string]string
+	// ModulePolicy picks the modules of the packages that Modules doesn't map; it defaults to ModulesIndex.
+	ModulePolicy ModulePolicy
+	// Mappings maps fully qualified Go type names (like `github.com/org/pkg.Type`) to the schema type references
+	// they should be emitted as, for types defined outside of this package. A field's `ref=` option takes precedence.
+	Mappings map[string]string
+	// ResolveRefs resolves the schemas of the other packages that references are to, from their provider plugins in
+	// Pulumi's plugin cache, or from their URLs, failing on references to types or resources that they don't have. It
+	// can't be used in hermetic mode.
+	ResolveRefs bool
+	// Metadata is the package-level metadata, like its version and license, to emit into the schema.
+	Metadata Metadata
+	// Strict rejects exported fields of gathered structs that lack a `pulumi:"..."` tag, rather than skipping them.
+	Strict bool
+	// BestEffort skips, rather than fails on, any types and fields that can't be processed. Generate then returns
+	// the partial schema alongside a *PartialError listing everything that was skipped.
+	BestEffort bool
+	// SkipGenerated leaves the types declared in generated Go files, which start with the standard
+	// `// Code generated ... DO NOT EDIT.` comment, out of the schema, such as SDK code kept in the same package.
+	SkipGenerated bool
+	// IncludePreview includes the resources and types marked `//pschema:preview`, with a notice that they're
+	// experimental prepended to their descriptions. Otherwise, they're left out of the schema.
+	IncludePreview bool
+	// PruneTypes leaves the types and enums that no resource refers to, directly or through other types, out of the
+	// schema, reporting each as a note, so that helper structs don't leak into it. It can't be used to patch.
+	PruneTypes bool
+	// Only, if set, are the tokens of the only resources to keep in the schema, along with the types and enums that
+	// they refer to, directly or through other types, for generating a slice of a large package. It can't be used to
+	// patch.
+	Only []string
+	// Annotations attach schema options to types and fields whose declarations can't carry tags, by fully qualified
+	// name; see Annotations.
+	Annotations Annotations
+	// Renames map the tokens that resources, types, and enums would otherwise have to the tokens they're renamed to,
+	// aliasing each renamed resource to its old token; see ReadRenames.
+	Renames map[string]string
+	// NameTags are the tags, in order of preference, such as `protobuf`, `yaml`, or `json`, that name the properties
+	// of exported fields without a `pulumi:"<name>"` tag, for types generated from, or shared with, other frameworks;
+	// see tags.NameFrom. A field that the first such tag leaves out, as with `json:"-"`, is left out of the schema.
+	NameTags []string
+	// AutoSecret marks the properties whose names suggest that their values are secret, such as `dbPassword` or
+	// `apiKey`, as `secret`, noting each one, rather than warning that they aren't.
+	AutoSecret bool
+	// Descriptions normalize the descriptions taken from doc comments and docs files.
+	Descriptions DescriptionOptions
+	// Hooks are post-processing steps run, in order, on the generated schema, after any registered hooks.
+	Hooks []Hook
+	// LintRules are custom schema policies checked against the gathered model, after any registered rules.
+	LintRules []LintRule
+	// Diagnostics, if set, receives each diagnostic as soon as it's found.
+	Diagnostics DiagnosticSink
+	// ResolveTrace, if set, receives a line for each field tracing how its Go type was resolved to a schema type,
+	// step by step, or why it was rejected. Results from the cache aren't traced, since nothing is resolved.
+	ResolveTrace io.Writer
+	// Provenance records how each element of the model was gathered, for Model.Provenance, which otherwise only
+	// knows which rule produced each element, rather than each step taken resolving its properties' types.
+	Provenance bool
+	// CacheDir, if set, is a directory in which Generate caches its results, keyed by a hash of the options and of
+	// the target package's files. When nothing has changed, Generate skips loading the package altogether.
+	CacheDir string
+}
+
+// clone returns a deep copy of the options, so that a run doesn't share any mutable state with its caller or with
+// other runs using the same options.
+func (opts Options) clone() Options {
+	opts.Modules = cloneStringMap(opts.Modules)
+	opts.Mappings = cloneStringMap(opts.Mappings)
+	opts.Renames = cloneStringMap(opts.Renames)
+	opts.Annotations = opts.Annotations.clone()
+	opts.Metadata.Keywords = append([]string(nil), opts.Metadata.Keywords...)
+	opts.Metadata.Suppress = append([]string(nil), opts.Metadata.Suppress...)
+	opts.Metadata.Language = opts.Metadata.Language.clone()
+	opts.BuildFlags = append([]string(nil), opts.BuildFlags...)
+	opts.Env = append([]string(nil), opts.Env...)
+	opts.Files = append([]string(nil), opts.Files...)
+	opts.NameTags = append([]string(nil), opts.NameTags...)
+	opts.Only = append([]string(nil), opts.Only...)
+	opts.Descriptions.DropLines = append([]string(nil), opts.Descriptions.DropLines...)
+	opts.Hooks = append([]Hook(nil), opts.Hooks...)
+	opts.LintRules = append([]LintRule(nil), opts.LintRules...)
+	return opts
+}
+
+func cloneStringMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	c := make(map[string]string, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+// Metadata is the package-level metadata emitted into a schema, so that it can be published without post-editing.
+type Metadata struct {
+	Version     string    // the package version.
+	Description string    // the package description.
+	License     string    // the package license, such as `Apache-2.0`.
+	Repository  string    // the URL of the package's source repository.
+	Homepage    string    // the URL of the package's homepage.
+	LogoURL     string    // the URL of the package's logo, which registries show.
+	Keywords    []string  // keywords to help find the package in registries.
+	Language    Languages // the language-specific sections, which configure SDK generation.
+	// PluginDownloadURL is the URL of the server to download the provider plugin from, if it isn't Pulumi's own, as
+	// in `github://api.github.com/org/mypkg`; `pulumi plugin install` and the SDKs install it from there.
+	PluginDownloadURL string
+	// ModuleFormat is a regex whose first capturing group extracts the module name from the module part of a token,
+	// emitted as the schema's `meta.moduleFormat`. If it's empty, one is derived from the modules in use.
+	ModuleFormat string
+	// Suppress lists the codes of the diagnostics to suppress throughout the package, such as MKS010. It isn't
+	// emitted into the schema.
+	Suppress []string
+	// CustomResources makes resources custom resources, rather than components, unless they're marked
+	// `//pschema:component`. It isn't emitted into the schema, except as each resource's `isComponent`.
+	CustomResources bool
+}
+
+// PartialError is returned, along with a partial schema, when generating in best-effort mode skipped anything.
+type PartialError struct {
+	Failures []Failure // the types and fields that were skipped.
+}
+
+func (e *PartialError) Error() string {
+	return fmt.Sprintf("skipped %d type(s) or field(s) that could not be processed", len(e.Failures))
+}
+
+// CanceledError is returned when generation is cancelled part way through, carrying whatever diagnostics had been
+// gathered by then so that they aren't lost.
+type CanceledError struct {
+	Err      error     // the context's error.
+	Failures []Failure // the types and fields skipped before cancellation.
+}
+
+func (e *CanceledError) Error() string {
+	return fmt.Sprintf("generation canceled: %v", e.Err)
+}
+
+func (e *CanceledError) Unwrap() error {
+	return e.Err
+}
+
+// Generate loads the target package name, parses and analyzes it, and transforms it into
+// a Pulumi package specification. Every call is an independent run, so it's safe to generate many packages
+// concurrently within one process.
+func Generate(ctx context.Context, opts Options) (*schema.PackageSpec, error) {
+	spec, _, err := generate(ctx, opts, false)
+	return spec, err
+}
+
+// GenerateModel generates a schema as Generate does, and also returns the model it was gathered from, reconciled
+// with the final schema, so that sidecars such as token constants are derived from the same run, rather than by
+// gathering the package again. A cached schema has no model, so the cache is written but never read.
+func GenerateModel(ctx context.Context, opts Options) (*schema.PackageSpec, *Model, error) {
+	return generate(ctx, opts, true)
+}
+
+// generate generates a schema, along with the model it was gathered from if withModel is true.
+func generate(ctx context.Context, opts Options, withModel bool) (*schema.PackageSpec, *Model, error) {
+	// If there's a cache, and nothing has changed since it was written, skip straight to the hooks.
+	var key string
+	var diags []Diagnostic
+	if err := opts.checkCache(); err != nil {
+		return nil, nil, err
+	} else if opts.CacheDir != "" {
+		var err error
+		if key, err = cacheKey(ctx, opts); err != nil {
+			return nil, nil, errors.Wrapf(err, "computing cache key")
+		}
+		if entry := readCache(opts.CacheDir, key); entry != nil && !withModel {
+			spec, err := entry.replay(opts.Diagnostics)
+			spec, err = finishSchema(ctx, spec, opts.Hooks, err)
+			return spec, nil, err
+		}
+
+		// Otherwise, record the diagnostics along the way, so that a later hit can replay them.
+		sink := opts.Diagnostics
+		opts.Diagnostics = func(d Diagnostic) {
+			diags = append(diags, d)
+			if sink != nil {
+				sink(d)
+			}
+		}
+	}
+
+	// Analyze the AST and gather up all resource and schema types.
+	m, err := Gather(ctx, opts)
+	if m == nil {
+		return nil, nil, err
+	}
+	spec := m.Schema()
+	if key != "" {
+		if cacheErr := writeCache(opts.CacheDir, key, newCacheEntry(spec, diags, m.Failures)); cacheErr != nil {
+			return nil, nil, errors.Wrapf(cacheErr, "writing cache")
+		}
+	}
+	if spec, err = finishSchema(ctx, spec, opts.Hooks, err); spec == nil || !withModel {
+		return spec, nil, err
+	}
+	return spec, m.Reconcile(spec), err
+}
+
+// finishSchema gives any hooks a chance to post-process the schema, returning it along with err, which is either
+// nil or a *PartialError.
+func finishSchema(ctx context.Context, spec *schema.PackageSpec, hooks []Hook,
+	err error) (*schema.PackageSpec, error) {
+	if hookErr := runHooks(ctx, spec, hooks); hookErr != nil {
+		return nil, hookErr
+	}
+	return spec, err
+}
+
+// loadGenerator parses and type-checks the target package, along with any extra packages requested (such
+// as the component's implementation), and returns a generator ready to populate the schema. The generator holds all
+// of the state for a single run, and its own copy of the options.
+func loadGenerator(ctx context.Context, opts Options, extraPkgs ...string) (*generator, error) {
+	opts = opts.clone()
+	if opts.Name == "" {
+		return nil, errors.New("missing Pulumi package name")
+	} else if opts.Package == "" {
+		return nil, errors.New("missing Go package path")
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, &CanceledError{Err: err}
+	}
+
+	dropLines, err := compileDropLines(opts.Descriptions.DropLines)
+	if err != nil {
+		return nil, err
+	}
+	switch opts.ModulePolicy {
+	case "", ModulesIndex, ModulesVersioned, ModulesPath:
+	default:
+		return nil, errors.Errorf("unknown module policy %q", opts.ModulePolicy)
+	}
+	if opts.ResolveRefs && opts.Hermetic {
+		return nil, errors.New("references to other packages can't be resolved in hermetic mode, which reads only " +
+			"its inputs")
+	}
+	rules, err := lintRules(opts.LintRules)
+	if err != nil {
+		return nil, err
+	}
+	for _, pkg := range sortedKeys(opts.Modules) {
+		if err := checkModule(opts.Modules[pkg]); err != nil {
+			return nil, errors.Wrapf(err, "mapping Go package %s", pkg)
+		}
+	}
+
+	// Now parse and type-check the target package and get ready to analyze the contents.
+	sources, err := opts.sourceFiles()
+	if err != nil {
+		return nil, err
+	}
+	var pkgs []*packages.Package
+	noCgo, err := loadWithoutCgo(opts.goCommandEnv(), func(env []string) (err error) {
+		if sources != nil {
+			pkgs, err = loadFiles(ctx, opts, env, extraPkgs)
+		} else {
+			patterns := append([]string{opts.Package}, extraPkgs...)
+			pkgs, err = loadPackages(ctx, opts.Dir, opts.BuildFlags, env, patterns)
+		}
+		return err
+	})
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, &CanceledError{Err: ctxErr}
+		}
+		return nil, err
+	}
+
+	// Afterwards, find the specific package information for the root we parsed.
+	pkg := findPackage(pkgs, opts.Dir, opts.Package)
+	if pkg == nil {
+		return nil, errors.Errorf("missing Go package %v", opts.Package)
+	}
+
+	// Create a checker context we'll use to populate the schema.
+	var modulePath string
+	if pkg.Module != nil {
+		modulePath = pkg.Module.Path
+	}
+	g := &generator{
+		Name:          opts.Name,
+		Metadata:      opts.Metadata,
+		Dir:           opts.Dir,
+		Packages:      pkgs,
+		Fset:          pkg.Fset,
+		Pkg:           pkg.Types,
+		Files:         pkg.Syntax,
+		Info:          pkg.TypesInfo,
+		Modules:       opts.Modules,
+		ModulePolicy:  opts.ModulePolicy,
+		modulePath:    modulePath,
+		Mappings:      opts.Mappings,
+		ResolveRefs:   opts.ResolveRefs,
+		Annotations:   opts.Annotations,
+		Renames:       opts.Renames,
+		NameTags:      opts.NameTags,
+		AutoSecret:    opts.AutoSecret,
+		Strict:        opts.Strict,
+		BestEffort:    opts.BestEffort,
+		Preview:       opts.IncludePreview,
+		SkipGenerated: opts.SkipGenerated,
+		PruneTypes:    opts.PruneTypes,
+		Only:          opts.Only,
+		sourceFiles:   sources,
+		Descriptions:  opts.Descriptions,
+		dropLines:     dropLines,
+		Diagnostics:   opts.Diagnostics,
+		Trace:         opts.ResolveTrace,
+		provenance:    opts.Provenance,
+		Resources:     make(map[string]*Type),
+		Types:         make(map[string]*Type),
+		Enums:         make(map[string]*Enum),
+	}
+	g.LintRules = rules
+	if noCgo != "" {
+		g.warn("", "", errors.Errorf("loaded Go packages with cgo disabled, since it can't run (%s); declarations in "+
+			"files that import \"C\" are left out", noCgo))
+	}
+	return g, nil
+}
+
+// loadPackages loads and type-checks the packages matching the given patterns, which may be Go package paths or
+// directories, resolving them from dir within its enclosing module or workspace, and passing any build flags and
+// environment on to the go command. Type-checking has no notion of cancellation, so when the
+// context is done first, the load is abandoned in the background and the context's error returned right away.
+func loadPackages(ctx context.Context, dir string, buildFlags, env, patterns []string) ([]*packages.Package,
+	error) {
+	// Only the requested packages need their syntax and full type information, since that's all we gather from;
+	// their dependencies are loaded from compiled export data, which is far faster than type-checking them all.
+	cfg := &packages.Config{
+		Context:    ctx,
+		Dir:        dir,
+		BuildFlags: buildFlags,
+		Env:        goEnv(env),
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedImports | packages.NeedTypes |
+			packages.NeedTypesSizes | packages.NeedSyntax | packages.NeedTypesInfo | packages.NeedModule,
+	}
+
+	type result struct {
+		pkgs []*packages.Package
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		pkgs, err := packages.Load(cfg, patterns...)
+		done <- result{pkgs, err}
+	}()
+
+	var r result
+	select {
+	case r = <-done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if r.err != nil {
+		return nil, errors.Wrapf(r.err, "loading Go packages")
+	}
+
+	// Packages that fail to parse or type-check are still returned, so check for any errors along the way.
+	var errs []string
+	packages.Visit(r.pkgs, nil, func(pkg *packages.Package) {
+		for _, err := range pkg.Errors {
+			errs = append(errs, err.Error())
+		}
+	})
+	if len(errs) > 0 {
+		return nil, packageErrors(errs)
+	}
+	return r.pkgs, nil
+}
+
+// goEnv returns the go command's environment, with the given environment added to the process's, or nil, for the
+// process's alone, if there's none to add.
+func goEnv(env []string) []string {
+	if len(env) == 0 {
+		return nil
+	}
+	return append(os.Environ(), env...)
+}
+
+// findPackage looks up the loaded root package for the given pattern, which is either a Go package path or a
+// directory relative to dir.
+func findPackage(pkgs []*packages.Package, dir, pattern string) *packages.Package {
+	for _, pkg := range pkgs {
+		if pkg.PkgPath == pattern {
+			return pkg
+		}
+	}
+	if build.IsLocalImport(pattern) || filepath.IsAbs(pattern) {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(dir, pattern)
+		}
+		pkgDir, err := filepath.Abs(pattern)
+		if err != nil {
+			return nil
+		}
+		for _, pkg := range pkgs {
+			if len(pkg.GoFiles) > 0 && filepath.Dir(pkg.GoFiles[0]) == pkgDir {
+				return pkg
+			}
+		}
+	}
+	return nil
+}
+
+type generator struct {
+	Name       string
+	Metadata   Metadata
+	Dir        string              // the directory Go packages were resolved from.
+	Packages   []*packages.Package // the loaded root packages, if the generator was loaded from source.
+	Fset       *token.FileSet      // the file set the package's positions are relative to.
+	Pkg        *types.Package      // the type-checked package to gather from.
+	Files      []*ast.File         // the package's syntax, with comments.
+	Info       *types.Info         // the package's type information, for evaluating constant expressions.
+	Modules    map[string]string   // Go package paths to schema modules.
+	Mappings   map[string]string   // Go type names to external schema type references.
+	Strict     bool                // true to reject untagged exported fields.
+	Resources  map[string]*Type    // gathered resources, keyed by Go type name.
+	Types      map[string]*Type    // gathered complex types, keyed by Go type name.
+	Enums      map[string]*Enum    // gathered enums, keyed by Go type name.
+	BestEffort bool                // true to skip, rather than fail on, types and fields that can't be processed.
+	Failures   []Failure           // the types and fields skipped in best-effort mode.
+	noWarnings bool                // true to report only problems that stop types or fields from being gathered.
+	Preview    bool                // true to include the types marked `//pschema:preview`.
+
+	Annotations Annotations // schema options for types and fields, by fully qualified name.
+	NameTags    []string    // the other frameworks' tags that name the properties of fields without a `pulumi` tag.
+	AutoSecret  bool        // true to mark the properties named like secrets `secret`, rather than warning of them.
+
+	ResolveRefs bool // true to resolve the schemas of other packages that references are to, to check them.
+
+	Renames map[string]string // the tokens that resources, types, and enums are renamed from, mapped to their new ones.
+	renamed map[string]bool   // the tokens that have been renamed, to catch renames that match nothing.
+
+	ModulePolicy ModulePolicy // how to pick the modules of the packages that Modules doesn't map.
+	modulePath   string       // the path of the target package's Go module, within which packages can be versioned.
+
+	SkipGenerated bool            // true to leave out the types declared in generated files.
+	generated     map[string]bool // whether each of the package's files is generated, indexed by name.
+	sourceFiles   map[string]bool // the explicit files that may be read, by absolute path, or nil for any.
+
+	Descriptions DescriptionOptions // how to normalize descriptions.
+	dropLines    []*regexp.Regexp   // the compiled patterns of doc comment lines to leave out of descriptions.
+
+	Diagnostics DiagnosticSink // if non-nil, receives diagnostics as they're found.
+	Trace       io.Writer      // if non-nil, receives each field's type resolution trace.
+	provenance  bool           // true to record each field's type resolution steps in its property.
+
+	errors []Diagnostic // the errors reported outside of best-effort mode, to fail with once gathering is done.
+
+	PruneTypes bool     // true to leave out the types and enums that no resource refers to.
+	pruned     []string // the tokens of the types and enums left out as unreachable, sorted.
+	Only       []string // the tokens of the only resources to keep, with the types they refer to, if any.
+
+	LintRules []LintRule // the custom schema policies to check the model against.
+
+	typeNodes     map[string]*ast.TypeSpec           // the package's type declarations, indexed by name.
+	enumConstants map[*types.TypeName][]*types.Const // the package's typed constants, indexed by their types.
+	schemaTypes   map[string]*typeutil.Map           // memoized gatherSchemaType results, keyed by `ref=` option.
+	hasher        typeutil.Hasher                    // the hasher shared by all of the schemaTypes maps.
+	steps         []string                           // the steps taken resolving the current field's type, if tracing.
+	nolint        map[string]map[int][][]string      // the nolint directives' codes, indexed by file and line.
+	examples      map[string][]example               // the examples in the package's tests, indexed by type name.
+	inlining      map[*types.TypeName]bool           // the internal structs being inlined, to stop at cycles.
+
+	defaults map[*types.TypeName]map[string]interface{} // the structs' fields' defaults, indexed by field name.
+}
+
+// Failure records a type, or a field within it, that couldn't be processed in best-effort mode.
+type Failure struct {
+	Type  string // the Go type that failed.
+	Field string // the Go field that failed, if the failure was specific to one.
+	Err   error  // the reason for the failure.
+}
+
+func (f Failure) String() string {
+	if f.Field != "" {
+		return fmt.Sprintf("skipped field %s.%s: %v", f.Type, f.Field, f.Err)
+	}
+	return fmt.Sprintf("skipped type %s: %v", f.Type, f.Err)
+}
+
+// Model returns the intermediate model for everything gathered so far.
+func (g *generator) Model() *Model {
+	m := &Model{
+		Name:     g.Name,
+		Metadata: g.Metadata,
+		Package:  g.Pkg.Path(),
+		Failures: g.Failures,
+		Pruned:   g.pruned,
+	}
+	for _, r := range g.Resources {
+		m.Resources = append(m.Resources, r)
+	}
+	for _, t := range g.Types {
+		m.Types = append(m.Types, t)
+	}
+	for _, e := range g.Enums {
+		m.Enums = append(m.Enums, e)
+	}
+	sortTypes(m.Resources)
+	sortTypes(m.Types)
+	sort.Slice(m.Enums, func(i, j int) bool { return m.Enums[i].Token < m.Enums[j].Token })
+	return m
+}
+
+// checkOptions checks the options that refer to the package's types and tokens, before anything is gathered.
+func (g *generator) checkOptions() error {
+	if err := g.checkAnnotations(); err != nil {
+		return err
+	}
+	return g.checkRenames()
+}
+
+// gather gathers the package's schema, turning cancellation into a *CanceledError with the diagnostics so far.
+func (g *generator) gather(ctx context.Context) error {
+	if err := g.checkOptions(); err != nil {
+		return err
+	}
+	if err := g.gatherPackageMetadata(); err != nil {
+		return errors.Wrapf(err, "gathering Go package info")
+	}
+	if err := g.GatherPackageSchema(ctx); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return &CanceledError{Err: ctxErr, Failures: g.Failures}
+		}
+		return errors.Wrapf(err, "gathering Go package info")
+	}
+	if err := g.checkRenamesUsed(g.tokens()); err != nil {
+		return err
+	}
+	if g.ResolveRefs {
+		if err := g.resolveExternalRefs(ctx); err != nil {
+			return &CanceledError{Err: err, Failures: g.Failures}
+		}
+	}
+	if len(g.Only) > 0 {
+		if err := g.selectOnly(); err != nil {
+			return err
+		}
+	}
+	if g.PruneTypes {
+		g.pruneUnreachable()
+	}
+	g.checkLintRules(g.Model())
+	if err := g.checkErrors(); err != nil {
+		return err
+	}
+	return g.Model().checkModuleFormat()
+}
+
+// GatherPackageSchema enumerates all package-scoped types, processes them, and
+// generates the schema specs for any that are of the expected kind (resources, etc).
+func (g *generator) GatherPackageSchema(ctx context.Context) error {
+	scope := g.Pkg.Scope()
+	for _, name := range scope.Names() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		obj := scope.Lookup(name)
+		switch o := obj.(type) {
+		case *types.TypeName:
+			if err := g.GatherTypeSchemas(o); err != nil {
+				g.report(name, "", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// generatedComment is the comment that marks a Go file as generated, per https://golang.org/s/generatedcode.
+var generatedComment = regexp.MustCompile(`^// Code generated .* DO NOT EDIT\.$`)
+
+// isGenerated returns true if a position is in a generated file: one with a generated comment before its package
+// clause.
+func (g *generator) isGenerated(pos token.Pos) bool {
+	if g.generated == nil {
+		g.generated = make(map[string]bool, len(g.Files))
+		for _, file := range g.Files {
+			name := g.Fset.Position(file.Pos()).Filename
+			for _, group := range file.Comments {
+				if group.Pos() > file.Package {
+					break
+				}
+				for _, c := range group.List {
+					if generatedComment.MatchString(c.Text) {
+						g.generated[name] = true
+					}
+				}
+			}
+		}
+	}
+	return g.generated[g.Fset.Position(pos).Filename]
+}
+
+// getTypeNode finds the parsed AST information for the given type. This provides
+// us access to parser-only information such as comments.
+func (g *generator) getTypeNode(t *types.TypeName) (*ast.TypeSpec, error) {
+	if g.typeNodes == nil {
+		g.typeNodes = indexTypeNodes(g.Files)
+	}
+	if ts, has := g.typeNodes[t.Name()]; has {
+		return ts, nil
+	}
+	return nil, errors.Errorf("missing Go declaration for %v", t.Name())
+}
+
+// indexTypeNodes indexes all of the package-level type declarations in the given files by name.
+func indexTypeNodes(files []*ast.File) map[string]*ast.TypeSpec {
+	index := make(map[string]*ast.TypeSpec)
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			if gdecl, isgdecl := decl.(*ast.GenDecl); isgdecl {
+				for _, spec := range gdecl.Specs {
+					if ts, ok := spec.(*ast.TypeSpec); ok {
+						// The doc comment of a declaration of just one type, without parentheses, belongs to it.
+						if ts.Doc == nil && !gdecl.Lparen.IsValid() {
+							ts.Doc = gdecl.Doc
+						}
+						index[ts.Name.Name] = ts
+					}
+				}
+			}
+		}
+	}
+	return index
+}
+
+func (g *generator) GatherTypeSchemas(t *types.TypeName) error {
+	// First look up the declaration for this type, and skip it if it's left out of the schema, such as an internal
+	// type, which is only ever inlined into others.
+	node, err := g.getTypeNode(t)
+	if err != nil {
+		return errors.Wrapf(err, "gathering Go type info")
+	} else if g.excluded(t) != nil {
+		return nil
+	} else if err = g.checkModuleDirective(node); err != nil {
+		return err
+	}
+
+	// Now check the members of the type and ensure that it's of the expected shape.
+	switch typ := t.Type().(type) {
+	case *types.Named:
+		switch s := typ.Underlying().(type) {
+		case *types.Struct:
+			// A struct definition, possibly a resource.  First, check that all the fields are supported types.
+			return g.gatherStructSchemas(node, t, s)
+		case *types.Basic:
+			// A primitive, which is only legal as an enum, whose values are the constants declared of its type.
+			if hasTypeDirective(node, "resource") {
+				return g.ruleErrorf(node.Name, ruleTypeDirectives, "%v is marked //pschema:resource, but only structs "+
+					"can be resources", t.Name())
+			}
+			return g.gatherEnumSchema(node, t, s)
+		default:
+			return g.ruleErrorf(node.Name, ruleSchemaTypes, "%v is an illegal underlying type: %v", s,
+				reflect.TypeOf(s))
+		}
+	default:
+		return g.ruleErrorf(node.Name, ruleSchemaTypes, "%v is an illegal Go type kind: %v", t.Name(),
+			reflect.TypeOf(typ))
+	}
+}
+
+// gatherPropertySchemas gathers the properties of a struct's fields, which are those of a resource if isRes is true.
+// The properties of any internal structs it embeds are inlined, as if their fields were its own.
+func (g *generator) gatherPropertySchemas(node *ast.TypeSpec, t *types.TypeName, s *types.Struct,
+	isRes bool) ([]*Property, error) {
+
+	// Now declare the output list and walk the fields.
+	var props []*Property
+	var untagged []*types.Var
+	for i := 0; i < s.NumFields(); i++ {
+		// See if there is a Pulumi tag; if not, skip this field, unless it embeds an internal struct.
+		has, opts, err := ParsePropertyOptions(s.Tag(i))
+		if a, annotated := g.fieldAnnotation(t, s.Field(i)); annotated && err == nil {
+			has, opts = true, a.apply(opts)
+		}
+		if name, named := g.fallbackName(s, i); named && err == nil && opts.Name == "" {
+			if name == "-" {
+				continue
+			}
+			has, opts.Name = true, name
+		}
+		if err != nil {
+			fld := s.Field(i)
+			g.report(t.Name(), fld.Name(), g.tagError(node, t, fld, err))
+			continue
+		} else if it, is := g.embeddedInternal(s.Field(i)); !has && it != nil && !g.inlining[it] {
+			inlined, err := g.gatherInternalSchemas(it, is, isRes)
+			if err != nil {
+				return nil, err
+			}
+			props = append(props, inlined...)
+			continue
+		} else if !has {
+			if fld := s.Field(i); fld.Exported() && !fld.Anonymous() {
+				untagged = append(untagged, fld)
+			}
+			continue
+		}
+
+		// Fetch the field and generate its property, skipping it if we're allowed to.
+		fld := s.Field(i)
+		propSpec, err := g.gatherPropertySchema(node, t, i, fld, opts, isRes)
+		if err != nil {
+			g.report(t.Name(), fld.Name(), err)
+			continue
+		}
+
+		prop := &Property{
+			Name:    opts.Name,
+			Field:   fld,
+			Pos:     g.Fset.Position(fld.Pos()),
+			Options: opts,
+			Spec:    *propSpec,
+			owner:   t,
+		}
+		if g.provenance {
+			prop.steps = append([]string(nil), g.steps...)
+		}
+		props = append(props, prop)
+	}
+
+	// A struct that's going into the schema may not have exported fields that silently aren't in strict mode, and
+	// otherwise, they're warned about. Only strict mode fixes them, since they may have been left out on purpose.
+	if isRes || len(props) > 0 {
+		for _, fld := range untagged {
+			if g.Strict {
+				err := g.ruleErrorf(fld, ruleStrictTags,
+					"field %v.%v is exported but is missing a `pulumi:\"<name>\"` tag directive", t.Name(), fld.Name())
+				g.report(t.Name(), fld.Name(), g.suggestName(err, node, fld))
+				continue
+			}
+			err := g.ruleErrorf(fld, ruleUntaggedFields,
+				"field %v.%v is exported but has no `pulumi:\"<name>\"` tag directive, so it is left out of the schema",
+				t.Name(), fld.Name())
+			g.warn(t.Name(), fld.Name(), suggest(err, fmt.Sprintf("tag it with `pulumi:%q` to put it in the schema, "+
+				"or unexport it", propertyName(fld.Name()))))
+		}
+	}
+
+	return props, nil
+}
+
+// fallbackName returns the property name that the first of the name tags present on the i'th field of a struct gives
+// it, if the field is exported; see Options.NameTags.
+func (g *generator) fallbackName(s *types.Struct, i int) (string, bool) {
+	if !s.Field(i).Exported() {
+		return "", false
+	}
+	for _, key := range g.NameTags {
+		if name, named := tags.NameFrom(s.Tag(i), key); named {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// gatherPropertySchema validates the options for the i'th field of a struct and generates its property schema.
+func (g *generator) gatherPropertySchema(node *ast.TypeSpec, t *types.TypeName, i int, fld *types.Var,
+	opts PropertyOptions, isRes bool) (*schema.PropertySpec, error) {
+	// Problems with the options are pointed out on the tag they came from.
+	tag := goPos(fld)
+	if structNode, ok := node.Type.(*ast.StructType); ok && structNode.Fields.List[i].Tag != nil {
+		tag = structNode.Fields.List[i].Tag
+	}
+	if opts.Name == "" {
+		err := g.ruleErrorf(tag, ruleNamedProperties,
+			"field %v.%v is missing a `pulumi:\"<name>\"` tag directive", t.Name(), fld.Name())
+		return nil, g.suggestName(err, node, fld)
+	}
+	if opts.Out && !isRes {
+		err := g.ruleErrorf(tag, ruleResourceOutputs,
+			"field %v.%v is marked `out` but is not a resource property", t.Name(), fld.Name())
+		return nil, g.suggestRemoveOption(err, node, fld, t, "out")
+	}
+	if opts.Replaces && !isRes {
+		err := g.ruleErrorf(tag, ruleResourceReplaces,
+			"field %v.%v is marked `replaces` but is not a resource property", t.Name(), fld.Name())
+		return nil, g.suggestRemoveOption(err, node, fld, t, "replaces")
+	}
+	if _, isPtr := fld.Type().(*types.Pointer); !isPtr && opts.Optional {
+		// Either fix changes what the property means, so neither is made mechanically.
+		err := g.ruleErrorf(tag, ruleOptionalPointers,
+			"field %v.%v is marked `optional` but is not a pointer in the schema", t.Name(), fld.Name())
+		return nil, suggest(err, fmt.Sprintf("make the field a pointer, `*%v`, or remove `optional` to make the "+
+			"property required", types.TypeString(fld.Type(), types.RelativeTo(g.Pkg))))
+	}
+
+	// Generate the PropertySpec for this property based on its type.
+	g.steps = g.steps[:0]
+	propType, err := g.gatherSchemaType(fld.Type(), opts)
+	g.traceResolve(t, fld, propType, err)
+	if err != nil {
+		err = g.ruleErrorf(fld, rulePropertyTypes,
+			"field %v.%v is an not a legal schema type: %v", t.Name(), fld.Name(), err)
+		if ext := g.externalType(fld.Type()); ext != nil {
+			return nil, suggest(err, fmt.Sprintf("map %v to a schema type with `-mapping %v=REF`, or give the "+
+				"field a `ref=` option", ext.Obj().Name(), ext))
+		}
+		return nil, suggest(err, "use a bool, integer, float, string, struct, or enum type, or a pointer, slice, "+
+			"or string-keyed map of one")
+	}
+	propSpec := schema.PropertySpec{
+		TypeSpec: *propType,
+		Secret:   opts.Secret,
+	}
+	if v, has := g.fieldDefaults(t)[fld.Name()]; has {
+		propSpec.Default = v
+	}
+	if isEmptyInterface(fld.Type()) {
+		err = g.ruleErrorf(fld, ruleSpecificTypes,
+			"field %v.%v is an empty interface, so its property accepts any value at all", t.Name(), fld.Name())
+		g.warn(t.Name(), fld.Name(), suggest(err, "use a struct, or a map or slice of a more specific type"))
+	}
+	g.checkSecret(node, t, fld, opts.Name, &propSpec)
+	if err = g.checkRefVersions(t, fld, propType); err != nil {
+		return nil, err
+	}
+
+	// Use the property's doc-comment as the description, if available, followed by any example of its value. An
+	// annotation's description takes the place of the doc comment.
+	a, _ := g.fieldAnnotation(t, fld)
+	if structNode, ok := node.Type.(*ast.StructType); ok {
+		example := opts.Example
+		if comment := structNode.Fields.List[i].Doc; comment != nil {
+			propSpec.Description, propSpec.DeprecationMessage = g.commentDoc(comment)
+			if marked, has := marker(comment, "example"); has && example == "" {
+				example = marked
+			}
+		}
+		if a.Description != "" {
+			propSpec.Description = g.normalizeDescription(a.Description)
+		}
+		propSpec.Description = appendPropertyExample(propSpec.Description, example)
+		g.lintDescription(fld, t.Name(), fld.Name(), "field "+t.Name()+"."+fld.Name(), propSpec.Description)
+	}
+
+	return &propSpec, nil
+}
+
+// tagError attributes an error parsing a field's tag to the tag, or to the offending part of it, if it can be located
+// within the source.
+func (g *generator) tagError(node *ast.TypeSpec, t *types.TypeName, fld *types.Var, err error) error {
+	var serr *tags.SyntaxError
+	if !errors.As(err, &serr) {
+		return err
+	}
+	syntax := fieldSyntax(node, fld)
+	if syntax == nil || syntax.Tag == nil {
+		return g.ruleErrorf(fld, ruleWellFormedTags, "field %v.%v has a malformed tag: %v", t.Name(), fld.Name(),
+			serr.Msg)
+	}
+
+	// Offsets within a raw string literal are those of the tag, once past the opening quote.
+	var elem goPos = syntax.Tag
+	raw := syntax.Tag.Value[0] == '`'
+	if raw {
+		pos := syntax.Tag.Pos() + token.Pos(1+serr.Offset)
+		elem = span{pos: pos, end: pos + token.Pos(serr.Length)}
+	}
+	err = g.ruleErrorf(elem, ruleWellFormedTags, "field %v.%v has a malformed tag: %v", t.Name(), fld.Name(),
+		serr.Msg)
+
+	// Suggest the closest known option for an unknown one, which is likely a typo.
+	switch {
+	case serr.Option == "":
+		return err
+	case strings.HasPrefix(serr.Option, "ref="):
+		return suggest(err, "give it the schema type to reference, as in `ref=#/types/mypkg:index:MyType`")
+	case strings.HasPrefix(serr.Option, "example="):
+		return suggest(err, "give it an example of the property's value, as in `example=us-west-2`")
+	}
+	if closest := closestOption(serr.Option); closest != "" {
+		suggestion := fmt.Sprintf("did you mean `%v`?", closest)
+		if raw && serr.Length == len(serr.Option) {
+			return suggest(err, suggestion, g.edit(elem.Pos(), elem.(span).end, closest))
+		}
+		return suggest(err, suggestion)
+	}
+	known := tags.Options[:len(tags.Options)-1]
+	return suggest(err, fmt.Sprintf("the options are %v, and %v", strings.Join(known, ", "),
+		tags.Options[len(tags.Options)-1]))
+}
+
+// span is a range of source, from pos up to end.
+type span struct {
+	pos, end token.Pos
+}
+
+func (s span) Pos() token.Pos { return s.pos }
+func (s span) End() token.Pos { return s.end }
+
+// closestOption returns the known tag option closest to an unknown one, if any is close enough to be a typo of it.
+func closestOption(option string) string {
+	best, bestDistance := "", 3
+	for _, o := range tags.Options {
+		if strings.HasSuffix(o, ">") {
+			continue
+		}
+		if d := editDistance(option, o); d < bestDistance {
+			best, bestDistance = o, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between two strings.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+// warnUndocumented warns that a type going into the schema has no doc comment to describe it.
+func (g *generator) warnUndocumented(node *ast.TypeSpec, name string) {
+	err := g.ruleErrorf(node.Name, ruleDocumentedTypes,
+		"type %v has no doc comment, so it has no description in the schema", name)
+	g.warn(name, "", suggest(err, fmt.Sprintf("add a doc comment, such as `// %v is ...`", name)))
+}
+
+// isEmptyInterface returns true if a type is an empty interface, or a pointer to one.
+func isEmptyInterface(t types.Type) bool {
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	iface, ok := t.Underlying().(*types.Interface)
+	return ok && iface.Empty()
+}
+
+// suggestName suggests naming a field's property after the field, and adding the name to its tag.
+func (g *generator) suggestName(err error, node *ast.TypeSpec, fld *types.Var) error {
+	name := propertyName(fld.Name())
+	suggestion := fmt.Sprintf("name its property with `pulumi:%q`", name)
+	if syntax := fieldSyntax(node, fld); syntax != nil {
+		if fix, ok := g.tagEdit(syntax, PropertyNameTag, name); ok {
+			return suggest(err, suggestion, fix)
+		}
+	}
+	return suggest(err, suggestion)
+}
+
+// suggestRemoveOption suggests removing an option that only resource properties may have from a field's tag.
+func (g *generator) suggestRemoveOption(err error, node *ast.TypeSpec, fld *types.Var, t *types.TypeName,
+	option string) error {
+	suggestion := fmt.Sprintf("remove `%v`, or embed pulumi.ResourceState to make %v a resource", option, t.Name())
+	if syntax := fieldSyntax(node, fld); syntax != nil && syntax.Tag != nil {
+		if tag, uerr := strconv.Unquote(syntax.Tag.Value); uerr == nil {
+			options := removeTagOption(reflect.StructTag(tag).Get(PropertyOptionsTag), option)
+			if fix, ok := g.tagEdit(syntax, PropertyOptionsTag, options); ok {
+				return suggest(err, suggestion, fix)
+			}
+		}
+	}
+	return suggest(err, suggestion)
+}
+
+// externalType returns the named type from another package, if any, that a field's type is made of, looking
+// through pointers, slices, and maps.
+func (g *generator) externalType(t types.Type) *types.Named {
+	for {
+		switch ft := t.(type) {
+		case *types.Pointer:
+			t = ft.Elem()
+		case *types.Slice:
+			t = ft.Elem()
+		case *types.Map:
+			t = ft.Elem()
+		case *types.Named:
+			if ft.Obj().Pkg() != nil && ft.Obj().Pkg() != g.Pkg {
+				return ft
+			}
+			return nil
+		default:
+			return nil
+		}
+	}
+}
+
+// gatherStructSchemas interprets a Go struct declaration and deeply generates the resource, and/or plain old,
+// types, depending on what contents are found within.
+func (g *generator) gatherStructSchemas(node *ast.TypeSpec, t *types.TypeName, s *types.Struct) error {
+	// Skip those that we've already visited.
+	name := t.Name()
+	_, hasType := g.Types[name]
+	_, hasResource := g.Resources[name]
+	if hasType || hasResource {
+		return nil
+	}
+
+	// Extract the property metadata.
+	isRes := isResourceNode(node, t, s)
+	props, err := g.gatherPropertySchemas(node, t, s, isRes)
+	if err != nil {
+		return err
+	}
+
+	// Now generate the appropriate type information based on what we've found.
+	typ := &Type{
+		Token:      g.defaultType(name),
+		Object:     t,
+		Pos:        g.Fset.Position(t.Pos()),
+		IsResource: isRes,
+		Properties: props,
+		rule:       "struct with `pulumi` tagged fields",
+	}
+	switch {
+	case isRes && IsResource(t, s):
+		typ.rule = "struct embedding pulumi.ResourceState"
+	case isRes:
+		typ.rule = "struct marked //pschema:resource"
+	}
+	if isRes {
+		typ.Aliases = g.renamedFrom(typ.Token)
+	}
+	if typ.IsComponent, err = g.isComponent(node, isRes); err != nil {
+		return err
+	}
+
+	// Use the type's doc-comment as the description, if available. Only resources can be deprecated in the schema.
+	if node.Doc != nil && typ.IsResource {
+		typ.Description, typ.DeprecationMessage = g.commentDoc(node.Doc)
+	} else if node.Doc != nil {
+		typ.Description = g.cleanComment(node.Doc)
+	}
+	if a, _ := g.typeAnnotation(t); a.Description != "" {
+		typ.Description = g.normalizeDescription(a.Description)
+	}
+	if typ.IsResource {
+		docs, err := g.resourceDocs(name)
+		if err != nil {
+			return g.errorf(node.Name, "reading %s's docs: %v", name, err)
+		}
+		typ.Description = appendExamples(appendDocs(typ.Description, docs), g.resourceExamples(name))
+	}
+
+	if typ.IsResource {
+		g.Resources[name] = typ
+	} else if len(props) > 0 {
+		g.Types[name] = typ
+	} else {
+		return nil
+	}
+	if typ.Description == "" {
+		g.warnUndocumented(node, name)
+	}
+	typ.Description = g.previewDescription(node, typ.IsResource, typ.Description)
+	g.lintDescription(node.Name, name, "", "type "+name, typ.Description)
+
+	return nil
+}
+
+// gatherSchemaType ensures that a type has been created for the target type, and returns
+// a TypeSpec to it, either by name or reference, as appropriate. The same field types tend to crop up over and
+// over, so results are memoized by type identity and the one option that affects them, `ref=`.
+func (g *generator) gatherSchemaType(t types.Type, opts PropertyOptions) (*schema.TypeSpec, error) {
+	if g.schemaTypes == nil {
+		g.schemaTypes = make(map[string]*typeutil.Map)
+		g.hasher = typeutil.MakeHasher()
+	}
+	cache, has := g.schemaTypes[opts.Ref]
+	if !has {
+		cache = new(typeutil.Map)
+		cache.SetHasher(g.hasher)
+		g.schemaTypes[opts.Ref] = cache
+	}
+	// Each hit gets its own copy, so that the properties of the same type don't share, and so can't clobber, any
+	// type specs nested within theirs.
+	if entry, has := cache.At(t).(schemaTypeEntry); has {
+		g.steps = append(g.steps, entry.Steps...)
+		return copyTypeSpec(entry.Spec), entry.Err
+	}
+
+	start := len(g.steps)
+	spec, err := g.analyzeSchemaType(t, opts)
+	cache.Set(t, schemaTypeEntry{Spec: copyTypeSpec(spec), Err: err, Steps: append([]string(nil), g.steps[start:]...)})
+	return spec, err
+}
+
+// copyTypeSpec returns a deep copy of a type spec.
+func copyTypeSpec(spec *schema.TypeSpec) *schema.TypeSpec {
+	if spec == nil {
+		return nil
+	}
+	c := *spec
+	c.AdditionalProperties = copyTypeSpec(spec.AdditionalProperties)
+	c.Items = copyTypeSpec(spec.Items)
+	if spec.OneOf != nil {
+		c.OneOf = make([]schema.TypeSpec, len(spec.OneOf))
+		for i := range spec.OneOf {
+			c.OneOf[i] = *copyTypeSpec(&spec.OneOf[i])
+		}
+	}
+	if spec.Discriminator != nil {
+		d := *spec.Discriminator
+		if spec.Discriminator.Mapping != nil {
+			d.Mapping = make(map[string]string, len(spec.Discriminator.Mapping))
+			for k, v := range spec.Discriminator.Mapping {
+				d.Mapping[k] = v
+			}
+		}
+		c.Discriminator = &d
+	}
+	return &c
+}
+
+// schemaTypeEntry is a memoized result of gatherSchemaType.
+type schemaTypeEntry struct {
+	Spec  *schema.TypeSpec
+	Err   error
+	Steps []string // the steps taken resolving the type, if they're recorded, to replay for each field of the type.
+}
+
+// analyzeSchemaType does the work of gatherSchemaType, for a type that hasn't been seen yet.
+func (g *generator) analyzeSchemaType(t types.Type, opts PropertyOptions) (*schema.TypeSpec, error) {
+	// Only these types are legal:
+	//     - Primitives: bool, int, float, string
+	//     - Other structs
+	//     - Pointers to any of the above
+	//     - Pointers to other resource types
+	//     - Arrays of the above things
+	//     - Maps with string keys and any of the above as values
+	switch ft := t.(type) {
+	case *types.Basic:
+		g.traceStep("%v (basic)", g.typeString(t))
+		if basic, isbasic := t.(*types.Basic); isbasic {
+			switch basic.Kind() {
+			case types.Bool:
+				return &schema.TypeSpec{Type: "boolean"}, nil
+			case types.Int, types.Int16, types.Int32, types.Int64:
+				return &schema.TypeSpec{Type: "integer"}, nil
+			case types.Float32, types.Float64:
+				return &schema.TypeSpec{Type: "number"}, nil
+			case types.String:
+				return &schema.TypeSpec{Type: "string"}, nil
+			}
+		}
+		return nil, errors.Errorf("bad primitive type %v; must be bool, int, float, or string", ft)
+	case *types.Interface:
+		// interface{} is fine and is interpreted as any valid type. There is no "any" type
+		// in JSON schema, instead, we simply leave the type empty which means "no constraints."
+		// TODO: is this right? "object" is a map. Is Pulumi schema doing the right thing?
+		g.traceStep("%v (interface)", g.typeString(t))
+		return &schema.TypeSpec{Type: "object"}, nil
+	case *types.Named:
+		g.traceStep("%v (named)", g.typeString(t))
+		switch ut := ft.Underlying().(type) {
+		case *types.Basic:
+			// An enum, if it has constants, which is referenced like a struct; otherwise, just recurse.
+			if len(g.enumConsts(ft.Obj())) == 0 {
+				g.traceStep("no enum constants, so its underlying type")
+				return g.gatherSchemaType(ut, opts)
+			}
+			g.traceStep("enum")
+			if opts.Ref == "" && g.Mappings[ft.String()] == "" {
+				if err := g.excluded(ft.Obj()); err != nil {
+					return nil, err
+				}
+			}
+			refType := opts.Ref
+			if refType == "" {
+				refType = g.Mappings[ft.String()]
+			}
+			if refType == "" {
+				refType = g.defaultRefType(ft.String())
+			}
+			g.traceRef(ft, opts, refType)
+			return &schema.TypeSpec{Ref: refType}, nil
+		case *types.Interface:
+			// A named type alias of another type, just recurse.
+			return g.gatherSchemaType(ut, opts)
+		case *types.Struct:
+			if opts.Ref == "" && g.Mappings[ft.String()] == "" {
+				if err := g.excluded(ft.Obj()); err != nil {
+					return nil, err
+				}
+			}
+
+			// A struct can be either a reference to another struct within this package,
+			// or a struct defined elsewhere. In either case, we emit a reference to it. For
+			// structs defined within the same package, we don't visit the type, as it will
+			// presumably be visited as a top-level declaration anyway. If something goes wrong
+			// here, we'll generate a dangling ref, but the schema checker will catch that.
+			refType := opts.Ref
+			if refType == "" {
+				refType = g.Mappings[ft.String()]
+			}
+			if refType == "" {
+				refType = g.defaultRefType(ft.String())
+			}
+			g.traceStep("struct")
+			g.traceRef(ft, opts, refType)
+			return &schema.TypeSpec{Ref: refType}, nil
+		default:
+			return nil, errors.Errorf("bad named field type: %v", reflect.TypeOf(ut))
+		}
+	case *types.Pointer:
+		// For pointers, just use the underlying type.
+		// TODO: not sure exactly where pointers should be legal; for instance, should this imply optional?
+		g.traceStep("%v (pointer)", g.typeString(t))
+		return g.gatherSchemaType(ft.Elem(), opts)
+	case *types.Map:
+		// A map is OK so long as its key is a string (or string-backed type) and its element type is legal.
+		g.traceStep("%v (map)", g.typeString(t))
+		isStringKey := false
+		switch kt := ft.Key().(type) {
+		case *types.Basic:
+			isStringKey = (kt.Kind() == types.String)
+		case *types.Named:
+			if bt, isbt := kt.Underlying().(*types.Basic); isbt {
+				isStringKey = (bt.Kind() == types.String)
+			}
+		}
+		if !isStringKey {
+			return nil, errors.Errorf("map index type %v must be a string (or string-backed typedef)", ft.Key())
+		}
+
+		// Generate the element type and return the map type that references it.
+		et, err := g.gatherSchemaType(ft.Elem(), opts)
+		if err != nil {
+			return nil, err
+		}
+		return &schema.TypeSpec{
+			Type:                 "object",
+			AdditionalProperties: et,
+		}, nil
+	case *types.Slice:
+		// A slice is OK so long as its element type is also OK.
+		g.traceStep("%v (slice)", g.typeString(t))
+		et, err := g.gatherSchemaType(ft.Elem(), opts)
+		if err != nil {
+			return nil, err
+		}
+		return &schema.TypeSpec{
+			Type:  "array",
+			Items: et,
+		}, nil
+	}
+
+	g.traceStep("%v", g.typeString(t))
+	return nil, errors.Errorf("unrecognized field type %v: %v", t, reflect.TypeOf(t))
+}
+
+// defaultType generates a default fully qualified type name. The name may be qualified by its Go package
+// path (as in `github.com/org/pkg.Type`); otherwise it is assumed to live in the target package.
+func (g *generator) defaultType(t string) string {
+	pkg := g.Pkg.Path()
+	lix := strings.LastIndex(t, ".")
+	if lix != -1 {
+		pkg, t = t[:lix], t[lix+1:]
+	}
+	if a := g.Annotations.Types[pkg+"."+t]; a.Token != "" {
+		return g.rename(a.Token)
+	}
+	if pkg == g.Pkg.Path() {
+		if mod := g.directiveModule(t); mod != "" {
+			return g.rename(fmt.Sprintf("%s:%s:%s", g.Name, mod, t))
+		}
+	}
+	return g.rename(fmt.Sprintf("%s:%s:%s", g.Name, g.module(pkg), t))
+}
+
+// module returns the schema module that types in the given Go package belong to.
+func (g *generator) module(pkg string) string {
+	if mod, has := g.Modules[pkg]; has {
+		return mod
+	}
+	switch g.ModulePolicy {
+	case ModulesVersioned:
+		if mod := g.versionedModule(pkg); mod != "" {
+			return mod
+		}
+	case ModulesPath:
+		if mod := g.pathModule(pkg); mod != "" {
+			return mod
+		}
+	}
+	return "index"
+}
+
+// defaultRefType generates a default reference type. Unless otherwise noted, it assumes
+// we are referencing another type within the same package.
+func (g *generator) defaultRefType(t string) string {
+	dt := g.defaultType(t)
+	return fmt.Sprintf("#/types/%s", dt)
+}
+
+// diag stringifies a Go element's position for purposes of diagnostics.
+func (g *generator) diag(elem goPos) string {
+	pos := g.Fset.Position(elem.Pos())
+	return fmt.Sprintf("%s:%d,%d", pos.Filename, pos.Line, pos.Column)
+}
+
+type goPos interface {
+	Pos() token.Pos
+}
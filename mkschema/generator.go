@@ -0,0 +1,136 @@
+package mkschema
+
+import (
+	"context"
+
+	"github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+)
+
+// Generator generates schemas from a fixed configuration, assembled from functional options. It's a convenience
+// for programmatic users that would rather compose configurations than fill in an Options struct by hand.
+//
+// A Generator's configuration is fixed once it's created, and each call is an independent run, so a Generator may be
+// reused, including from many goroutines at once.
+type Generator struct {
+	opts Options
+}
+
+// Option configures a Generator.
+type Option func(opts *Options)
+
+// New creates a generator for the given Pulumi package name and Go package path, configured by the given options.
+func New(name, pkg string, options ...Option) *Generator {
+	opts := Options{Name: name, Package: pkg}
+	for _, o := range options {
+		o(&opts)
+	}
+	return &Generator{opts: opts.clone()}
+}
+
+// Options returns a copy of the options the generator was configured with.
+func (g *Generator) Options() Options {
+	return g.opts.clone()
+}
+
+// Generate generates the schema; see the Generate function.
+func (g *Generator) Generate(ctx context.Context) (*schema.PackageSpec, error) {
+	return Generate(ctx, g.opts)
+}
+
+// Gather gathers the intermediate model; see the Gather function.
+func (g *Generator) Gather(ctx context.Context) (*Model, error) {
+	return Gather(ctx, g.opts)
+}
+
+// CheckConstruct generates the schema and cross-checks it against implPkg; see the CheckConstruct function.
+func (g *Generator) CheckConstruct(ctx context.Context,
+	implPkg string) (*schema.PackageSpec, []ConstructMismatch, error) {
+	return CheckConstruct(ctx, g.opts, implPkg)
+}
+
+// WithModuleMap places the types from each Go package path in the map into the given schema module.
+func WithModuleMap(modules map[string]string) Option {
+	return func(opts *Options) {
+		for pkg, mod := range modules {
+			WithModule(pkg, mod)(opts)
+		}
+	}
+}
+
+// WithModule places the types from a Go package path into the given schema module.
+func WithModule(pkg, module string) Option {
+	return func(opts *Options) {
+		if opts.Modules == nil {
+			opts.Modules = make(map[string]string)
+		}
+		opts.Modules[pkg] = module
+	}
+}
+
+// WithModulePolicy picks the schema modules of the Go packages that aren't mapped to one.
+func WithModulePolicy(policy ModulePolicy) Option {
+	return func(opts *Options) {
+		opts.ModulePolicy = policy
+	}
+}
+
+// WithTypeMappings maps each fully qualified Go type name in the map to an external schema type reference.
+func WithTypeMappings(mappings map[string]string) Option {
+	return func(opts *Options) {
+		for typ, ref := range mappings {
+			WithTypeMapping(typ, ref)(opts)
+		}
+	}
+}
+
+// WithTypeMapping maps a fully qualified Go type name to the external schema type reference to emit for it.
+func WithTypeMapping(typ, ref string) Option {
+	return func(opts *Options) {
+		if opts.Mappings == nil {
+			opts.Mappings = make(map[string]string)
+		}
+		opts.Mappings[typ] = ref
+	}
+}
+
+// WithMetadata sets the package-level metadata to emit into the schema.
+func WithMetadata(meta Metadata) Option {
+	return func(opts *Options) {
+		opts.Metadata = meta
+	}
+}
+
+// WithStrictMode rejects exported fields of gathered structs that lack a `pulumi:"..."` tag.
+func WithStrictMode() Option {
+	return func(opts *Options) {
+		opts.Strict = true
+	}
+}
+
+// WithBestEffort skips, rather than fails on, any types and fields that can't be processed.
+func WithBestEffort() Option {
+	return func(opts *Options) {
+		opts.BestEffort = true
+	}
+}
+
+// WithHooks appends post-processing hooks to run on the generated schema.
+func WithHooks(hooks ...Hook) Option {
+	return func(opts *Options) {
+		opts.Hooks = append(opts.Hooks, hooks...)
+	}
+}
+
+// WithLintRules appends custom schema policies to check the gathered model against.
+func WithLintRules(rules ...LintRule) Option {
+	return func(opts *Options) {
+		opts.LintRules = append(opts.LintRules, rules...)
+	}
+}
+
+// WithDiagnostics sends each diagnostic to the given sink as soon as it's found.
+func WithDiagnostics(sink DiagnosticSink) Option {
+	return func(opts *Options) {
+		opts.Diagnostics = sink
+	}
+}
@@ -0,0 +1,264 @@
+package mkschema
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// docBlockKind is a kind of block within a doc comment, as go doc reads them.
+type docBlockKind int
+
+const (
+	docParagraph  docBlockKind = iota // prose, whose lines are joined.
+	docHeading                        // a `# Heading` line, or a lone title-like line before a paragraph.
+	docList                           // a bulleted or numbered list, one item per line.
+	docCode                           // indented lines of code.
+	docFenced                         // a fenced code block, which is kept verbatim.
+	docDeprecated                     // a paragraph starting with `Deprecated:`.
+)
+
+// docBlock is a block within a doc comment.
+type docBlock struct {
+	kind  docBlockKind
+	lines []string // the block's lines; for lists, each is an item, starting with its marker.
+}
+
+// cleanComment turns a doc comment's text into a markdown description, as commentDoc does, but with any `Deprecated:`
+// paragraphs kept in place, for elements that can't be deprecated in the schema.
+func cleanComment(s string) string {
+	description, _ := renderDoc(parseDoc(s), true)
+	return description
+}
+
+// commentDoc turns a doc comment's text into a markdown description, following the conventions go doc does, so that
+// registry docs render it as go doc would: paragraphs are joined onto one line each, headings, lists, and indented
+// code become their markdown equivalents, fenced code blocks are kept verbatim, and links are resolved against the
+// comment's link definitions. The message of any `Deprecated:` paragraph is returned apart from the description.
+func commentDoc(s string) (description, deprecated string) {
+	return renderDoc(parseDoc(s), false)
+}
+
+var (
+	docLinkDef    = regexp.MustCompile(`^\[([^\]]+)\]:\s+(\S+)$`)
+	docListMarker = regexp.MustCompile(`^([-*+•]|[0-9]+[.)])\s+`)
+	docLink       = regexp.MustCompile(`\[([^\[\]]+)\]`)
+	docIdentLink  = regexp.MustCompile(`^\*?([\w/.]+\.)?[A-Za-z_]\w*(\.[A-Za-z_]\w*)?$`)
+)
+
+// parseDoc splits a doc comment's text into blocks, resolving its links as it goes.
+func parseDoc(s string) []docBlock {
+	lines := strings.Split(strings.Trim(s, "\n"), "\n")
+	indented := func(line string) bool { return line != "" && (line[0] == ' ' || line[0] == '\t') }
+	blank := func(line string) bool { return strings.TrimSpace(line) == "" }
+
+	var blocks []docBlock
+	var para []string
+	flush := func() {
+		if len(para) > 0 {
+			kind := docParagraph
+			if strings.HasPrefix(para[0], "Deprecated: ") {
+				kind = docDeprecated
+			}
+			blocks, para = append(blocks, docBlock{kind: kind, lines: para}), nil
+		}
+	}
+	links := make(map[string]string)
+	for i := 0; i < len(lines); {
+		line, trimmed := lines[i], strings.TrimSpace(lines[i])
+		switch {
+		case trimmed == "":
+			flush()
+			i++
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			// A fenced code block runs until a closing fence of the same kind, or the end of the comment.
+			flush()
+			fence, block := trimmed[:3], []string{line}
+			for i++; i < len(lines); i++ {
+				block = append(block, lines[i])
+				if t := strings.TrimSpace(lines[i]); strings.HasPrefix(t, fence) && strings.Trim(t, fence[:1]) == "" {
+					i++
+					break
+				}
+			}
+			blocks = append(blocks, docBlock{kind: docFenced, lines: block})
+		case docListMarker.MatchString(trimmed):
+			// A list runs until a blank line that isn't followed by another item. Lines without markers continue the
+			// item before them.
+			flush()
+			var items []string
+			for ; i < len(lines); i++ {
+				t := strings.TrimSpace(lines[i])
+				if t == "" {
+					j := i
+					for j < len(lines) && blank(lines[j]) {
+						j++
+					}
+					if j == len(lines) || !docListMarker.MatchString(strings.TrimSpace(lines[j])) {
+						break
+					}
+					i = j - 1
+				} else if m := docListMarker.FindStringSubmatch(t); m != nil {
+					marker := "-"
+					if n := m[1]; n[len(n)-1] == '.' || n[len(n)-1] == ')' {
+						marker = n[:len(n)-1] + "."
+					}
+					items = append(items, marker+" "+t[len(m[0]):])
+				} else if strings.HasPrefix(t, "```") || strings.HasPrefix(t, "~~~") {
+					break
+				} else {
+					items[len(items)-1] += " " + t
+				}
+			}
+			blocks = append(blocks, docBlock{kind: docList, lines: items})
+		case indented(line):
+			// Indented lines are code, which runs until the next unindented one.
+			flush()
+			var code []string
+			for ; i < len(lines) && (indented(lines[i]) || blank(lines[i])); i++ {
+				code = append(code, strings.TrimRight(lines[i], " \t"))
+			}
+			for blank(code[len(code)-1]) {
+				code = code[:len(code)-1]
+			}
+			blocks = append(blocks, docBlock{kind: docCode, lines: dedent(code)})
+		case strings.HasPrefix(line, "# ") && len(para) == 0 && (i+1 == len(lines) || blank(lines[i+1])):
+			blocks = append(blocks, docBlock{kind: docHeading, lines: []string{strings.TrimSpace(line[2:])}})
+			i++
+		default:
+			if m := docLinkDef.FindStringSubmatch(trimmed); m != nil {
+				links[m[1]] = m[2]
+			} else {
+				para = append(para, trimmed)
+			}
+			i++
+		}
+	}
+	flush()
+
+	// A lone line that reads as a title, followed by a paragraph, is an old-style heading, unless it's the first.
+	for i := 1; i+1 < len(blocks); i++ {
+		if b := blocks[i]; b.kind == docParagraph && len(b.lines) == 1 && isDocHeading(b.lines[0]) &&
+			blocks[i+1].kind == docParagraph {
+			blocks[i].kind = docHeading
+		}
+	}
+
+	for i, b := range blocks {
+		if b.kind != docCode && b.kind != docFenced {
+			for j, line := range b.lines {
+				blocks[i].lines[j] = resolveDocLinks(line, links)
+			}
+		}
+	}
+	return blocks
+}
+
+// renderDoc renders a doc comment's blocks as markdown, returning the message of any `Deprecated:` paragraphs apart
+// from the description, unless they're to be kept in it.
+func renderDoc(blocks []docBlock, keepDeprecated bool) (description, deprecated string) {
+	var parts, deprecations []string
+	for _, b := range blocks {
+		switch b.kind {
+		case docParagraph:
+			parts = append(parts, strings.Join(b.lines, " "))
+		case docHeading:
+			parts = append(parts, "## "+b.lines[0])
+		case docList:
+			parts = append(parts, strings.Join(b.lines, "\n"))
+		case docCode:
+			parts = append(parts, "```\n"+strings.Join(b.lines, "\n")+"\n```")
+		case docFenced:
+			parts = append(parts, strings.Join(b.lines, "\n"))
+		case docDeprecated:
+			text := strings.Join(b.lines, " ")
+			if keepDeprecated {
+				parts = append(parts, text)
+			} else {
+				deprecations = append(deprecations, strings.TrimSpace(strings.TrimPrefix(text, "Deprecated: ")))
+			}
+		}
+	}
+	return strings.Join(parts, "\n\n"), strings.Join(deprecations, " ")
+}
+
+// resolveDocLinks turns the links in a line of prose into markdown: `[Text]`, where Text has a link definition,
+// links to its URL, and a doc link to a Go declaration, such as `[Bucket]` or `[json.Marshal]`, becomes its name.
+// Anything else in brackets, such as a markdown link, is left alone.
+func resolveDocLinks(line string, links map[string]string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range docLink.FindAllStringSubmatchIndex(line, -1) {
+		// Brackets right after a word, as in `a[i]`, are an index, and those right before a paren or bracket are
+		// already markdown.
+		if m[0] > 0 && isWordByte(line[m[0]-1]) || m[1] < len(line) && (line[m[1]] == '(' || line[m[1]] == '[') {
+			continue
+		}
+		text := line[m[2]:m[3]]
+		if url, has := links[text]; has {
+			b.WriteString(line[last:m[0]] + "[" + text + "](" + url + ")")
+		} else if docIdentLink.MatchString(text) {
+			b.WriteString(line[last:m[0]] + strings.TrimPrefix(text, "*"))
+		} else {
+			continue
+		}
+		last = m[1]
+	}
+	b.WriteString(line[last:])
+	return b.String()
+}
+
+// isWordByte returns true if b is an ASCII letter, digit, or underscore.
+func isWordByte(b byte) bool {
+	return b == '_' || '0' <= b && b <= '9' || 'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z'
+}
+
+// isDocHeading returns true if a line reads as an old-style go doc heading: it starts with a capital, ends with a
+// letter or digit, and has no punctuation but parentheses, commas, possessives, and periods within words.
+func isDocHeading(line string) bool {
+	runes := []rune(line)
+	if len(runes) == 0 || !unicode.IsUpper(runes[0]) {
+		return false
+	}
+	if last := runes[len(runes)-1]; !unicode.IsLetter(last) && !unicode.IsDigit(last) {
+		return false
+	}
+	if strings.ContainsAny(line, ";:!?+*/=[]{}_^°&§~%#@<\">\\`") {
+		return false
+	}
+	for i, r := range runes {
+		switch r {
+		case '\'':
+			if i+1 >= len(runes) || runes[i+1] != 's' || i+2 < len(runes) && runes[i+2] != ' ' {
+				return false
+			}
+		case '.':
+			if i+1 >= len(runes) || runes[i+1] == ' ' {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// dedent removes the indentation that every non-blank line shares.
+func dedent(lines []string) []string {
+	indent, first := "", true
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		lead := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if first {
+			indent, first = lead, false
+		}
+		for !strings.HasPrefix(lead, indent) {
+			indent = indent[:len(indent)-1]
+		}
+	}
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		out[i] = strings.TrimPrefix(line, indent)
+	}
+	return out
+}
@@ -0,0 +1,233 @@
+package mkschema
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+)
+
+// GraphNodeKind classifies a node of a schema's dependency graph.
+type GraphNodeKind string
+
+const (
+	// GraphResource is one of the schema's resources.
+	GraphResource GraphNodeKind = "resource"
+	// GraphFunction is one of the schema's functions.
+	GraphFunction GraphNodeKind = "function"
+	// GraphType is one of the schema's object types.
+	GraphType GraphNodeKind = "type"
+	// GraphEnum is one of the schema's enums.
+	GraphEnum GraphNodeKind = "enum"
+	// GraphExternal is a resource or type of another package that the schema refers to.
+	GraphExternal GraphNodeKind = "external"
+	// GraphMissing is a resource or type that the schema refers to, but doesn't declare.
+	GraphMissing GraphNodeKind = "missing"
+)
+
+// Graph is the dependency graph of a schema's resources, functions, and types: which of them refer to which others,
+// including those of other packages, through their properties.
+type Graph struct {
+	Nodes []GraphNode `json:"nodes"` // the resources, functions, and types, sorted by token.
+	Edges []GraphEdge `json:"edges"` // the references between them, sorted by the tokens they're from and to.
+}
+
+// GraphNode is a resource, function, or type in a schema's dependency graph.
+type GraphNode struct {
+	Token  string        `json:"token"`         // the token, as in `mypkg:storage:Bucket`.
+	Kind   GraphNodeKind `json:"kind"`          // what it is.
+	Module string        `json:"module"`        // the module part of its token.
+	Ref    string        `json:"ref,omitempty"` // the reference to it, for one in another package.
+}
+
+// GraphEdge is a reference from one resource, function, or type in a schema's dependency graph to another.
+type GraphEdge struct {
+	From       string   `json:"from"`       // the token of the resource, function, or type with the reference.
+	To         string   `json:"to"`         // the token of the resource or type it refers to.
+	Properties []string `json:"properties"` // the properties that refer to it, sorted.
+}
+
+// SchemaGraph returns the dependency graph of a schema. References to the built-in types, such as archives and
+// assets, aren't dependencies, and so aren't in the graph.
+func SchemaGraph(spec *schema.PackageSpec) *Graph {
+	g := &Graph{}
+	nodes := make(map[string]GraphNode)
+	edges := make(map[[2]string]map[string]bool)
+	addNode := func(tok string, kind GraphNodeKind, ref string) {
+		if _, has := nodes[tok]; !has {
+			nodes[tok] = GraphNode{Token: tok, Kind: kind, Module: tokenModule(tok), Ref: ref}
+		}
+	}
+	addProps := func(from string, props map[string]schema.PropertySpec) {
+		for name, p := range props {
+			p := p
+			walkTypeRefs(&p.TypeSpec, func(ref string) {
+				to, external := graphRef(spec, ref)
+				if to == "" {
+					return
+				}
+				if external {
+					addNode(to, GraphExternal, ref)
+				}
+				key := [2]string{from, to}
+				if edges[key] == nil {
+					edges[key] = make(map[string]bool)
+				}
+				edges[key][name] = true
+			})
+		}
+	}
+
+	for tok, r := range spec.Resources {
+		addNode(tok, GraphResource, "")
+		addProps(tok, r.Properties)
+		addProps(tok, r.InputProperties)
+	}
+	for tok, f := range spec.Functions {
+		addNode(tok, GraphFunction, "")
+		for _, obj := range []*schema.ObjectTypeSpec{f.Inputs, f.Outputs} {
+			if obj != nil {
+				addProps(tok, obj.Properties)
+			}
+		}
+	}
+	for tok, t := range spec.Types {
+		if len(t.Enum) > 0 {
+			addNode(tok, GraphEnum, "")
+			continue
+		}
+		addNode(tok, GraphType, "")
+		addProps(tok, t.Properties)
+	}
+
+	for key := range edges {
+		addNode(key[1], GraphMissing, "")
+	}
+	for _, n := range nodes {
+		g.Nodes = append(g.Nodes, n)
+	}
+	sort.Slice(g.Nodes, func(i, j int) bool { return g.Nodes[i].Token < g.Nodes[j].Token })
+	for key, props := range edges {
+		e := GraphEdge{From: key[0], To: key[1]}
+		for prop := range props {
+			e.Properties = append(e.Properties, prop)
+		}
+		sort.Strings(e.Properties)
+		g.Edges = append(g.Edges, e)
+	}
+	sort.Slice(g.Edges, func(i, j int) bool {
+		if g.Edges[i].From != g.Edges[j].From {
+			return g.Edges[i].From < g.Edges[j].From
+		}
+		return g.Edges[i].To < g.Edges[j].To
+	})
+	return g
+}
+
+// walkTypeRefs calls f with each type reference in a type, including those of its items, additional properties, and
+// alternatives.
+func walkTypeRefs(t *schema.TypeSpec, f func(ref string)) {
+	if t == nil {
+		return
+	}
+	if t.Ref != "" {
+		f(t.Ref)
+	}
+	walkTypeRefs(t.Items, f)
+	walkTypeRefs(t.AdditionalProperties, f)
+	for i := range t.OneOf {
+		walkTypeRefs(&t.OneOf[i], f)
+	}
+}
+
+// graphRef returns the token that a type reference refers to, and whether it's in another package, or "" if it
+// isn't a reference to a resource or type, such as one to a built-in type.
+func graphRef(spec *schema.PackageSpec, ref string) (string, bool) {
+	hash := strings.Index(ref, "#/")
+	if hash == -1 {
+		return "", false
+	}
+	doc, pointer := ref[:hash], ref[hash+2:]
+	for _, kind := range []string{"types/", "resources/"} {
+		if strings.HasPrefix(pointer, kind) {
+			tok := pointer[len(kind):]
+			if doc == "" {
+				return tok, false
+			}
+			return tok, !strings.HasPrefix(tok, spec.Name+":")
+		}
+	}
+	return "", false
+}
+
+// tokenModule returns the module part of a token, or "" if it isn't of the form `pkg:module:name`.
+func tokenModule(tok string) string {
+	if parts := strings.Split(tok, ":"); len(parts) == 3 {
+		return parts[1]
+	}
+	return ""
+}
+
+// DOT renders the graph in Graphviz's DOT language, with each module's resources, functions, and types clustered
+// together, so that references between modules stand out. Resources are boxes, functions are diamonds, enums are
+// octagons, other packages' resources and types are dashed, and those that are missing are red.
+func (g *Graph) DOT(name string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %s {\n", strconv.Quote(name))
+	b.WriteString("\trankdir=LR;\n")
+	b.WriteString("\tnode [shape=ellipse];\n")
+
+	var modules []string
+	byModule := make(map[string][]GraphNode)
+	for _, n := range g.Nodes {
+		mod := n.Module
+		if n.Kind == GraphExternal {
+			mod = ""
+		}
+		if _, has := byModule[mod]; !has {
+			modules = append(modules, mod)
+		}
+		byModule[mod] = append(byModule[mod], n)
+	}
+	sort.Strings(modules)
+	for i, mod := range modules {
+		indent := "\t"
+		if mod != "" {
+			fmt.Fprintf(&b, "\tsubgraph %s {\n", strconv.Quote(fmt.Sprintf("cluster_%d", i)))
+			fmt.Fprintf(&b, "\t\tlabel=%s;\n", strconv.Quote(mod))
+			indent = "\t\t"
+		}
+		for _, n := range byModule[mod] {
+			label := n.Token
+			if n.Kind != GraphExternal {
+				label = n.Token[strings.LastIndex(n.Token, ":")+1:]
+			}
+			var attrs string
+			switch n.Kind {
+			case GraphResource:
+				attrs = ", shape=box"
+			case GraphFunction:
+				attrs = ", shape=diamond"
+			case GraphEnum:
+				attrs = ", shape=octagon"
+			case GraphExternal:
+				attrs = ", style=dashed"
+			case GraphMissing:
+				attrs = ", color=red"
+			}
+			fmt.Fprintf(&b, "%s%s [label=%s%s];\n", indent, strconv.Quote(n.Token), strconv.Quote(label), attrs)
+		}
+		if mod != "" {
+			b.WriteString("\t}\n")
+		}
+	}
+
+	for _, e := range g.Edges {
+		fmt.Fprintf(&b, "\t%s -> %s [label=%s];\n", strconv.Quote(e.From), strconv.Quote(e.To),
+			strconv.Quote(strings.Join(e.Properties, ", ")))
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
@@ -0,0 +1,183 @@
+package mkschema
+
+import (
+	"context"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/tools/go/packages"
+)
+
+// hermeticEnv is the go command's environment in hermetic mode, which keeps it from reaching the network, from
+// reading the user's go environment file or workspace, and from switching to another toolchain.
+var hermeticEnv = []string{"GOPROXY=off", "GOSUMDB=off", "GOENV=off", "GOWORK=off", "GOTOOLCHAIN=local"}
+
+// goCommandEnv returns the extra environment for the go command that the options call for.
+func (opts Options) goCommandEnv() []string {
+	if !opts.Hermetic {
+		return opts.Env
+	}
+	return append(append([]string(nil), hermeticEnv...), opts.Env...)
+}
+
+// checkCache rejects a cache directory when the options can't use one: in hermetic mode, in which nothing may be
+// written outside of the outputs, or with explicit files, which the cache's key can't account for.
+func (opts Options) checkCache() error {
+	if opts.CacheDir != "" && (opts.Hermetic || len(opts.Files) > 0) {
+		return errors.New("a cache directory can't be used in hermetic mode, or with explicit files")
+	}
+	return nil
+}
+
+// sourceFiles returns the absolute paths of the options' explicit files, or nil if there are none.
+func (opts Options) sourceFiles() (map[string]bool, error) {
+	if len(opts.Files) == 0 {
+		return nil, nil
+	}
+	files := make(map[string]bool, len(opts.Files))
+	for _, file := range opts.Files {
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(opts.Dir, file)
+		}
+		path, err := filepath.Abs(file)
+		if err != nil {
+			return nil, err
+		}
+		files[path] = true
+	}
+	return files, nil
+}
+
+// mayRead returns true if a file other than the package's Go files, such as a test file with examples, or a resource's
+// docs, may be read: with explicit files, only they may be.
+func (g *generator) mayRead(file string) bool {
+	return g.sourceFiles == nil || g.sourceFiles[file]
+}
+
+// loadFiles parses and type-checks the options' explicit files, other than tests and docs, as the package at the
+// options' Package path, rather than asking the go command for the package's files, so that nothing but the files is
+// read from it. Their imports are loaded from compiled export data, resolved from the options' Dir, as are any extra
+// packages, by the go command with the given extra environment.
+func loadFiles(ctx context.Context, opts Options, env, extraPkgs []string) ([]*packages.Package, error) {
+	sources, err := opts.sourceFiles()
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for path := range sources {
+		if strings.HasSuffix(path, ".go") && !strings.HasSuffix(path, "_test.go") {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	fset := token.NewFileSet()
+	var syntax []*ast.File
+	var goFiles []string
+	imports := make(map[string]bool)
+	for _, path := range paths {
+		f, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing Go files")
+		}
+		for _, spec := range f.Imports {
+			if imp, err := strconv.Unquote(spec.Path.Value); err == nil {
+				imports[imp] = true
+			}
+		}
+		syntax, goFiles = append(syntax, f), append(goFiles, path)
+	}
+
+	// The imports are each loaded by the same go command, so that they share the types of their own dependencies.
+	paths = nil
+	for imp := range imports {
+		if imp != "C" && imp != "unsafe" {
+			paths = append(paths, imp)
+		}
+	}
+	sort.Strings(paths)
+	deps := make(map[string]*types.Package)
+	if len(paths) > 0 {
+		loaded, err := packages.Load(&packages.Config{
+			Context:    ctx,
+			Dir:        opts.Dir,
+			BuildFlags: opts.BuildFlags,
+			Env:        goEnv(env),
+			Mode:       packages.NeedName | packages.NeedTypes,
+		}, paths...)
+		if err != nil {
+			return nil, errors.Wrapf(err, "loading Go packages")
+		}
+		var errs []string
+		packages.Visit(loaded, nil, func(dep *packages.Package) {
+			for _, err := range dep.Errors {
+				errs = append(errs, err.Error())
+			}
+		})
+		if len(errs) > 0 {
+			return nil, packageErrors(errs)
+		}
+		for _, dep := range loaded {
+			deps[dep.PkgPath] = dep.Types
+		}
+	}
+
+	info := &types.Info{
+		Types:      make(map[ast.Expr]types.TypeAndValue),
+		Defs:       make(map[*ast.Ident]types.Object),
+		Uses:       make(map[*ast.Ident]types.Object),
+		Implicits:  make(map[ast.Node]types.Object),
+		Selections: make(map[*ast.SelectorExpr]*types.Selection),
+		Scopes:     make(map[ast.Node]*types.Scope),
+	}
+	conf := types.Config{
+		Importer: importerFunc(func(path string) (*types.Package, error) {
+			if path == "unsafe" {
+				return types.Unsafe, nil
+			} else if dep, has := deps[path]; has {
+				return dep, nil
+			}
+			return nil, errors.Errorf("missing Go package %s", path)
+		}),
+		Sizes: types.SizesFor("gc", runtime.GOARCH),
+		// Files that import "C" are checked without running cgo, which only their C identifiers need.
+		FakeImportC: true,
+	}
+	pkg, err := conf.Check(opts.Package, fset, syntax, info)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing Go files")
+	}
+	pkgs := []*packages.Package{{
+		ID:        opts.Package,
+		Name:      pkg.Name(),
+		PkgPath:   opts.Package,
+		GoFiles:   goFiles,
+		Fset:      fset,
+		Syntax:    syntax,
+		Types:     pkg,
+		TypesInfo: info,
+	}}
+	if len(extraPkgs) > 0 {
+		extra, err := loadPackages(ctx, opts.Dir, opts.BuildFlags, env, extraPkgs)
+		if err != nil {
+			return nil, err
+		}
+		pkgs = append(pkgs, extra...)
+	}
+	return pkgs, nil
+}
+
+// importerFunc is a types.Importer implemented by a function.
+type importerFunc func(path string) (*types.Package, error)
+
+func (f importerFunc) Import(path string) (*types.Package, error) {
+	return f(path)
+}
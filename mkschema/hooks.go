@@ -0,0 +1,54 @@
+package mkschema
+
+import (
+	"context"
+	"strconv"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+)
+
+// Hook is a post-processing step that may inspect and mutate a generated schema before it is serialized, for
+// instance to inject language sections or to rewrite tokens, without forking the tool.
+type Hook func(spec *schema.PackageSpec) error
+
+var (
+	registeredHooksLock sync.Mutex
+	registeredHooks     []namedHook
+)
+
+type namedHook struct {
+	Name string
+	Hook Hook
+}
+
+// RegisterHook registers a hook that runs after every generation in this process, before any hooks supplied in
+// Options. This is meant to be called from init functions, so that plugins can be linked into a custom build of
+// the tool with a blank import.
+func RegisterHook(name string, hook Hook) {
+	registeredHooksLock.Lock()
+	defer registeredHooksLock.Unlock()
+	registeredHooks = append(registeredHooks, namedHook{Name: name, Hook: hook})
+}
+
+// runHooks runs all registered hooks, followed by the given ones, in order, stopping at the first failure or when
+// the context is cancelled.
+func runHooks(ctx context.Context, spec *schema.PackageSpec, hooks []Hook) error {
+	registeredHooksLock.Lock()
+	all := append([]namedHook(nil), registeredHooks...)
+	registeredHooksLock.Unlock()
+	for i, hook := range hooks {
+		all = append(all, namedHook{Name: "#" + strconv.Itoa(i), Hook: hook})
+	}
+
+	for _, h := range all {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := h.Hook(spec); err != nil {
+			return errors.Wrapf(err, "running post-processing hook %s", h.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,438 @@
+package mkschema
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+)
+
+// Import scaffolds the Go source of a package of annotated structs from a Pulumi package schema: the reverse of
+// Generate, so that packages with hand-written schemas can adopt the generator without retyping them. Each resource
+// becomes a struct embedding pulumi.ResourceState, and each object type a struct of tagged fields, documented with the
+// schema's descriptions.
+//
+// Types from all of the schema's modules are declared in the one Go package. References to other packages' types
+// become empty placeholder structs, whose fields carry the reference in a `ref=` option. Schema features that the
+// generator doesn't yet produce are approximated or left out: enums are declared by their underlying primitive types,
+// and functions are skipped.
+func Import(spec *schema.PackageSpec, goPkg string) ([]byte, error) {
+	imp := &importer{
+		spec:         spec,
+		names:        make(map[string]string),
+		taken:        make(map[string]bool),
+		placeholders: make(map[string]string),
+	}
+	imp.nameTokens()
+
+	var body bytes.Buffer
+	for _, tok := range sortedKeys(spec.Resources) {
+		if err := imp.resource(&body, tok, spec.Resources[tok]); err != nil {
+			return nil, errors.Wrapf(err, "importing resource %s", tok)
+		}
+	}
+	for _, tok := range sortedKeys(spec.Types) {
+		if err := imp.complexType(&body, tok, spec.Types[tok]); err != nil {
+			return nil, errors.Wrapf(err, "importing type %s", tok)
+		}
+	}
+	for _, ref := range sortedKeys(imp.placeholders) {
+		fmt.Fprintf(&body, "\n// %s is a placeholder for the externally defined type %s.\ntype %s struct{}\n",
+			imp.placeholders[ref], ref, imp.placeholders[ref])
+	}
+
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "// Package %s declares the schema of the %s Pulumi package.\npackage %s\n", goPkg, spec.Name,
+		goPkg)
+	if imp.usesPulumi {
+		fmt.Fprintf(&src, "\nimport \"github.com/pulumi/pulumi/sdk/v3/go/pulumi\"\n")
+	}
+	src.Write(body.Bytes())
+
+	out, err := format.Source(src.Bytes())
+	if err != nil {
+		return nil, errors.Wrapf(err, "formatting imported Go source")
+	}
+	return out, nil
+}
+
+// importer holds the state of an Import.
+type importer struct {
+	spec         *schema.PackageSpec
+	names        map[string]string // the Go names of the package's resources and types, keyed by token.
+	taken        map[string]bool   // the Go names in use at the package level.
+	placeholders map[string]string // the Go names of placeholders for external types, keyed by reference.
+	usesPulumi   bool              // true if the Pulumi SDK needs to be imported.
+}
+
+// nameTokens assigns Go names to the package's resources and types. Names are taken from the last component of the
+// token, qualified by the module when that would collide with a name already taken.
+func (imp *importer) nameTokens() {
+	tokens := append(sortedKeys(imp.spec.Resources), sortedKeys(imp.spec.Types)...)
+	for _, tok := range tokens {
+		parts := strings.Split(tok, ":")
+		name := goName(parts[len(parts)-1])
+		if imp.taken[name] && len(parts) == 3 {
+			name = goName(parts[1]) + name
+		}
+		imp.names[tok] = imp.claim(name)
+	}
+}
+
+// claim reserves a package-level Go name, suffixing it with a number if it's already taken.
+func (imp *importer) claim(name string) string {
+	unique := name
+	for i := 2; imp.taken[unique]; i++ {
+		unique = name + strconv.Itoa(i)
+	}
+	imp.taken[unique] = true
+	return unique
+}
+
+// scaffoldTokens claims the tokens of the resources and types of a schema converted from another format's, such as a
+// Terraform provider's, to be scaffolded by Import. Every resource and type is in the index module.
+type scaffoldTokens struct {
+	pkg   string          // the package's name.
+	taken map[string]bool // the names of the resources and types claimed so far.
+}
+
+// claim claims the token of a resource or type of the given name, suffixing the name with a number if it's taken.
+func (t *scaffoldTokens) claim(name string) string {
+	unique := name
+	for i := 2; t.taken[unique]; i++ {
+		unique = name + strconv.Itoa(i)
+	}
+	t.taken[unique] = true
+	return t.pkg + ":index:" + unique
+}
+
+// importField is a struct field to declare.
+type importField struct {
+	Name    string
+	Spec    schema.PropertySpec
+	Options PropertyOptions
+}
+
+func (imp *importer) resource(w *bytes.Buffer, tok string, res schema.ResourceSpec) error {
+	imp.usesPulumi = true
+
+	required := stringSet(res.Required)
+	requiredInputs := stringSet(res.RequiredInputs)
+	var fields []importField
+	for _, name := range sortedKeys(unionProperties(res.InputProperties, res.Properties)) {
+		in, isIn := res.InputProperties[name]
+		out, isOut := res.Properties[name]
+		f := importField{Name: name, Spec: out}
+		if isIn {
+			f.Spec = in
+			f.Options.Optional = !requiredInputs[name]
+		} else {
+			f.Options.Optional = !required[name]
+		}
+		if f.Spec.Description == "" {
+			f.Spec.Description = out.Description
+		}
+		f.Options.In = !isOut
+		f.Options.Out = !isIn
+		f.Options.Replaces = in.ReplaceOnChanges || out.ReplaceOnChanges
+		f.Options.Secret = in.Secret || out.Secret
+		fields = append(fields, f)
+	}
+
+	fmt.Fprintf(w, "\n")
+	writeDocComment(w, "", res.Description)
+	if !res.IsComponent {
+		if strings.TrimSpace(res.Description) != "" {
+			fmt.Fprintf(w, "//\n")
+		}
+		fmt.Fprintf(w, "//pschema:custom\n")
+	}
+	fmt.Fprintf(w, "type %s struct {\n\tpulumi.ResourceState\n\n", imp.names[tok])
+	if err := imp.fields(w, fields, map[string]bool{"ResourceState": true}); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "}\n")
+	return nil
+}
+
+func (imp *importer) complexType(w *bytes.Buffer, tok string, typ schema.ComplexTypeSpec) error {
+	name := imp.names[tok]
+	if len(typ.Enum) > 0 {
+		return nil // enums are declared by their underlying types, where they're used.
+	}
+
+	required := stringSet(typ.Required)
+	var fields []importField
+	for _, prop := range sortedKeys(typ.Properties) {
+		fields = append(fields, importField{
+			Name:    prop,
+			Spec:    typ.Properties[prop],
+			Options: PropertyOptions{Optional: !required[prop], Secret: typ.Properties[prop].Secret},
+		})
+	}
+
+	fmt.Fprintf(w, "\n")
+	writeDocComment(w, "", typ.Description)
+	fmt.Fprintf(w, "type %s struct {\n", name)
+	if err := imp.fields(w, fields, map[string]bool{}); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "}\n")
+	return nil
+}
+
+// fields writes the fields of a struct, avoiding the names already in use.
+func (imp *importer) fields(w *bytes.Buffer, fields []importField, used map[string]bool) error {
+	for _, f := range fields {
+		var ref string
+		typ, err := imp.goType(f.Spec.TypeSpec, &ref)
+		if err != nil {
+			return errors.Wrapf(err, "property %s", f.Name)
+		}
+		if f.Options.Optional && !strings.HasPrefix(typ, "*") {
+			typ = "*" + typ
+		}
+
+		fieldName := goName(f.Name)
+		for i := 2; used[fieldName]; i++ {
+			fieldName = goName(f.Name) + strconv.Itoa(i)
+		}
+		used[fieldName] = true
+
+		var opts []string
+		for _, opt := range []struct {
+			set  bool
+			name string
+		}{
+			{f.Options.Optional, "optional"},
+			{f.Options.Replaces, "replaces"},
+			{f.Options.In, "in"},
+			{f.Options.Out, "out"},
+			{f.Options.Secret, "secret"},
+			{ref != "", "ref=" + ref},
+		} {
+			if opt.set {
+				opts = append(opts, opt.name)
+			}
+		}
+		tag := fmt.Sprintf("%s:%q", PropertyNameTag, f.Name)
+		if len(opts) > 0 {
+			tag += fmt.Sprintf(" %s:%q", PropertyOptionsTag, strings.Join(opts, ","))
+		}
+
+		if f.Spec.Description != "" {
+			fmt.Fprintf(w, "\n")
+			writeDocComment(w, "\t", f.Spec.Description)
+		}
+		fmt.Fprintf(w, "\t%s %s `%s`\n", fieldName, typ, tag)
+	}
+	return nil
+}
+
+// goType returns the Go type declaring a schema type. If the type references something other than one of the
+// package's own object or enum types, the reference is stored in ref, for the field's `ref=` option.
+func (imp *importer) goType(t schema.TypeSpec, ref *string) (string, error) {
+	if t.Ref != "" {
+		return imp.goRefType(t.Ref, ref)
+	}
+	switch t.Type {
+	case "string":
+		return "string", nil
+	case "integer":
+		return "int", nil
+	case "number":
+		return "float64", nil
+	case "boolean":
+		return "bool", nil
+	case "array":
+		if t.Items == nil {
+			return "[]interface{}", nil
+		}
+		et, err := imp.goType(*t.Items, ref)
+		if err != nil {
+			return "", err
+		}
+		return "[]" + et, nil
+	case "object":
+		if t.AdditionalProperties == nil {
+			return "interface{}", nil
+		}
+		et, err := imp.goType(*t.AdditionalProperties, ref)
+		if err != nil {
+			return "", err
+		}
+		return "map[string]" + et, nil
+	case "":
+		if len(t.OneOf) > 0 {
+			return "interface{}", nil
+		}
+	}
+	return "", errors.Errorf("unsupported schema type %q", t.Type)
+}
+
+func (imp *importer) goRefType(r string, ref *string) (string, error) {
+	switch {
+	case r == "pulumi.json#/Any":
+		return "interface{}", nil
+	case strings.HasPrefix(r, "#/types/"):
+		tok := strings.TrimPrefix(r, "#/types/")
+		if typ, has := imp.spec.Types[tok]; has && len(typ.Enum) > 0 {
+			return imp.goType(schema.TypeSpec{Type: typ.Type}, ref)
+		} else if has {
+			return imp.names[tok], nil
+		}
+	case strings.HasPrefix(r, "#/resources/"):
+		if name, has := imp.names[strings.TrimPrefix(r, "#/resources/")]; has {
+			return imp.setRef(ref, r, "*"+name)
+		}
+	}
+
+	// Anything else is defined elsewhere, so it's declared with a placeholder.
+	name, has := imp.placeholders[r]
+	if !has {
+		base := r[strings.LastIndexAny(r, "/:")+1:]
+		name = imp.claim(goName(base))
+		imp.placeholders[r] = name
+	}
+	return imp.setRef(ref, r, name)
+}
+
+// setRef records a field's `ref=` option. Only one is allowed per field, since it applies to the field's whole type.
+func (imp *importer) setRef(ref *string, r, typ string) (string, error) {
+	if ref == nil {
+		return "", errors.Errorf("reference %s cannot be declared here", r)
+	} else if *ref != "" && *ref != r {
+		return "", errors.Errorf("references %s and %s cannot both be declared in the same property", *ref, r)
+	}
+	*ref = r
+	return typ, nil
+}
+
+// goName turns a schema name into an exported Go identifier.
+func goName(s string) string {
+	var b strings.Builder
+	upper := true
+	for _, c := range s {
+		switch {
+		case !unicode.IsLetter(c) && !unicode.IsDigit(c):
+			upper = true
+		case upper:
+			b.WriteRune(unicode.ToUpper(c))
+			upper = false
+		default:
+			b.WriteRune(c)
+		}
+	}
+	name := b.String()
+	if name == "" || !unicode.IsLetter([]rune(name)[0]) {
+		name = "X" + name
+	}
+	return name
+}
+
+// writeDocComment writes a description as a doc comment, wrapped to 120 columns.
+func writeDocComment(w *bytes.Buffer, indent, description string) {
+	description = strings.TrimSpace(description)
+	if description == "" {
+		return
+	}
+
+	const width = 120
+	for _, para := range strings.Split(description, "\n") {
+		line := indent + "//"
+		for _, word := range strings.Fields(para) {
+			if len(line)+1+len(word) > width && line != indent+"//" {
+				fmt.Fprintln(w, line)
+				line = indent + "//"
+			}
+			line += " " + word
+		}
+		fmt.Fprintln(w, line)
+	}
+}
+
+// sortedKeys returns a map's keys in sorted order.
+func sortedKeys(m interface{}) []string {
+	var keys []string
+	switch m := m.(type) {
+	case map[string]schema.ResourceSpec:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	case map[string]schema.ComplexTypeSpec:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	case map[string]schema.PropertySpec:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	case map[string]TypeAnnotation:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	case map[string]FieldAnnotation:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	case map[string]string:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	case map[string]bool:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	case map[string]map[string]string:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	case map[string]tfProvider:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	case map[string]tfSchema:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	case map[string]tfAttribute:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	case map[string]tfBlockType:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	case map[string]*openAPISchema:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func unionProperties(a, b map[string]schema.PropertySpec) map[string]bool {
+	props := make(map[string]bool)
+	for k := range a {
+		props[k] = true
+	}
+	for k := range b {
+		props[k] = true
+	}
+	return props
+}
+
+func stringSet(ss []string) map[string]bool {
+	set := make(map[string]bool)
+	for _, s := range ss {
+		set[s] = true
+	}
+	return set
+}
@@ -0,0 +1,111 @@
+package mkschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"gopkg.in/yaml.v2"
+)
+
+// LanguageDescriptions are descriptions of a package and its resources that are specific to each language, such as
+// install notes that differ between Python and Node.js, which go into the `description` of their `language` sections,
+// so that docs can be contributed without touching the Go code.
+type LanguageDescriptions struct {
+	// Package maps languages, such as `python`, to the package's description in them.
+	Package map[string]string `json:"package,omitempty"`
+	// Resources maps resources' tokens to maps of languages to their descriptions in them.
+	Resources map[string]map[string]string `json:"resources,omitempty"`
+}
+
+// descriptionLanguages are the languages that descriptions may be given in, which are those with language sections.
+var descriptionLanguages = []string{"csharp", "go", "java", "nodejs", "python"}
+
+// ReadLanguageDescriptions reads language-specific descriptions from a YAML, or JSON, file, whose `package` key maps
+// languages to the package's descriptions in them, and whose `resources` key maps resources' tokens to the same.
+// Unknown keys and languages are rejected, so that typos don't go unnoticed.
+func ReadLanguageDescriptions(path string) (LanguageDescriptions, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return LanguageDescriptions{}, err
+	}
+
+	// Decode the YAML generically and re-encode it as JSON, as ReadMetadata does, to reject unknown keys.
+	var doc interface{}
+	if err = yaml.Unmarshal(b, &doc); err != nil {
+		return LanguageDescriptions{}, errors.Wrapf(err, "parsing language descriptions file %s", path)
+	}
+	if b, err = json.Marshal(jsonValue(doc)); err != nil {
+		return LanguageDescriptions{}, errors.Wrapf(err, "parsing language descriptions file %s", path)
+	}
+	var d LanguageDescriptions
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err = dec.Decode(&d); err != nil {
+		return LanguageDescriptions{}, errors.Wrapf(err, "parsing language descriptions file %s", path)
+	}
+	all := []map[string]string{d.Package}
+	for _, tok := range sortedKeys(d.Resources) {
+		all = append(all, d.Resources[tok])
+	}
+	for _, descs := range all {
+		for _, lang := range sortedKeys(descs) {
+			if i := sort.SearchStrings(descriptionLanguages, lang); i == len(descriptionLanguages) ||
+				descriptionLanguages[i] != lang {
+				return LanguageDescriptions{}, errors.Errorf("parsing language descriptions file %s: unknown "+
+					"language %q; expected one of %s", path, lang, strings.Join(descriptionLanguages, ", "))
+			}
+		}
+	}
+	return d, nil
+}
+
+// LanguageDescriptionsHook returns a hook that merges language-specific descriptions into the `language` sections of
+// the package and its resources, adding to whatever else the sections configure. A resource that isn't in the schema
+// is an error, since its descriptions would otherwise silently go unused.
+func LanguageDescriptionsHook(d LanguageDescriptions) Hook {
+	return func(spec *schema.PackageSpec) error {
+		var err error
+		if spec.Language, err = setLanguageDescriptions(spec.Language, d.Package); err != nil {
+			return errors.Wrap(err, "setting the package's language descriptions")
+		}
+		for _, tok := range sortedKeys(d.Resources) {
+			r, has := spec.Resources[tok]
+			if !has {
+				return errors.Errorf("language descriptions are given for resource %s, which isn't in the schema", tok)
+			}
+			if r.Language, err = setLanguageDescriptions(r.Language, d.Resources[tok]); err != nil {
+				return errors.Wrapf(err, "setting the language descriptions of resource %s", tok)
+			}
+			spec.Resources[tok] = r
+		}
+		return nil
+	}
+}
+
+// setLanguageDescriptions sets the `description` of each given language's section, creating sections as needed.
+func setLanguageDescriptions(sections map[string]schema.RawMessage,
+	descs map[string]string) (map[string]schema.RawMessage, error) {
+	for _, lang := range sortedKeys(descs) {
+		section := make(map[string]interface{})
+		if raw, has := sections[lang]; has {
+			if err := json.Unmarshal(raw, &section); err != nil {
+				return nil, errors.Wrapf(err, "parsing the %s section", lang)
+			}
+		}
+		section["description"] = strings.TrimSpace(descs[lang])
+		b, err := json.Marshal(section)
+		if err != nil {
+			return nil, err
+		}
+		if sections == nil {
+			sections = make(map[string]schema.RawMessage)
+		}
+		sections[lang] = b
+	}
+	return sections, nil
+}
@@ -0,0 +1,151 @@
+package mkschema
+
+import (
+	"encoding/json"
+
+	"github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+)
+
+// Languages are the language-specific sections of a schema, which configure SDK generation in each language. Unset
+// sections are left out of the schema.
+type Languages struct {
+	Go     *GoLanguage     // the `language.go` section.
+	NodeJS *NodeJSLanguage // the `language.nodejs` section.
+	Python *PythonLanguage // the `language.python` section.
+	CSharp *CSharpLanguage // the `language.csharp` section.
+	Java   *JavaLanguage   // the `language.java` section.
+}
+
+// GoLanguage is the schema's `language.go` section. A schema without an import base path produces a Go SDK whose
+// packages import each other by paths that don't exist.
+type GoLanguage struct {
+	// ImportBasePath is the import path of the Go SDK's root package, such as `github.com/org/mypkg/sdk/go/mypkg`.
+	ImportBasePath string `json:"importBasePath,omitempty"`
+	// GenerateResourceContainerTypes generates array and map types for resources, as well as for object types.
+	GenerateResourceContainerTypes bool `json:"generateResourceContainerTypes,omitempty"`
+	// InternalDependencies are Go packages the SDK depends on only internally, which are blank-imported so that
+	// they're kept in its go.mod.
+	InternalDependencies []string `json:"internalDependencies,omitempty"`
+}
+
+// NodeJSLanguage is the schema's `language.nodejs` section, which fills in the TypeScript SDK's package.json.
+type NodeJSLanguage struct {
+	// PackageName is the NPM package name, such as `@org/mypkg`.
+	PackageName string `json:"packageName,omitempty"`
+	// Dependencies are the package's dependencies, mapping NPM package names to version ranges.
+	Dependencies map[string]string `json:"dependencies,omitempty"`
+	// DevDependencies are the package's development dependencies, mapping NPM package names to version ranges.
+	DevDependencies map[string]string `json:"devDependencies,omitempty"`
+	// RespectSchemaVersion versions the package by the schema's version, rather than leaving it to be set when the
+	// package is published.
+	RespectSchemaVersion bool `json:"respectSchemaVersion,omitempty"`
+}
+
+// PythonLanguage is the schema's `language.python` section, which fills in the Python SDK's package metadata.
+type PythonLanguage struct {
+	// PackageName is the PyPI package name, such as `pulumi_mypkg`.
+	PackageName string `json:"packageName,omitempty"`
+	// Requires are the package's dependencies, mapping PyPI package names to version specifiers.
+	Requires map[string]string `json:"requires,omitempty"`
+	// PyProject configures the SDK's pyproject.toml.
+	PyProject *PythonPyProject `json:"pyproject,omitempty"`
+	// RespectSchemaVersion versions the package by the schema's version, rather than leaving it to be set when the
+	// package is published.
+	RespectSchemaVersion bool `json:"respectSchemaVersion,omitempty"`
+}
+
+// PythonPyProject configures the Python SDK's pyproject.toml.
+type PythonPyProject struct {
+	Enabled bool `json:"enabled,omitempty"` // generate a pyproject.toml alongside setup.py.
+}
+
+// CSharpLanguage is the schema's `language.csharp` section, which fills in the .NET SDK's project and namespaces.
+type CSharpLanguage struct {
+	// RootNamespace is the namespace the SDK's namespaces are nested in, which defaults to `Pulumi`.
+	RootNamespace string `json:"rootNamespace,omitempty"`
+	// PackageReferences are the project's NuGet package references, mapping package names to versions.
+	PackageReferences map[string]string `json:"packageReferences,omitempty"`
+	// Namespaces maps schema modules, and the package name itself, to the .NET namespaces they're generated into,
+	// nested in the root namespace. Modules not in the map are generated into namespaces named after them.
+	Namespaces map[string]string `json:"namespaces,omitempty"`
+	// RespectSchemaVersion versions the package by the schema's version, rather than leaving it to be set when the
+	// package is published.
+	RespectSchemaVersion bool `json:"respectSchemaVersion,omitempty"`
+}
+
+// JavaLanguage is the schema's `language.java` section, which fills in the Java SDK's packages and build.
+type JavaLanguage struct {
+	// BasePackage is the Java package the SDK's packages are nested in, which defaults to `com.pulumi`.
+	BasePackage string `json:"basePackage,omitempty"`
+	// BuildFiles is the build system to generate build files for, such as `gradle`. If it's empty, none are.
+	BuildFiles string `json:"buildFiles,omitempty"`
+	// Dependencies are the build's dependencies, mapping Maven `group:artifact` coordinates to versions.
+	Dependencies map[string]string `json:"dependencies,omitempty"`
+}
+
+// clone returns a deep copy of the sections.
+func (l Languages) clone() Languages {
+	if l.Go != nil {
+		g := *l.Go
+		g.InternalDependencies = append([]string(nil), g.InternalDependencies...)
+		l.Go = &g
+	}
+	if l.NodeJS != nil {
+		n := *l.NodeJS
+		n.Dependencies = cloneStringMap(n.Dependencies)
+		n.DevDependencies = cloneStringMap(n.DevDependencies)
+		l.NodeJS = &n
+	}
+	if l.Python != nil {
+		p := *l.Python
+		p.Requires = cloneStringMap(p.Requires)
+		if p.PyProject != nil {
+			pyproject := *p.PyProject
+			p.PyProject = &pyproject
+		}
+		l.Python = &p
+	}
+	if l.CSharp != nil {
+		c := *l.CSharp
+		c.PackageReferences = cloneStringMap(c.PackageReferences)
+		c.Namespaces = cloneStringMap(c.Namespaces)
+		l.CSharp = &c
+	}
+	if l.Java != nil {
+		j := *l.Java
+		j.Dependencies = cloneStringMap(j.Dependencies)
+		l.Java = &j
+	}
+	return l
+}
+
+// sections serializes the sections that are set into the schema's `language` map, or returns nil if none are.
+func (l Languages) sections() map[string]schema.RawMessage {
+	sections := make(map[string]schema.RawMessage)
+	add := func(lang string, section interface{}) {
+		b, err := json.Marshal(section)
+		if err != nil {
+			panic(err) // the sections are plain data, so they always serialize.
+		}
+		sections[lang] = b
+	}
+	if l.Go != nil {
+		add("go", l.Go)
+	}
+	if l.NodeJS != nil {
+		add("nodejs", l.NodeJS)
+	}
+	if l.Python != nil {
+		add("python", l.Python)
+	}
+	if l.CSharp != nil {
+		add("csharp", l.CSharp)
+	}
+	if l.Java != nil {
+		add("java", l.Java)
+	}
+	if len(sections) == 0 {
+		return nil
+	}
+	return sections
+}
@@ -0,0 +1,110 @@
+package mkschema
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxSummaryLength is the longest that a description's first line, which registries show as its summary in lists of
+// resources and properties, should be.
+const maxSummaryLength = 200
+
+var (
+	shortcodePattern  = regexp.MustCompile(`\{\{%\s*(/?)\s*(\w*)\s*%\}\}`)
+	unclosedLinkRegex = regexp.MustCompile(`\]\([^)]*$`)
+)
+
+// lintDescription warns of any problems with the markdown of the description of what, such as `type Bucket`, which
+// would otherwise only surface once a registry renders it. They're attributed to the given Go element, within the
+// given type and field.
+func (g *generator) lintDescription(elem goPos, typ, field, what, description string) {
+	if description == "" {
+		return
+	}
+	for _, problem := range markdownProblems(description) {
+		g.warn(typ, field, g.ruleErrorf(elem, ruleWellFormedDescriptions, "the description of %s %s", what, problem))
+	}
+	if summary := strings.SplitN(description, "\n", 2)[0]; len(summary) > maxSummaryLength {
+		err := g.ruleErrorf(elem, ruleSummaries, "the description of %s starts with a %d-character line, which "+
+			"registries show as its summary", what, len(summary))
+		g.warn(typ, field, suggest(err, "start with a sentence or two that summarize it, in a paragraph of their own"))
+	}
+}
+
+// markdownProblems returns the problems with a description's markdown: unclosed code blocks, code spans, and links,
+// and unbalanced or misplaced registry shortcodes, such as an `{{% example %}}` outside of `{{% examples %}}`.
+func markdownProblems(description string) []string {
+	var problems []string
+	var shortcodes []string
+	fence, fenceLine := "", 0
+	backquotes, backquoteLine := 0, 0
+	for i, line := range strings.Split(description, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+				fence = ""
+			}
+			continue
+		} else if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence, fenceLine = trimmed[:3], i+1
+			continue
+		}
+
+		// Code spans and links can't span paragraphs.
+		if trimmed == "" {
+			if backquotes%2 != 0 {
+				problems = append(problems, lineProblem(backquoteLine, "has an unclosed code span"))
+			}
+			backquotes = 0
+			continue
+		}
+		if n := strings.Count(line, "`"); n > 0 {
+			if backquotes%2 == 0 {
+				backquoteLine = i + 1
+			}
+			backquotes += n
+		}
+		if unclosedLinkRegex.MatchString(line) {
+			problems = append(problems, lineProblem(i+1, "has an unclosed link"))
+		}
+
+		if strings.Count(line, "{{%") != len(shortcodePattern.FindAllString(line, -1)) {
+			problems = append(problems, lineProblem(i+1, "has a malformed shortcode"))
+		}
+		for _, m := range shortcodePattern.FindAllStringSubmatch(line, -1) {
+			closing, name := m[1] == "/", m[2]
+			switch {
+			case name != "examples" && name != "example":
+				problems = append(problems, lineProblem(i+1, "has an unknown shortcode `"+m[0]+"`"))
+			case !closing && name == "example" && (len(shortcodes) == 0 || shortcodes[len(shortcodes)-1] != "examples"):
+				problems = append(problems, lineProblem(i+1, "has an `{{% example %}}` outside of `{{% examples %}}`"))
+				shortcodes = append(shortcodes, name)
+			case !closing && name == "examples" && len(shortcodes) > 0:
+				problems = append(problems, lineProblem(i+1, "has a nested `{{% examples %}}`"))
+				shortcodes = append(shortcodes, name)
+			case !closing:
+				shortcodes = append(shortcodes, name)
+			case len(shortcodes) == 0 || shortcodes[len(shortcodes)-1] != name:
+				problems = append(problems, lineProblem(i+1, "has an unmatched `"+m[0]+"`"))
+			default:
+				shortcodes = shortcodes[:len(shortcodes)-1]
+			}
+		}
+	}
+	if backquotes%2 != 0 {
+		problems = append(problems, lineProblem(backquoteLine, "has an unclosed code span"))
+	}
+	if fence != "" {
+		problems = append(problems, lineProblem(fenceLine, "has an unclosed code block"))
+	}
+	for _, name := range shortcodes {
+		problems = append(problems, "has an unclosed `{{% "+name+" %}}`")
+	}
+	return problems
+}
+
+// lineProblem describes a problem found on a line of a description.
+func lineProblem(line int, problem string) string {
+	return problem + " on line " + strconv.Itoa(line)
+}
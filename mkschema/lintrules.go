@@ -0,0 +1,112 @@
+package mkschema
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// LintRule is a custom schema policy, such as a naming prefix, a forbidden property name, or a mandatory keyword,
+// checked against the intermediate model once a package has been gathered, so that platform teams can enforce their
+// own conventions during generation. Its findings are diagnostics like any other, carrying its code, by which they
+// can be suppressed with `-suppress` or a `//pschema:nolint` directive.
+type LintRule struct {
+	Code     string   // the rule's stable code, such as ORG001; the MKS prefix is reserved for the built-in rules.
+	Text     string   // what the rule requires, as shown in diagnostics, such as "resource names must be nouns".
+	Severity Severity // the severity of the rule's findings; errors fail generation.
+	// Check returns the model's violations of the rule.
+	Check func(m *Model) []LintFinding
+}
+
+// LintFinding is a violation of a lint rule by the package, or by one of its resources, types, enums, or properties.
+type LintFinding struct {
+	Type       *Type     // the resource or type that violates the rule, if one does.
+	Enum       *Enum     // the enum that violates the rule, if one does.
+	Property   *Property // the property of the type that violates the rule, if the violation is specific to one.
+	Message    string    // a description of the violation, such as "resource Bucket lacks the Org prefix".
+	Suggestion string    // how the violation might be fixed, if known.
+}
+
+var (
+	registeredLintRulesLock sync.Mutex
+	registeredLintRules     []LintRule
+)
+
+// RegisterLintRule registers a lint rule that's checked by every generation in this process, before any rules
+// supplied in Options. Like RegisterHook, this is meant to be called from init functions, so that a platform team's
+// rules can be linked into a custom build of the tool with a blank import.
+func RegisterLintRule(rule LintRule) {
+	registeredLintRulesLock.Lock()
+	defer registeredLintRulesLock.Unlock()
+	registeredLintRules = append(registeredLintRules, rule)
+}
+
+// lintRules returns the registered lint rules, followed by the given ones, checking that each has a unique code,
+// outside of the built-in rules' MKS prefix, and a check.
+func lintRules(rules []LintRule) ([]LintRule, error) {
+	registeredLintRulesLock.Lock()
+	all := append(append([]LintRule(nil), registeredLintRules...), rules...)
+	registeredLintRulesLock.Unlock()
+
+	codes := make(map[string]bool)
+	for _, r := range all {
+		switch {
+		case r.Code == "" || strings.HasPrefix(r.Code, "MKS"):
+			return nil, errors.Errorf("lint rule %q must have a code, which can't start with MKS", r.Text)
+		case codes[r.Code]:
+			return nil, errors.Errorf("lint rule code %s is used by more than one rule", r.Code)
+		case r.Check == nil:
+			return nil, errors.Errorf("lint rule %s has no check", r.Code)
+		}
+		codes[r.Code] = true
+	}
+	return all, nil
+}
+
+// checkLintRules checks the model against the lint rules, reporting each finding as a diagnostic of the rule's
+// severity, attributed to the property, type, or enum it's about. In best-effort mode, errors are recorded as
+// failures, as any other errors are.
+func (g *generator) checkLintRules(m *Model) {
+	for _, r := range g.LintRules {
+		for _, f := range r.Check(m) {
+			var typ, field string
+			var elem goPos
+			switch {
+			case f.Property != nil:
+				elem, field = f.Property.Field, f.Property.Field.Name()
+				if f.Type != nil {
+					typ = f.Type.Object.Name()
+				}
+			case f.Type != nil:
+				elem, typ = f.Type.Object, f.Type.Object.Name()
+			case f.Enum != nil:
+				elem, typ = f.Enum.Object, f.Enum.Object.Name()
+			}
+
+			var err error
+			if elem != nil && g.Fset.File(elem.Pos()) != nil {
+				err = g.ruleErrorf(elem, rule{code: r.Code, text: r.Text}, "%s", f.Message)
+			} else {
+				err = &posError{Msg: f.Message, Rule: r.Text, Code: r.Code}
+			}
+			if f.Suggestion != "" {
+				err = suggest(err, f.Suggestion)
+			}
+			switch r.Severity {
+			case SeverityError:
+				// Nothing is skipped for a custom rule, so a suppressed error needn't be recorded as a failure.
+				if !g.suppressed(newDiagnostic(SeverityError, typ, field, err)) {
+					g.report(typ, field, err)
+				}
+			case SeverityWarning:
+				g.warn(typ, field, err)
+			default:
+				if d := newDiagnostic(r.Severity, typ, field, err); g.Diagnostics != nil && !g.noWarnings &&
+					!g.suppressed(d) {
+					g.Diagnostics(d)
+				}
+			}
+		}
+	}
+}
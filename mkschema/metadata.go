@@ -0,0 +1,167 @@
+package mkschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// ReadMetadata reads package metadata from a YAML, or JSON, file, whose keys are `version`, `description`, `license`,
+// `repository`, `homepage`, `logoUrl`, `pluginDownloadURL`, `keywords`, a list, `moduleFormat`, `language`, a map
+// from each language to its schema section, `suppress`, a list of diagnostic codes, and `customResources`, a bool.
+// Unknown keys are rejected, so that typos don't go unnoticed.
+func ReadMetadata(path string) (Metadata, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Metadata{}, err
+	}
+
+	// Decode the YAML generically and re-encode it as JSON, so that language sections are read with the same field
+	// names as they're written into the schema with.
+	var doc interface{}
+	if err = yaml.Unmarshal(b, &doc); err != nil {
+		return Metadata{}, errors.Wrapf(err, "parsing metadata file %s", path)
+	}
+	if doc, err = interpolateEnv(jsonValue(doc)); err != nil {
+		return Metadata{}, errors.Wrapf(err, "parsing metadata file %s", path)
+	}
+	if b, err = json.Marshal(doc); err != nil {
+		return Metadata{}, errors.Wrapf(err, "parsing metadata file %s", path)
+	}
+	var meta Metadata
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err = dec.Decode(&meta); err != nil {
+		return Metadata{}, errors.Wrapf(err, "parsing metadata file %s", path)
+	}
+	return meta, nil
+}
+
+// jsonValue converts a value decoded from YAML, whose maps may have keys of any type, into one that can be encoded
+// as JSON.
+func jsonValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[fmt.Sprint(k)] = jsonValue(e)
+		}
+		return m
+	case []interface{}:
+		for i, e := range v {
+			v[i] = jsonValue(e)
+		}
+	}
+	return v
+}
+
+// interpolateEnv replaces references to environment variables in the strings within a value decoded from JSON.
+func interpolateEnv(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case string:
+		return expandEnv(v)
+	case map[string]interface{}:
+		for k, e := range v {
+			x, err := interpolateEnv(e)
+			if err != nil {
+				return nil, errors.Wrapf(err, "%s", k)
+			}
+			v[k] = x
+		}
+	case []interface{}:
+		for i, e := range v {
+			x, err := interpolateEnv(e)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = x
+		}
+	}
+	return v, nil
+}
+
+// expandEnv replaces each `${NAME}` or `${NAME:-DEFAULT}` in s with the value of the environment variable, and each
+// `$$` with `$`. Any other `$` is left alone, so that regexes, such as module formats, needn't be escaped.
+func expandEnv(s string) (string, error) {
+	if !strings.Contains(s, "$") {
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case strings.HasPrefix(s[i:], "$$"):
+			b.WriteByte('$')
+			i++
+		case strings.HasPrefix(s[i:], "${"):
+			end := strings.IndexByte(s[i:], '}')
+			if end == -1 {
+				return "", errors.Errorf("unterminated variable reference in %q", s)
+			}
+			ref := s[i+2 : i+end]
+			name, def, hasDefault := ref, "", false
+			if sep := strings.Index(ref, ":-"); sep != -1 {
+				name, def, hasDefault = ref[:sep], ref[sep+2:], true
+			}
+			value, ok := os.LookupEnv(name)
+			switch {
+			case ok && value != "":
+			case hasDefault:
+				value = def
+			case !ok:
+				return "", errors.Errorf("environment variable %s is not set", name)
+			}
+			b.WriteString(value)
+			i += end
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String(), nil
+}
+
+// projectFileNames are the names of the Pulumi project files ReadProjectMetadata looks for in a directory, in order.
+var projectFileNames = []string{"Pulumi.yaml", "Pulumi.yml", "PulumiPlugin.yaml", "PulumiPlugin.yml"}
+
+// projectFile is the part of a Pulumi project file that corresponds to package metadata.
+type projectFile struct {
+	Description string `yaml:"description"`
+	Website     string `yaml:"website"`
+	License     string `yaml:"license"`
+}
+
+// ReadProjectMetadata seeds package metadata from a Pulumi project file, Pulumi.yaml or PulumiPlugin.yaml, at path,
+// or in the directory at path, so that a component project doesn't declare the same things twice. The project's
+// `description` and `license` carry over as they are, and its `website` becomes the package's homepage.
+func ReadProjectMetadata(path string) (Metadata, error) {
+	if info, err := os.Stat(path); err != nil {
+		return Metadata{}, err
+	} else if info.IsDir() {
+		dir := path
+		for _, name := range projectFileNames {
+			if _, err = os.Stat(filepath.Join(dir, name)); err == nil {
+				path = filepath.Join(dir, name)
+				break
+			}
+		}
+		if path == dir {
+			return Metadata{}, errors.Errorf("no Pulumi.yaml or PulumiPlugin.yaml in %s", dir)
+		}
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Metadata{}, err
+	}
+	var f projectFile
+	if err = yaml.Unmarshal(b, &f); err != nil {
+		return Metadata{}, errors.Wrapf(err, "parsing project file %s", path)
+	}
+	return Metadata{Description: f.Description, Homepage: f.Website, License: f.License}, nil
+}
@@ -0,0 +1,76 @@
+package mkschema
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+)
+
+// keptEmpty are the keys whose values are kept even when they're empty objects or arrays, because they mean
+// something: a type spec without a type accepts anything, and defaults, constants, and enum values are values.
+var keptEmpty = map[string]bool{"additionalProperties": true, "items": true, "default": true, "const": true,
+	"value": true}
+
+// propertyMaps are the keys of the maps of properties, whose members are all kept, since they're type specs.
+var propertyMaps = map[string]bool{"properties": true, "inputProperties": true, "variables": true}
+
+// MarshalMinimal serializes a schema to the smallest JSON that means the same thing, for embedding in provider
+// binaries: empty objects and arrays, such as an empty `config` or language section, are left out, as is the
+// `type` of resources and the provider, which is always `object`. Object keys are sorted.
+func MarshalMinimal(spec *schema.PackageSpec) ([]byte, error) {
+	b, err := json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	// Numbers are kept as they were written, so that large integers don't lose precision on the way through.
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var doc map[string]interface{}
+	if err = dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	if resources, ok := doc["resources"].(map[string]interface{}); ok {
+		for _, r := range resources {
+			dropObjectType(r)
+		}
+	}
+	dropObjectType(doc["provider"])
+	minimize(doc, false)
+
+	var out bytes.Buffer
+	enc := json.NewEncoder(&out)
+	enc.SetEscapeHTML(false)
+	if err = enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(out.Bytes(), []byte("\n")), nil
+}
+
+// dropObjectType removes the `type` of a resource, which is always `object`.
+func dropObjectType(v interface{}) {
+	if m, ok := v.(map[string]interface{}); ok && m["type"] == "object" {
+		delete(m, "type")
+	}
+}
+
+// minimize removes the empty objects and arrays within a JSON value, other than the members of an object whose
+// members are all to be kept, returning true if it's empty itself afterwards.
+func minimize(v interface{}, keepMembers bool) bool {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, e := range v {
+			if minimize(e, propertyMaps[k]) && !keepMembers && !keptEmpty[k] {
+				delete(v, k)
+			}
+		}
+		return len(v) == 0
+	case []interface{}:
+		for _, e := range v {
+			minimize(e, false)
+		}
+		return len(v) == 0
+	}
+	return false
+}
@@ -0,0 +1,264 @@
+package mkschema
+
+import (
+	"context"
+	"go/token"
+	"go/types"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+)
+
+// Model is the intermediate representation gathered from an annotated Go package, before it is turned into a
+// Pulumi package schema. It retains the Go origins and options behind every schema element, so that downstream
+// tools can build linters, doc generators, or custom emitters on top of the same analysis.
+type Model struct {
+	Name      string    // the Pulumi package name.
+	Metadata  Metadata  // the package-level metadata.
+	Package   string    // the Go package path the model was gathered from.
+	Resources []*Type   // the gathered resources, sorted by token.
+	Types     []*Type   // the gathered complex types, sorted by token.
+	Enums     []*Enum   // the gathered enums, sorted by token.
+	Failures  []Failure // the types and fields that were skipped in best-effort mode.
+	Pruned    []string  // the tokens of the types and enums left out as unreachable from any resource, sorted.
+}
+
+// Type is a resource or complex type gathered from a Go struct.
+type Type struct {
+	Token              string          // the schema token for this type.
+	Object             *types.TypeName // the Go type declaration this was gathered from.
+	Pos                token.Position  // the position of the Go type declaration.
+	Description        string          // the description, taken from the Go doc comment.
+	IsResource         bool            // true if this is a resource, rather than a complex type.
+	IsComponent        bool            // true if this resource is a component, rather than a custom resource.
+	Properties         []*Property     // the properties, in Go field order.
+	DeprecationMessage string          // why the resource is deprecated, from its doc comment.
+	Aliases            []string        // the tokens this resource is renamed from, which it's aliased to, sorted.
+
+	rule string // the rule that made the Go type this schema element, for Model.Provenance.
+}
+
+// Property is a property gathered from a tagged Go struct field.
+type Property struct {
+	Name    string              // the schema property name.
+	Field   *types.Var          // the Go field this property was gathered from.
+	Pos     token.Position      // the position of the Go field declaration.
+	Options PropertyOptions     // the options parsed from the field's tags.
+	Spec    schema.PropertySpec // the generated property schema.
+
+	owner *types.TypeName // the struct the field is declared in, which is internal if it was inlined.
+	steps []string        // the steps taken resolving the field's type, if recording provenance.
+}
+
+// Gather loads the target package and gathers its intermediate model, without producing a schema. In best-effort
+// mode, the model is returned alongside a *PartialError if anything was skipped. If the context is cancelled, the
+// error is a *CanceledError.
+func Gather(ctx context.Context, opts Options) (*Model, error) {
+	g, err := loadGenerator(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err = g.gather(ctx); err != nil {
+		return nil, err
+	}
+
+	m := g.Model()
+	if len(m.Failures) > 0 {
+		return m, &PartialError{Failures: m.Failures}
+	}
+	return m, nil
+}
+
+// Schema transforms the model into a Pulumi package specification.
+func (m *Model) Schema() *schema.PackageSpec {
+	spec := schema.PackageSpec{
+		Name:              m.Name,
+		Version:           m.Metadata.Version,
+		Description:       m.Metadata.Description,
+		License:           m.Metadata.License,
+		Repository:        m.Metadata.Repository,
+		Homepage:          m.Metadata.Homepage,
+		LogoURL:           m.Metadata.LogoURL,
+		PluginDownloadURL: m.Metadata.PluginDownloadURL,
+		Keywords:          append([]string(nil), m.Metadata.Keywords...),
+		Language:          m.Metadata.Language.sections(),
+	}
+	if format := m.moduleFormat(); format != "" {
+		spec.Meta = &schema.MetadataSpec{ModuleFormat: format}
+	}
+
+	for _, r := range m.Resources {
+		if spec.Resources == nil {
+			spec.Resources = make(map[string]schema.ResourceSpec)
+		}
+		res := schema.ResourceSpec{
+			ObjectTypeSpec:     r.ObjectTypeSpec(),
+			IsComponent:        r.IsComponent,
+			DeprecationMessage: r.DeprecationMessage,
+		}
+		for _, alias := range r.Aliases {
+			alias := alias
+			res.Aliases = append(res.Aliases, schema.AliasSpec{Type: &alias})
+		}
+		spec.Resources[r.Token] = res
+	}
+	for _, t := range m.Types {
+		if spec.Types == nil {
+			spec.Types = make(map[string]schema.ComplexTypeSpec)
+		}
+		spec.Types[t.Token] = schema.ComplexTypeSpec{
+			ObjectTypeSpec: t.ObjectTypeSpec(),
+		}
+	}
+	for _, e := range m.Enums {
+		if spec.Types == nil {
+			spec.Types = make(map[string]schema.ComplexTypeSpec)
+		}
+		spec.Types[e.Token] = e.ComplexTypeSpec()
+	}
+
+	return &spec
+}
+
+// Reconcile returns a copy of the model with only the resources, types, enums, properties, and enum values that spec,
+// the final schema after any hooks, still has, and with the properties' schemas taken from it, so that whatever is
+// derived from the model, such as its token constants, agrees with the schema that's written.
+func (m *Model) Reconcile(spec *schema.PackageSpec) *Model {
+	r := *m
+	r.Resources, r.Types, r.Enums = nil, nil, nil
+	for _, t := range m.Resources {
+		if res, has := spec.Resources[t.Token]; has {
+			r.Resources = append(r.Resources, t.reconcile(res.ObjectTypeSpec))
+		}
+	}
+	for _, t := range m.Types {
+		if typ, has := spec.Types[t.Token]; has {
+			r.Types = append(r.Types, t.reconcile(typ.ObjectTypeSpec))
+		}
+	}
+	for _, e := range m.Enums {
+		typ, has := spec.Types[e.Token]
+		if !has {
+			continue
+		}
+		names := make(map[string]bool, len(typ.Enum))
+		for _, v := range typ.Enum {
+			names[v.Name] = true
+		}
+		enum := *e
+		enum.Values = nil
+		for _, v := range e.Values {
+			if names[v.Name] {
+				enum.Values = append(enum.Values, v)
+			}
+		}
+		r.Enums = append(r.Enums, &enum)
+	}
+	return &r
+}
+
+// reconcile returns a copy of the type with only the properties that spec still has, with their schemas from it.
+func (t *Type) reconcile(spec schema.ObjectTypeSpec) *Type {
+	r := *t
+	r.Properties = nil
+	for _, p := range t.Properties {
+		if prop, has := spec.Properties[p.Name]; has {
+			p := *p
+			p.Spec = prop
+			r.Properties = append(r.Properties, &p)
+		}
+	}
+	return &r
+}
+
+// Modules returns the schema modules that the model's resources, types, and enums belong to, sorted.
+func (m *Model) Modules() []string {
+	seen := make(map[string]bool)
+	var modules []string
+	add := func(tok string) {
+		if parts := strings.Split(tok, ":"); len(parts) == 3 && !seen[parts[1]] {
+			seen[parts[1]] = true
+			modules = append(modules, parts[1])
+		}
+	}
+	for _, r := range m.Resources {
+		add(r.Token)
+	}
+	for _, t := range m.Types {
+		add(t.Token)
+	}
+	for _, e := range m.Enums {
+		add(e.Token)
+	}
+	sort.Strings(modules)
+	return modules
+}
+
+// moduleFormat returns the schema's module format. Unless the metadata sets one, modules with multiple segments, as
+// in `storage/v1`, get one that takes the whole module part of a token as the module, so that codegen doesn't need to
+// guess at how they nest; otherwise, there's no need for one.
+func (m *Model) moduleFormat() string {
+	if m.Metadata.ModuleFormat != "" {
+		return m.Metadata.ModuleFormat
+	}
+	for _, mod := range m.Modules() {
+		if strings.Contains(mod, "/") {
+			return "(.*)"
+		}
+	}
+	return ""
+}
+
+// checkModuleFormat checks that the metadata's module format, if any, is a valid regex that extracts each module in
+// use from the tokens it appears in, so that codegen places everything where it belongs.
+func (m *Model) checkModuleFormat() error {
+	if m.Metadata.ModuleFormat == "" {
+		return nil
+	}
+	re, err := regexp.Compile(m.Metadata.ModuleFormat)
+	if err != nil {
+		return errors.Wrapf(err, "invalid module format")
+	}
+	if re.NumSubexp() < 1 {
+		return errors.Errorf("module format %q has no capturing group for the module name", m.Metadata.ModuleFormat)
+	}
+	for _, mod := range m.Modules() {
+		if match := re.FindStringSubmatch(mod); match == nil || match[1] != mod {
+			return errors.Errorf("module format %q doesn't extract module %q from its tokens", m.Metadata.ModuleFormat,
+				mod)
+		}
+	}
+	return nil
+}
+
+// ObjectTypeSpec returns the object type schema for this type's properties.
+func (t *Type) ObjectTypeSpec() schema.ObjectTypeSpec {
+	spec := schema.ObjectTypeSpec{
+		Type:        "object",
+		Description: t.Description,
+		Properties:  make(map[string]schema.PropertySpec),
+	}
+	for _, p := range t.Properties {
+		spec.Properties[p.Name] = p.Spec
+	}
+	// TODO: required, based on tags
+	return spec
+}
+
+// Property looks up a property by its schema name, returning nil if there is no such property.
+func (t *Type) Property(name string) *Property {
+	for _, p := range t.Properties {
+		if p.Name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// sortTypes sorts types by their tokens, for deterministic output.
+func sortTypes(ts []*Type) {
+	sort.Slice(ts, func(i, j int) bool { return ts[i].Token < ts[j].Token })
+}
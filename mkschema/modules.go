@@ -0,0 +1,137 @@
+package mkschema
+
+import (
+	"go/ast"
+	"go/types"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ModulePolicy picks the schema modules of the Go packages that the options' Modules don't map.
+type ModulePolicy string
+
+const (
+	// ModulesIndex puts every package's types into the `index` module. It's the default.
+	ModulesIndex ModulePolicy = "index"
+	// ModulesVersioned puts the types of versioned packages, whose last path element is a version such as `v2` or
+	// `v1beta1`, into a module named after their parent package and version, such as `storage/v2` for
+	// `github.com/org/mypkg/storage/v2`, so that the same types in different versions don't collide. Other packages'
+	// types go into the `index` module. Only packages within the target package's Go module are versioned, so that a
+	// module's major version suffix, as in `github.com/org/mypkg/v2`, isn't taken for one.
+	ModulesVersioned ModulePolicy = "versioned"
+	// ModulesPath puts the types of the packages within the target package's Go module into modules named after
+	// their paths, relative to the target package if they're nested within it, or else to the module's root, such as
+	// `compute/instance` for `github.com/org/mypkg/compute/instance`, so that the schema's modules nest as the Go
+	// packages do. The target package's own types, and those of packages outside of its module, go into the `index`
+	// module.
+	ModulesPath ModulePolicy = "path"
+)
+
+// moduleSegment matches a segment of a module with more than one, which codegen turns into a directory or namespace.
+var moduleSegment = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// checkModule returns an error if a module can't be the module part of a token: it's empty, contains a colon, or has
+// more than one segment, any of which isn't an identifier, so that codegen can't nest it.
+func checkModule(mod string) error {
+	if mod == "" || strings.Contains(mod, ":") {
+		return errors.Errorf("module %q must be non-empty, and can't contain a colon", mod)
+	}
+	if segments := strings.Split(mod, "/"); len(segments) > 1 {
+		for _, s := range segments {
+			if !moduleSegment.MatchString(s) {
+				return errors.Errorf("module %q has segment %q, which isn't an identifier", mod, s)
+			}
+		}
+	}
+	return nil
+}
+
+// pathModule returns the module that a package belongs to under the path policy, or "" if it's the target package,
+// or isn't within the target package's Go module. Elements of the path that aren't identifiers, such as `go-client`,
+// have their other characters replaced with underscores, so that codegen can nest them.
+func (g *generator) pathModule(pkg string) string {
+	var rel string
+	switch {
+	case pkg == g.Pkg.Path():
+		return ""
+	case strings.HasPrefix(pkg, g.Pkg.Path()+"/"):
+		rel = pkg[len(g.Pkg.Path())+1:]
+	case g.modulePath != "" && strings.HasPrefix(pkg, g.modulePath+"/"):
+		rel = pkg[len(g.modulePath)+1:]
+	default:
+		return ""
+	}
+	segments := strings.Split(rel, "/")
+	for i, s := range segments {
+		segments[i] = strings.Map(func(r rune) rune {
+			if r == '_' || 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9' {
+				return r
+			}
+			return '_'
+		}, s)
+		if segments[i][0] >= '0' && segments[i][0] <= '9' {
+			segments[i] = "_" + segments[i]
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// moduleDirective is the type directive that puts a type in the target package into the given module, whatever the
+// module of its package, as in `//pschema:module compute/instance`.
+const moduleDirective = "module"
+
+// checkModuleDirective checks the module that a type's `//pschema:module` directive gives it, if it has one.
+func (g *generator) checkModuleDirective(node *ast.TypeSpec) error {
+	mod, has := typeDirective(node, moduleDirective)
+	if !has {
+		return nil
+	}
+	if err := checkModule(mod); err != nil {
+		return g.ruleErrorf(node.Name, ruleTypeDirectives, "%v is marked %s%s, but its %v", node.Name.Name,
+			typeDirectivePrefix, moduleDirective, err)
+	}
+	return nil
+}
+
+// directiveModule returns the module that the `//pschema:module` directive of a type in the target package gives it,
+// or "" if it has none, or a malformed one, which checkModuleDirective reports.
+func (g *generator) directiveModule(name string) string {
+	t, ok := g.Pkg.Scope().Lookup(name).(*types.TypeName)
+	if !ok {
+		return ""
+	}
+	node, err := g.getTypeNode(t)
+	if err != nil {
+		return ""
+	}
+	if mod, has := typeDirective(node, moduleDirective); has && checkModule(mod) == nil {
+		return mod
+	}
+	return ""
+}
+
+// apiVersion matches a package path element that's an API version.
+var apiVersion = regexp.MustCompile(`^v[0-9]+((alpha|beta)[0-9]*)?$`)
+
+// versionedModule returns the module that a package belongs to under the versioned policy, or "" if it isn't a
+// versioned package within the target package's Go module. A parent package that's mapped to a module other than
+// `index` lends it its module, as in `storage/v2` for a parent mapped to `storage`.
+func (g *generator) versionedModule(pkg string) string {
+	if g.modulePath == "" || !strings.HasPrefix(pkg, g.modulePath+"/") {
+		return ""
+	}
+	version := path.Base(pkg)
+	if !apiVersion.MatchString(version) {
+		return ""
+	}
+	parent := path.Dir(pkg)
+	if mod, has := g.Modules[parent]; has && mod != "index" {
+		return mod + "/" + version
+	} else if parent == g.modulePath {
+		return version
+	}
+	return path.Base(parent) + "/" + version
+}
@@ -0,0 +1,128 @@
+package mkschema
+
+import (
+	"go/ast"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// DescriptionOptions normalize the descriptions taken from doc comments and docs files, so that the schema's docs are
+// clean however the comments were formatted. They're all off by default, leaving descriptions as written.
+type DescriptionOptions struct {
+	// Dedent removes the indentation that all of the code in a fenced code block shares, such as from a block indented
+	// to line up with the prose around it.
+	Dedent bool
+	// Wrap, if positive, wraps paragraphs and list items at that many columns, rather than joining each onto one line.
+	// Code blocks, headings, and tables are never wrapped.
+	Wrap int
+	// TrimTrailingSpace removes whitespace from the ends of lines, including within code blocks.
+	TrimTrailingSpace bool
+	// DropLines are regexps matching doc comment lines to leave out of descriptions, such as the directives of linters
+	// and other tools, as in `^nolint\b`. They're matched against each line's text, less its `//`, and its first space.
+	DropLines []string
+}
+
+// compileDropLines compiles the patterns of the doc comment lines to leave out of descriptions.
+func compileDropLines(patterns []string) ([]*regexp.Regexp, error) {
+	var res []*regexp.Regexp
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid pattern of description lines to drop")
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+// docText returns the text of a doc comment, less any markers, and any lines that are to be dropped from descriptions.
+func (g *generator) docText(doc *ast.CommentGroup) string {
+	var kept []string
+	for _, line := range strings.Split(doc.Text(), "\n") {
+		drop := strings.HasPrefix(line, markerPrefix)
+		for _, re := range g.dropLines {
+			if re.MatchString(line) {
+				drop = true
+				break
+			}
+		}
+		if !drop {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+// commentDoc turns a doc comment into a normalized description, and the message of any `Deprecated:` paragraph in it;
+// see the commentDoc function.
+func (g *generator) commentDoc(doc *ast.CommentGroup) (description, deprecated string) {
+	description, deprecated = commentDoc(g.docText(doc))
+	return g.normalizeDescription(description), deprecated
+}
+
+// cleanComment turns a doc comment into a normalized description; see the cleanComment function.
+func (g *generator) cleanComment(doc *ast.CommentGroup) string {
+	return g.normalizeDescription(cleanComment(g.docText(doc)))
+}
+
+// normalizeDescription normalizes a markdown description as the description options ask.
+func (g *generator) normalizeDescription(description string) string {
+	opts := g.Descriptions
+	if !opts.Dedent && opts.Wrap <= 0 && !opts.TrimTrailingSpace {
+		return description
+	}
+
+	var out, block []string
+	fence := ""
+	for _, line := range strings.Split(description, "\n") {
+		if opts.TrimTrailingSpace {
+			line = strings.TrimRight(line, " \t")
+		}
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case fence != "":
+			block = append(block, line)
+			if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+				if opts.Dedent {
+					block = append(append(block[:1:1], dedent(block[1:len(block)-1])...), block[len(block)-1])
+				}
+				out, fence, block = append(out, block...), "", nil
+			}
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			fence, block = trimmed[:3], []string{line}
+		case opts.Wrap > 0:
+			out = append(out, wrapLine(line, opts.Wrap)...)
+		default:
+			out = append(out, line)
+		}
+	}
+	return strings.Join(append(out, block...), "\n")
+}
+
+// wrapLine wraps a line of markdown prose at the given width, indenting the lines of a list item to line up with its
+// text. Headings, tables, and indented lines, which may be code, are left alone.
+func wrapLine(line string, width int) []string {
+	if len(line) <= width || line == "" || line[0] == '#' || line[0] == '|' || line[0] == ' ' || line[0] == '\t' {
+		return []string{line}
+	}
+	indent := ""
+	if m := docListMarker.FindString(line); m != "" {
+		indent = strings.Repeat(" ", len(m))
+	}
+
+	var lines []string
+	current := ""
+	for _, word := range strings.Fields(line) {
+		switch {
+		case current == "":
+			current = word
+		case len(current)+1+len(word) > width:
+			lines, current = append(lines, current), indent+word
+		default:
+			current += " " + word
+		}
+	}
+	return append(lines, current)
+}
@@ -0,0 +1,21 @@
+package mkschema
+
+import (
+	"github.com/pulumi/pulumi-mkschema/mkschema/tags"
+)
+
+const (
+	// PropertyNameTag is the field tag used to drive the Pulumi schema name; see tags.NameTag.
+	PropertyNameTag = tags.NameTag
+	// PropertyOptionsTag is the field tag used to control various schema options; see tags.OptionsTag.
+	PropertyOptionsTag = tags.OptionsTag
+)
+
+// PropertyOptions represents a parsed field tag, controlling how properties are treated.
+type PropertyOptions = tags.PropertyOptions
+
+// ParsePropertyOptions parses a tag into a structured set of options. It is the same as tags.Parse, which runtime
+// frameworks should use instead, to avoid depending on the generator.
+func ParsePropertyOptions(tag string) (bool, PropertyOptions, error) {
+	return tags.Parse(tag)
+}
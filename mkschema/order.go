@@ -0,0 +1,146 @@
+package mkschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// SourceOrder is the order that a schema's elements were declared in Go, which the schema's JSON objects don't carry,
+// for writing a schema whose related resources, types, and properties are kept together, as they are in the source.
+type SourceOrder struct {
+	Tokens     []string            // the resources', types', and enums' tokens, in declaration order.
+	Properties map[string][]string // the names of each resource's and type's properties in field order, by token.
+}
+
+// SourceOrder returns the declaration order of the model's elements. Declarations are ordered by file name, and then
+// by their position within their file; properties are in the order of their fields, including any inlined ones.
+func (m *Model) SourceOrder() *SourceOrder {
+	type decl struct {
+		token string
+		file  string
+		pos   int
+	}
+	var decls []decl
+	o := &SourceOrder{Properties: make(map[string][]string)}
+	addType := func(t *Type) {
+		decls = append(decls, decl{t.Token, t.Pos.Filename, t.Pos.Offset})
+		names := make([]string, len(t.Properties))
+		for i, p := range t.Properties {
+			names[i] = p.Name
+		}
+		o.Properties[t.Token] = names
+	}
+	for _, r := range m.Resources {
+		addType(r)
+	}
+	for _, t := range m.Types {
+		addType(t)
+	}
+	for _, e := range m.Enums {
+		decls = append(decls, decl{e.Token, e.Pos.Filename, e.Pos.Offset})
+	}
+	sort.SliceStable(decls, func(i, j int) bool {
+		if decls[i].file != decls[j].file {
+			return decls[i].file < decls[j].file
+		}
+		return decls[i].pos < decls[j].pos
+	})
+	for _, d := range decls {
+		o.Tokens = append(o.Tokens, d.token)
+	}
+	return o
+}
+
+// Reorder rewrites a schema's JSON so that its resources and types, and their properties and input properties, are
+// in source order. Any that the order doesn't know of, such as those added by hooks, follow in the order they were
+// in. Everything else is left as it was written, so a minimized schema stays minimized.
+func (o *SourceOrder) Reorder(b []byte) ([]byte, error) {
+	tokens := make(map[string]int, len(o.Tokens))
+	for i, tok := range o.Tokens {
+		tokens[tok] = i
+	}
+	members := func(tok string, v json.RawMessage) (json.RawMessage, error) {
+		props := make(map[string]int, len(o.Properties[tok]))
+		for i, name := range o.Properties[tok] {
+			props[name] = i
+		}
+		return reorderObject(v, nil, func(key string, v json.RawMessage) (json.RawMessage, error) {
+			if key != "properties" && key != "inputProperties" {
+				return v, nil
+			}
+			return reorderObject(v, props, nil)
+		})
+	}
+	out, err := reorderObject(b, nil, func(key string, v json.RawMessage) (json.RawMessage, error) {
+		if key != "resources" && key != "types" {
+			return v, nil
+		}
+		return reorderObject(v, tokens, members)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "reordering schema")
+	}
+	return out, nil
+}
+
+// memberFunc rewrites the value of a JSON object's member.
+type memberFunc func(key string, v json.RawMessage) (json.RawMessage, error)
+
+// reorderObject rewrites a JSON object so that the members whose keys have ranks come first, in order of their ranks,
+// followed by the rest in the order they were in, and with each member's value rewritten by member, if it's non-nil.
+func reorderObject(b []byte, ranks map[string]int, member memberFunc) (json.RawMessage, error) {
+	type pair struct {
+		key   string
+		value json.RawMessage
+	}
+	var pairs []pair
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if tok, err := dec.Token(); err != nil {
+		return nil, err
+	} else if tok != json.Delim('{') {
+		return b, nil // not an object, so there's nothing to reorder.
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		p := pair{key: tok.(string)}
+		if err = dec.Decode(&p.value); err != nil {
+			return nil, err
+		}
+		if member != nil {
+			if p.value, err = member(p.key, p.value); err != nil {
+				return nil, err
+			}
+		}
+		pairs = append(pairs, p)
+	}
+	sort.SliceStable(pairs, func(i, j int) bool {
+		ri, iok := ranks[pairs[i].key]
+		rj, jok := ranks[pairs[j].key]
+		return iok && (!jok || ri < rj)
+	})
+
+	var out bytes.Buffer
+	enc := json.NewEncoder(&out)
+	enc.SetEscapeHTML(false)
+	out.WriteByte('{')
+	for i, p := range pairs {
+		if i > 0 {
+			out.WriteByte(',')
+		}
+		if err := enc.Encode(p.key); err != nil {
+			return nil, err
+		}
+		out.Truncate(out.Len() - 1) // the encoder's newline.
+		out.WriteByte(':')
+		out.Write(p.value)
+	}
+	out.WriteByte('}')
+	return out.Bytes(), nil
+}
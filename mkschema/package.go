@@ -0,0 +1,145 @@
+package mkschema
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+)
+
+// PackageOptions controls how a plugin package is assembled.
+type PackageOptions struct {
+	OutDir   string            // the directory to assemble the package in.
+	Readme   string            // the README's contents; if empty, one is written from the schema's description.
+	Binaries map[string]string // the paths of the provider binaries to package, keyed by platform, as in `linux-amd64`.
+}
+
+// Package assembles the layout that `pulumi plugin install` and `pulumi package publish` expect for a schema in
+// opts.OutDir: the schema as schema.json, a pulumi-plugin.json describing the plugin, and a README.md. For each
+// binary, it also writes a `pulumi-resource-NAME-vVERSION-OS-ARCH.tar.gz` tarball containing the binary, under the
+// name the engine looks for, along with the plugin description and the README. It returns the paths it wrote.
+func Package(spec *schema.PackageSpec, opts PackageOptions) ([]string, error) {
+	version := strings.TrimPrefix(spec.Version, "v")
+	if len(opts.Binaries) > 0 && version == "" {
+		return nil, errors.Errorf("packaging binaries requires the schema to have a version")
+	}
+
+	sch, err := json.MarshalIndent(spec, "", "    ")
+	if err != nil {
+		return nil, errors.Wrapf(err, "serializing schema to JSON")
+	}
+	pluginJSON, err := (&plugin.PulumiPluginJSON{
+		Resource: true,
+		Name:     spec.Name,
+		Version:  version,
+		Server:   spec.PluginDownloadURL,
+	}).JSON()
+	if err != nil {
+		return nil, errors.Wrapf(err, "serializing pulumi-plugin.json")
+	}
+	readme := opts.Readme
+	if readme == "" {
+		readme = fmt.Sprintf("# %s\n", spec.Name)
+		if spec.Description != "" {
+			readme += "\n" + spec.Description + "\n"
+		}
+	}
+	files := map[string][]byte{
+		"schema.json":        append(sch, '\n'),
+		"pulumi-plugin.json": append(pluginJSON, '\n'),
+		"README.md":          []byte(readme),
+	}
+
+	if err = os.MkdirAll(opts.OutDir, 0755); err != nil {
+		return nil, err
+	}
+	var written []string
+	for _, name := range []string{"schema.json", "pulumi-plugin.json", "README.md"} {
+		path := filepath.Join(opts.OutDir, name)
+		if err = ioutil.WriteFile(path, files[name], 0644); err != nil {
+			return nil, err
+		}
+		written = append(written, path)
+	}
+
+	platforms := make([]string, 0, len(opts.Binaries))
+	for platform := range opts.Binaries {
+		platforms = append(platforms, platform)
+	}
+	sort.Strings(platforms)
+	for _, platform := range platforms {
+		path := filepath.Join(opts.OutDir,
+			fmt.Sprintf("pulumi-resource-%s-v%s-%s.tar.gz", spec.Name, version, platform))
+		if err = writePluginTarball(path, spec.Name, platform, opts.Binaries[platform], files); err != nil {
+			return nil, errors.Wrapf(err, "packaging %s binary", platform)
+		}
+		written = append(written, path)
+	}
+	return written, nil
+}
+
+// writePluginTarball writes a plugin tarball for the given platform's binary, along with the plugin description and
+// README.
+func writePluginTarball(path, name, platform, binary string, files map[string][]byte) (err error) {
+	if strings.Count(platform, "-") != 1 {
+		return errors.Errorf("platform %q is not of the form OS-ARCH", platform)
+	}
+	bin, err := os.Open(binary)
+	if err != nil {
+		return err
+	}
+	defer bin.Close()
+	info, err := bin.Stat()
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+
+	binName := "pulumi-resource-" + name
+	if strings.HasPrefix(platform, "windows-") {
+		binName += ".exe"
+	}
+	hdr := &tar.Header{Name: binName, Mode: 0755, Size: info.Size(), ModTime: info.ModTime()}
+	if err = tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	if _, err = io.Copy(tw, bin); err != nil {
+		return err
+	}
+	for _, name := range []string{"pulumi-plugin.json", "README.md"} {
+		b := files[name]
+		hdr = &tar.Header{Name: name, Mode: 0644, Size: int64(len(b)), ModTime: info.ModTime()}
+		if err = tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err = tw.Write(b); err != nil {
+			return err
+		}
+	}
+
+	if err = tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
@@ -0,0 +1,459 @@
+package mkschema
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"go/types"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+)
+
+// patchManifest records, for a schema written by Patch, which source files each Go type was gathered from and
+// which schema tokens it produced, so that a later Patch can regenerate just the types whose files changed.
+type patchManifest struct {
+	Schema string                `json:"schema"` // a hash of the schema the manifest describes.
+	Files  map[string]string     `json:"files"`  // the hash of each of the package's files.
+	Types  map[string]*patchType `json:"types"`  // the package's gathered types, keyed by Go type name.
+}
+
+// patchType records what gathering one Go type depended on and produced.
+type patchType struct {
+	Files       []string        `json:"files"`                 // the files declaring the type and its constants.
+	Tokens      []string        `json:"tokens,omitempty"`      // the tokens of the resources and types it produced.
+	Diagnostics []Diagnostic    `json:"diagnostics,omitempty"` // the diagnostics reported while gathering it.
+	Failures    []cachedFailure `json:"failures,omitempty"`    // anything skipped while gathering it.
+	Renamed     []string        `json:"renamed,omitempty"`     // the renamed tokens that gathering it used.
+}
+
+// Patch regenerates an existing schema, prior, which was previously written by Patch with the same options, by
+// gathering only the Go types declared in files that have changed since, and splicing their resources and types
+// into it. This makes the edit-generate loop far quicker for huge packages, since most of the schema is reused.
+// Anything else in prior, such as hand-written metadata that neither the options nor the package's doc comment set,
+// is kept as is.
+//
+// Patch records which files each type came from in opts.CacheDir, which is required. If there's no record for prior,
+// because prior is nil, was edited, or was generated with other options, the schema is regenerated in full. Hooks are
+// run on the whole spliced schema, so they must be idempotent. The package is still loaded and type-checked unless
+// nothing has changed, since the types in changed files may refer to any others.
+func Patch(ctx context.Context, opts Options, prior *schema.PackageSpec) (*schema.PackageSpec, error) {
+	if opts.CacheDir == "" {
+		return nil, errors.New("patching a schema requires a cache directory")
+	}
+	if err := opts.checkCache(); err != nil {
+		return nil, err
+	} else if opts.PruneTypes {
+		return nil, errors.New("unreachable types can't be pruned while patching, which regenerates only some types")
+	} else if len(opts.Only) > 0 {
+		return nil, errors.New("resources can't be selected while patching, which regenerates only some types")
+	} else if opts.ResolveRefs {
+		return nil, errors.New("references can't be resolved while patching, which regenerates only some types")
+	}
+
+	// List the package's files, and find which have changed since the manifest for this configuration was written.
+	inputs, err := listCacheInputs(ctx, opts)
+	if err != nil {
+		return nil, errors.Wrapf(err, "computing cache key")
+	}
+	h := sha256.New()
+	h.Write(inputs.Config)
+	for _, file := range inputs.ModFiles {
+		if err = hashFile(h, file); err != nil {
+			return nil, errors.Wrapf(err, "computing cache key")
+		}
+	}
+	key := "patch-" + hex.EncodeToString(h.Sum(nil))
+	files := make(map[string]string, len(inputs.PkgFiles))
+	for _, file := range inputs.PkgFiles {
+		h := sha256.New()
+		if err = hashFile(h, file); err != nil {
+			return nil, errors.Wrapf(err, "computing cache key")
+		}
+		files[file] = hex.EncodeToString(h.Sum(nil))
+	}
+
+	var manifest *patchManifest
+	if prior != nil {
+		if manifest = readPatchManifest(opts.CacheDir, key); manifest != nil {
+			if hash, err := schemaHash(prior); err != nil || hash != manifest.Schema {
+				manifest = nil
+			}
+		}
+	}
+	changed := make(map[string]bool)
+	if manifest != nil {
+		for file, hash := range files {
+			if manifest.Files[file] != hash {
+				changed[file] = true
+			}
+		}
+		for file := range manifest.Files {
+			if _, has := files[file]; !has {
+				changed[file] = true
+			}
+		}
+
+		// If nothing has changed, the prior schema is already up to date.
+		if len(changed) == 0 {
+			return prior, manifest.replay(opts.Diagnostics, nil, nil)
+		}
+	}
+
+	// Otherwise, load the package, and regenerate each type that's new, or that depends on a changed file. A type
+	// also depends on any changed file declaring constants of it, in case it's an enum that has gained values, or
+	// declaring its defaults, in case they've moved there.
+	g, err := loadGenerator(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err = g.checkOptions(); err != nil {
+		return nil, err
+	}
+	if err = g.gatherPackageMetadata(); err != nil {
+		return nil, errors.Wrapf(err, "gathering Go package info")
+	}
+	regen := make(map[string]bool)
+	scope := g.Pkg.Scope()
+	for _, name := range scope.Names() {
+		switch o := scope.Lookup(name).(type) {
+		case *types.TypeName:
+			if manifest == nil || manifest.Types[name] == nil {
+				regen[name] = true
+			} else {
+				for _, file := range manifest.Types[name].Files {
+					regen[name] = regen[name] || changed[file]
+				}
+			}
+			if v := g.defaultsVar(o); v != nil && changed[g.Fset.Position(v.Pos()).Filename] {
+				regen[name] = true
+			}
+			if method := g.defaultsMethod(o); method != nil && changed[g.Fset.Position(method.Pos()).Filename] {
+				regen[name] = true
+			}
+		case *types.Const:
+			if named, ok := o.Type().(*types.Named); ok && named.Obj().Pkg() == g.Pkg &&
+				changed[g.Fset.Position(o.Pos()).Filename] {
+				regen[named.Obj().Name()] = true
+			}
+		}
+	}
+
+	next := &patchManifest{Files: files, Types: make(map[string]*patchType)}
+	sink := opts.Diagnostics
+	renamed := make(map[string]bool)
+	for _, name := range scope.Names() {
+		if err := ctx.Err(); err != nil {
+			return nil, &CanceledError{Err: err, Failures: g.Failures}
+		}
+		o, ok := scope.Lookup(name).(*types.TypeName)
+		if !ok {
+			continue
+		}
+		if !regen[name] {
+			next.Types[name] = manifest.Types[name]
+			for _, old := range next.Types[name].Renamed {
+				renamed[old] = true
+			}
+			if sink != nil {
+				for _, d := range next.Types[name].Diagnostics {
+					sink(d)
+				}
+			}
+			continue
+		}
+
+		// Gather the type, recording what it reports and produces.
+		entry := &patchType{Files: g.typeFiles(o)}
+		g.Diagnostics = func(d Diagnostic) {
+			entry.Diagnostics = append(entry.Diagnostics, d)
+			if sink != nil {
+				sink(d)
+			}
+		}
+		skipped := len(g.Failures)
+		g.renamed = nil
+		if err := g.GatherTypeSchemas(o); err != nil {
+			g.report(name, "", err)
+		}
+		for _, f := range g.Failures[skipped:] {
+			entry.Failures = append(entry.Failures, cachedFailure{Type: f.Type, Field: f.Field, Err: f.Err.Error()})
+		}
+		entry.Renamed = sortedKeys(g.renamed)
+		for _, old := range entry.Renamed {
+			renamed[old] = true
+		}
+		if r, has := g.Resources[name]; has {
+			entry.Tokens = append(entry.Tokens, r.Token)
+		}
+		if t, has := g.Types[name]; has {
+			entry.Tokens = append(entry.Tokens, t.Token)
+		}
+		if e, has := g.Enums[name]; has {
+			entry.Tokens = append(entry.Tokens, e.Token)
+		}
+		next.Types[name] = entry
+	}
+
+	// Check the renames against the tokens of every type, as a full run would, including those of the types that
+	// weren't regenerated, and the renames they used.
+	g.Diagnostics, g.renamed = sink, renamed
+	tokens := g.tokens()
+	for name, entry := range next.Types {
+		if !regen[name] {
+			for _, tok := range entry.Tokens {
+				tokens[tok] = append(tokens[tok], name)
+			}
+		}
+	}
+	if err = g.checkRenamesUsed(tokens); err != nil {
+		return nil, err
+	}
+
+	// Splice the regenerated types into the prior schema, in place of whatever they, and any types that no longer
+	// exist, produced before, along with any metadata from the options or the package's doc comment. Without a
+	// manifest, the freshly gathered schema is the whole thing. Only the regenerated types are linted.
+	g.checkLintRules(g.Model())
+	if err = g.checkErrors(); err != nil {
+		return nil, err
+	}
+	m := g.Model()
+	if err = m.checkModuleFormat(); err != nil {
+		return nil, err
+	}
+	spec := m.Schema()
+	if manifest != nil {
+		spliced := *prior
+		for _, f := range []struct {
+			field *string
+			fresh string
+		}{{&spliced.Version, spec.Version}, {&spliced.Description, spec.Description},
+			{&spliced.License, spec.License}, {&spliced.Repository, spec.Repository},
+			{&spliced.Homepage, spec.Homepage}, {&spliced.LogoURL, spec.LogoURL},
+			{&spliced.PluginDownloadURL, spec.PluginDownloadURL}} {
+			if f.fresh != "" {
+				*f.field = f.fresh
+			}
+		}
+		if len(spec.Keywords) > 0 {
+			spliced.Keywords = spec.Keywords
+		}
+		if spec.Meta != nil {
+			spliced.Meta = spec.Meta
+		}
+		if len(spec.Language) > 0 {
+			spliced.Language = make(map[string]schema.RawMessage, len(prior.Language)+len(spec.Language))
+			for lang, section := range prior.Language {
+				spliced.Language[lang] = section
+			}
+			for lang, section := range spec.Language {
+				spliced.Language[lang] = section
+			}
+		}
+		spliced.Resources = make(map[string]schema.ResourceSpec, len(prior.Resources))
+		for tok, r := range prior.Resources {
+			spliced.Resources[tok] = r
+		}
+		spliced.Types = make(map[string]schema.ComplexTypeSpec, len(prior.Types))
+		for tok, t := range prior.Types {
+			spliced.Types[tok] = t
+		}
+		for name, entry := range manifest.Types {
+			if regen[name] || next.Types[name] == nil {
+				for _, tok := range entry.Tokens {
+					delete(spliced.Resources, tok)
+					delete(spliced.Types, tok)
+				}
+			}
+		}
+		for tok, r := range spec.Resources {
+			spliced.Resources[tok] = r
+		}
+		for tok, t := range spec.Types {
+			spliced.Types[tok] = t
+		}
+		if len(spliced.Resources) == 0 {
+			spliced.Resources = nil
+		}
+		if len(spliced.Types) == 0 {
+			spliced.Types = nil
+		}
+		spec = &spliced
+	}
+
+	if err = runHooks(ctx, spec, opts.Hooks); err != nil {
+		return nil, err
+	}
+	if next.Schema, err = schemaHash(spec); err != nil {
+		return nil, err
+	}
+	if err = writeCache(opts.CacheDir, key, next); err != nil {
+		return nil, errors.Wrapf(err, "writing cache")
+	}
+	// Diagnostics have all been reported along the way, so only gather up what was skipped.
+	return spec, next.replay(nil, regen, g.Failures)
+}
+
+// typeFiles returns the files that gathering a type depends on: the one declaring it, any declaring constants of it,
+// if it's an enum, its docs and examples, if it's a resource, those declaring its defaults, those declaring the structs
+// it embeds, whose fields are inlined into it if they're internal, and those declaring the types its fields refer to,
+// whose directives, such as `//pschema:module` or `//pschema:resource`, decide what the references to them are.
+func (g *generator) typeFiles(t *types.TypeName) []string {
+	decl := g.Fset.Position(t.Pos()).Filename
+	seen := map[string]bool{decl: true}
+	var files []string
+	add := func(file string) {
+		if !seen[file] {
+			seen[file] = true
+			files = append(files, file)
+		}
+	}
+	for _, c := range g.enumConsts(t) {
+		add(g.Fset.Position(c.Pos()).Filename)
+	}
+	add(g.resourceDocsFile(t.Name()))
+	for _, e := range g.resourceExamples(t.Name()) {
+		add(e.File)
+	}
+	structs := append([]*types.TypeName{t}, g.embeddedStructs(t)...)
+	for _, s := range structs[1:] {
+		add(g.Fset.Position(s.Pos()).Filename)
+	}
+	for _, s := range structs {
+		// An embedded internal struct's defaults are those of its inlined properties.
+		if v := g.defaultsVar(s); v != nil {
+			add(g.Fset.Position(v.Pos()).Filename)
+		}
+		if method := g.defaultsMethod(s); method != nil {
+			add(g.Fset.Position(method.Pos()).Filename)
+		}
+		for _, ref := range g.fieldTypes(s) {
+			add(g.Fset.Position(ref.Pos()).Filename)
+		}
+	}
+	sort.Strings(files)
+	return append([]string{decl}, files...)
+}
+
+// embeddedStructs returns the types in the target package that a struct embeds, directly or by pointer, and those
+// that they embed in turn, whether or not they're internal, since marking one internal, or no longer, changes the
+// properties of every struct embedding it.
+func (g *generator) embeddedStructs(t *types.TypeName) []*types.TypeName {
+	var embedded []*types.TypeName
+	seen := map[*types.TypeName]bool{t: true}
+	var walk func(t *types.TypeName)
+	walk = func(t *types.TypeName) {
+		s, ok := t.Type().Underlying().(*types.Struct)
+		if !ok {
+			return
+		}
+		for i := 0; i < s.NumFields(); i++ {
+			if !s.Field(i).Anonymous() {
+				continue
+			}
+			ft := s.Field(i).Type()
+			if ptr, ok := ft.(*types.Pointer); ok {
+				ft = ptr.Elem()
+			}
+			if named, ok := ft.(*types.Named); ok && named.Obj().Pkg() == g.Pkg && !seen[named.Obj()] {
+				seen[named.Obj()] = true
+				embedded = append(embedded, named.Obj())
+				walk(named.Obj())
+			}
+		}
+	}
+	walk(t)
+	return embedded
+}
+
+// fieldTypes returns the named types in the target package that a struct's fields are made of, looking through
+// pointers, arrays, slices, and maps.
+func (g *generator) fieldTypes(t *types.TypeName) []*types.TypeName {
+	s, ok := t.Type().Underlying().(*types.Struct)
+	if !ok {
+		return nil
+	}
+	var refs []*types.TypeName
+	var walk func(t types.Type)
+	walk = func(t types.Type) {
+		switch t := t.(type) {
+		case *types.Named:
+			if t.Obj().Pkg() == g.Pkg {
+				refs = append(refs, t.Obj())
+			}
+		case *types.Pointer:
+			walk(t.Elem())
+		case *types.Array:
+			walk(t.Elem())
+		case *types.Slice:
+			walk(t.Elem())
+		case *types.Map:
+			walk(t.Key())
+			walk(t.Elem())
+		}
+	}
+	for i := 0; i < s.NumFields(); i++ {
+		if !s.Field(i).Anonymous() {
+			walk(s.Field(i).Type())
+		}
+	}
+	return refs
+}
+
+// replay sends the diagnostics of every type not in regenerated to the sink, as a fresh run would have, and returns
+// a *PartialError if anything was skipped, whether it was in the manifest or among the given fresh failures.
+func (m *patchManifest) replay(sink DiagnosticSink, regenerated map[string]bool, fresh []Failure) error {
+	names := make([]string, 0, len(m.Types))
+	for name := range m.Types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	partial := &PartialError{}
+	for _, name := range names {
+		if regenerated[name] {
+			continue
+		}
+		entry := m.Types[name]
+		if sink != nil {
+			for _, d := range entry.Diagnostics {
+				sink(d)
+			}
+		}
+		for _, f := range entry.Failures {
+			partial.Failures = append(partial.Failures, Failure{Type: f.Type, Field: f.Field, Err: errors.New(f.Err)})
+		}
+	}
+	partial.Failures = append(partial.Failures, fresh...)
+	if len(partial.Failures) == 0 {
+		return nil
+	}
+	return partial
+}
+
+// readPatchManifest returns the patch manifest with the given key, or nil if there isn't a usable one.
+func readPatchManifest(dir, key string) *patchManifest {
+	b, err := ioutil.ReadFile(filepath.Join(dir, key+".json"))
+	if err != nil {
+		return nil
+	}
+	var manifest patchManifest
+	if err = json.Unmarshal(b, &manifest); err != nil || manifest.Types == nil {
+		return nil // a corrupt manifest is simply a miss; it'll be overwritten.
+	}
+	return &manifest
+}
+
+// schemaHash hashes a schema's JSON, to tell whether a schema is the one a manifest describes.
+func schemaHash(spec *schema.PackageSpec) (string, error) {
+	b, err := json.Marshal(spec)
+	if err != nil {
+		return "", errors.Wrapf(err, "serializing schema to JSON")
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,143 @@
+package mkschema
+
+import (
+	"go/ast"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// packageDirective is the comment directive that sets package metadata in the Go package's doc comment, as in:
+//
+//	//pschema:package license=Apache-2.0 keywords=pulumi,storage description="Buckets, done right."
+const packageDirective = "//pschema:package"
+
+// gatherPackageMetadata fills in any package metadata the options left unset from the Go package's doc comment:
+// from `//pschema:package` directives, and otherwise, for the description, from the doc comment's text. The doc
+// comment is taken from doc.go, if it has one, or else the first file that does. The version falls back to
+// BuildVersion, after any directive.
+func (g *generator) gatherPackageMetadata() error {
+	if err := g.gatherPackageDirectives(); err != nil {
+		return err
+	}
+	if g.Metadata.Version == "" {
+		g.Metadata.Version = BuildVersion
+	}
+	return nil
+}
+
+// gatherPackageDirectives fills in any package metadata the options left unset from the Go package's doc comment.
+func (g *generator) gatherPackageDirectives() error {
+	var doc *ast.CommentGroup
+	for _, file := range g.Files {
+		if file.Doc == nil {
+			continue
+		}
+		if doc == nil || filepath.Base(g.Fset.Position(file.Pos()).Filename) == "doc.go" {
+			doc = file.Doc
+		}
+	}
+	if doc == nil {
+		return nil
+	}
+
+	var directives Metadata
+	for _, c := range doc.List {
+		if !strings.HasPrefix(c.Text, packageDirective) {
+			continue
+		}
+		rest := c.Text[len(packageDirective):]
+		if rest != "" && !unicode.IsSpace(rune(rest[0])) {
+			continue
+		}
+		if err := parsePackageDirective(rest, &directives); err != nil {
+			return g.ruleErrorf(c, rulePackageDirectives,
+				"malformed %s directive: %v", packageDirective, err)
+		}
+	}
+	if directives.Description == "" {
+		// Directives are left out of the doc comment's text.
+		directives.Description = g.cleanComment(doc)
+	}
+
+	for _, f := range []struct {
+		field     *string
+		directive string
+	}{{&g.Metadata.Version, directives.Version}, {&g.Metadata.Description, directives.Description},
+		{&g.Metadata.License, directives.License}, {&g.Metadata.Repository, directives.Repository},
+		{&g.Metadata.Homepage, directives.Homepage}} {
+		if *f.field == "" {
+			*f.field = f.directive
+		}
+	}
+	if len(g.Metadata.Keywords) == 0 {
+		g.Metadata.Keywords = directives.Keywords
+	}
+	return nil
+}
+
+// parsePackageDirective parses the `key=value` pairs of a package directive into meta. Values containing spaces are
+// double-quoted Go strings, and keywords are comma-separated.
+func parsePackageDirective(s string, meta *Metadata) error {
+	for {
+		s = strings.TrimLeftFunc(s, unicode.IsSpace)
+		if s == "" {
+			return nil
+		}
+		eq := strings.IndexByte(s, '=')
+		if eq <= 0 {
+			return errors.Errorf("expected key=value, got %q", s)
+		}
+		key := s[:eq]
+		s = s[eq+1:]
+
+		var value string
+		if strings.HasPrefix(s, `"`) {
+			end := 1
+			for end < len(s) && s[end] != '"' {
+				if s[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(s) {
+				return errors.Errorf("unterminated quoted value for %s", key)
+			}
+			var err error
+			if value, err = strconv.Unquote(s[:end+1]); err != nil {
+				return errors.Errorf("malformed quoted value for %s", key)
+			}
+			s = s[end+1:]
+		} else {
+			end := strings.IndexFunc(s, unicode.IsSpace)
+			if end == -1 {
+				end = len(s)
+			}
+			value, s = s[:end], s[end:]
+		}
+
+		switch key {
+		case "version":
+			meta.Version = value
+		case "description":
+			meta.Description = value
+		case "license":
+			meta.License = value
+		case "repository":
+			meta.Repository = value
+		case "homepage":
+			meta.Homepage = value
+		case "logoUrl":
+			meta.LogoURL = value
+		case "pluginDownloadURL":
+			meta.PluginDownloadURL = value
+		case "keywords":
+			meta.Keywords = strings.Split(value, ",")
+		default:
+			return errors.Errorf("unknown key %q", key)
+		}
+	}
+}
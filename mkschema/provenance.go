@@ -0,0 +1,76 @@
+package mkschema
+
+import (
+	"fmt"
+	"go/types"
+)
+
+// provenanceVersion is the version of the provenance report's format, which is bumped whenever it changes
+// incompatibly.
+const provenanceVersion = 1
+
+// Provenance records why each element of a schema is there: the Go declaration it was gathered from, and the rule
+// that made it a schema element, for auditing what a migration to generated schemas produced.
+type Provenance struct {
+	Version int `json:"version"`
+	// Elements maps the JSON pointer to each resource, type, property, and enum value in the schema, such as
+	// `#/resources/pkg:index:Bucket/properties/name`, to where it came from. Enum values are pointed to by index.
+	Elements map[string]ProvenanceEntry `json:"elements"`
+}
+
+// ProvenanceEntry is where an element of a schema came from.
+type ProvenanceEntry struct {
+	Go   string `json:"go"`   // the Go declaration, as `path/to/pkg.Type`, `path/to/pkg.Type.Field`, or a constant.
+	Rule string `json:"rule"` // the rule that made it a schema element, such as "struct with `pulumi` tagged fields".
+	// Steps are the steps taken resolving a property's schema type from its field's Go type, such as where a reference
+	// came from: the field's `ref=` option, a mapping, or the package itself. They're only recorded if the model was
+	// gathered with the Provenance option.
+	Steps []string `json:"steps,omitempty"`
+}
+
+// Provenance returns the provenance of each element of the model's schema.
+func (m *Model) Provenance() *Provenance {
+	p := &Provenance{Version: provenanceVersion, Elements: make(map[string]ProvenanceEntry)}
+	addType := func(section string, t *Type) {
+		ptr := "#/" + section + "/" + escapePointer(t.Token)
+		p.Elements[ptr] = ProvenanceEntry{Go: qualifiedName(t.Object), Rule: t.rule}
+		for _, prop := range t.Properties {
+			rule := fmt.Sprintf("field tagged `pulumi:%q`", prop.Name)
+			owner := t.Object
+			if prop.owner != nil && prop.owner != t.Object {
+				owner = prop.owner
+				rule += ", inlined from internal struct " + owner.Name()
+			}
+			p.Elements[ptr+"/properties/"+escapePointer(prop.Name)] = ProvenanceEntry{
+				Go:    qualifiedName(owner) + "." + prop.Field.Name(),
+				Rule:  rule,
+				Steps: prop.steps,
+			}
+		}
+	}
+	for _, r := range m.Resources {
+		addType("resources", r)
+	}
+	for _, t := range m.Types {
+		addType("types", t)
+	}
+	for _, e := range m.Enums {
+		ptr := "#/types/" + escapePointer(e.Token)
+		p.Elements[ptr] = ProvenanceEntry{Go: qualifiedName(e.Object), Rule: "named primitive type with constants"}
+		for i, v := range e.Values {
+			p.Elements[fmt.Sprintf("%s/enum/%d", ptr, i)] = ProvenanceEntry{
+				Go:   qualifiedName(v.Const),
+				Rule: "constant of type " + e.Object.Name(),
+			}
+		}
+	}
+	return p
+}
+
+// qualifiedName returns a package-level Go declaration's name, qualified by its package's path.
+func qualifiedName(obj types.Object) string {
+	if obj.Pkg() == nil {
+		return obj.Name()
+	}
+	return obj.Pkg().Path() + "." + obj.Name()
+}
@@ -0,0 +1,141 @@
+package mkschema
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// pruneUnreachable leaves the types and enums that no resource refers to, directly or through other types, out of the
+// schema, so that helper structs that happen to be tagged don't leak into it. Each one that's left out is reported as
+// a note, and recorded in the model.
+func (g *generator) pruneUnreachable() {
+	for _, name := range g.unreachable() {
+		var d Diagnostic
+		if t, has := g.Types[name]; has {
+			d = Diagnostic{Pos: t.Pos, Message: "type " + name + " isn't reachable from any resource"}
+			g.pruned = append(g.pruned, t.Token)
+			delete(g.Types, name)
+		} else {
+			e := g.Enums[name]
+			d = Diagnostic{Pos: e.Pos, Message: "enum " + name + " isn't reachable from any resource"}
+			g.pruned = append(g.pruned, e.Token)
+			delete(g.Enums, name)
+		}
+		d.Severity, d.Type, d.End = SeverityNote, name, d.Pos
+		d.End.Column, d.End.Offset = d.Pos.Column+len(name), d.Pos.Offset+len(name)
+		d.Message += ", so it was pruned from the schema"
+		if g.Diagnostics != nil && !g.noWarnings {
+			g.Diagnostics(d)
+		}
+	}
+	sort.Strings(g.pruned)
+}
+
+// selectOnly leaves every resource but those with the Only tokens, and any that they refer to, out of the schema,
+// along with the types and enums that none of those refers to, directly or through other types, so that a slice of a
+// large package can be generated on its own. Unlike pruning, nothing that's left out is reported, since most of the
+// package may be.
+func (g *generator) selectOnly() error {
+	byToken := make(map[string]*Type, len(g.Resources))
+	for _, r := range g.Resources {
+		byToken[r.Token] = r
+	}
+	var missing []string
+	for _, tok := range g.Only {
+		if byToken[tok] == nil {
+			missing = append(missing, tok)
+		}
+	}
+	if len(missing) > 0 {
+		return errors.Errorf("no resources have the tokens %s given to keep only", strings.Join(missing, ", "))
+	}
+
+	// Resources can refer to others, directly or through types, so the references are followed from each one kept.
+	kept := make(map[string]bool)
+	var keep func(tok string)
+	keep = func(tok string) {
+		if kept[tok] || byToken[tok] == nil {
+			return
+		}
+		kept[tok] = true
+		g.walkResourceRefs(byToken[tok].Properties, make(map[string]bool), keep)
+	}
+	for _, tok := range g.Only {
+		keep(tok)
+	}
+	for name, r := range g.Resources {
+		if !kept[r.Token] {
+			delete(g.Resources, name)
+		}
+	}
+	for _, name := range g.unreachable() {
+		delete(g.Types, name)
+		delete(g.Enums, name)
+	}
+	return nil
+}
+
+// walkResourceRefs calls f with the token of everything that the given properties refer to, directly or through the
+// types they refer to, other than the types already visited. Since resources can be referred to as types, f is called
+// for each type's token, too.
+func (g *generator) walkResourceRefs(props []*Property, visited map[string]bool, f func(tok string)) {
+	for _, p := range props {
+		walkTypeRefs(&p.Spec.TypeSpec, func(ref string) {
+			switch {
+			case strings.HasPrefix(ref, "#/resources/"):
+				f(ref[len("#/resources/"):])
+			case strings.HasPrefix(ref, "#/types/") && !visited[ref]:
+				visited[ref] = true
+				f(ref[len("#/types/"):])
+				for _, t := range g.Types {
+					if "#/types/"+t.Token == ref {
+						g.walkResourceRefs(t.Properties, visited, f)
+					}
+				}
+			}
+		})
+	}
+}
+
+// unreachable returns the Go names of the types and enums that no resource refers to, directly or through other
+// types, sorted.
+func (g *generator) unreachable() []string {
+	props := make(map[string][]*Property, len(g.Types))
+	for _, t := range g.Types {
+		props[t.Token] = t.Properties
+	}
+	reached := make(map[string]bool)
+	var visit func(ps []*Property)
+	visit = func(ps []*Property) {
+		for _, p := range ps {
+			walkTypeRefs(&p.Spec.TypeSpec, func(ref string) {
+				if !strings.HasPrefix(ref, "#/types/") {
+					return
+				}
+				if tok := ref[len("#/types/"):]; !reached[tok] {
+					reached[tok] = true
+					visit(props[tok])
+				}
+			})
+		}
+	}
+	for _, r := range g.Resources {
+		visit(r.Properties)
+	}
+
+	var names []string
+	for name, t := range g.Types {
+		if !reached[t.Token] {
+			names = append(names, name)
+		}
+	}
+	for name, e := range g.Enums {
+		if !reached[e.Token] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,221 @@
+package mkschema
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/types"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/blang/semver"
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
+)
+
+// externalRef is a reference to a type or resource in another package's schema, either by the package's name, as in
+// `/aws/v4.37.1/schema.json#/types/aws:s3:BucketWebsite`, which Pulumi resolves from the package's provider plugin,
+// or by the URL of the schema itself.
+type externalRef struct {
+	doc     string // the schema's document, as in `/aws/v4.37.1/schema.json`.
+	pkg     string // the package's name, for a reference by name.
+	version string // the version the reference is pinned to, if it's pinned.
+	kind    string // what's referred to, `types` or `resources`.
+	token   string // the token of the type or resource referred to.
+}
+
+// parseExternalRef parses a reference to a type or resource in another package's schema. It returns false if the
+// reference isn't one, such as one within this package or to a built-in type, and fails if the version it's pinned to
+// isn't a semantic version.
+func parseExternalRef(ref string) (externalRef, bool, error) {
+	hash := strings.Index(ref, "#/")
+	if hash == -1 {
+		return externalRef{}, false, nil
+	}
+	r := externalRef{doc: ref[:hash]}
+	kind := ref[hash+2:]
+	if slash := strings.IndexByte(kind, '/'); slash != -1 {
+		r.kind, r.token = kind[:slash], kind[slash+1:]
+	}
+	if r.kind != "types" && r.kind != "resources" || r.token == "" {
+		return externalRef{}, false, nil
+	}
+
+	switch {
+	case strings.HasPrefix(r.doc, "https://") || strings.HasPrefix(r.doc, "http://"):
+		// A URL is pinned by any path element that's a version, as in `.../v4.37.1/schema.json`.
+		for _, elem := range strings.Split(r.doc, "/") {
+			if v, ok := refVersion(elem); ok {
+				r.version = v
+			}
+		}
+	case strings.HasPrefix(r.doc, "/") && strings.HasSuffix(r.doc, "/schema.json"):
+		switch elems := strings.Split(strings.Trim(r.doc, "/"), "/"); len(elems) {
+		case 2:
+			r.pkg = elems[0]
+		case 3:
+			v, ok := refVersion(elems[1])
+			if !ok {
+				return externalRef{}, false, errors.Errorf("reference %q is pinned to %q, which isn't a version of "+
+					"the form `v<semver>`, as in `v1.2.3`", ref, elems[1])
+			}
+			r.pkg, r.version = elems[0], v
+		default:
+			return externalRef{}, false, nil
+		}
+	default:
+		return externalRef{}, false, nil
+	}
+	return r, true, nil
+}
+
+// refVersion returns the semantic version that a path element of a reference, such as `v1.2.3`, is, if it's one.
+func refVersion(elem string) (string, bool) {
+	if !strings.HasPrefix(elem, "v") {
+		return "", false
+	}
+	if _, err := semver.Parse(elem[1:]); err != nil {
+		return "", false
+	}
+	return elem[1:], true
+}
+
+// checkRefVersions checks that the references to other packages' types and resources in a field's property are
+// pinned to versions, warning of those that aren't, since their schemas may change under them, and failing on those
+// pinned to something that isn't a version.
+func (g *generator) checkRefVersions(t *types.TypeName, fld *types.Var, typ *schema.TypeSpec) error {
+	var err error
+	walkTypeRefs(typ, func(ref string) {
+		r, external, perr := parseExternalRef(ref)
+		switch {
+		case err != nil:
+		case perr != nil:
+			err = g.ruleErrorf(fld, ruleExternalRefVersions, "field %v.%v's %v", t.Name(), fld.Name(), perr)
+		case external && r.version == "":
+			werr := g.ruleErrorf(fld, ruleExternalRefVersions,
+				"field %v.%v refers to %s, which isn't pinned to a version", t.Name(), fld.Name(), ref)
+			g.warn(t.Name(), fld.Name(), suggest(werr, "pin it to the version of the package it refers to, as in "+
+				"`/<package>/v<version>/schema.json#/...`"))
+		}
+	})
+	return err
+}
+
+// refSchema is an external schema that was resolved, or failed to be.
+type refSchema struct {
+	spec *schema.PackageSpec // the schema, if it was resolved.
+	err  error               // why it couldn't be resolved, if it couldn't.
+}
+
+// resolveExternalRefs resolves the schemas that the gathered properties' references to other packages' types and
+// resources are to, reporting each reference to a type or resource that its schema lacks, or whose schema couldn't be
+// resolved. Each schema is resolved once, from the provider plugin installed in Pulumi's plugin cache, for references
+// by package name, or else from its URL.
+func (g *generator) resolveExternalRefs(ctx context.Context) error {
+	schemas := make(map[string]refSchema)
+	m := g.Model()
+	for _, typ := range append(m.Resources, m.Types...) {
+		kept := typ.Properties[:0]
+		for _, p := range typ.Properties {
+			var err error
+			walkTypeRefs(&p.Spec.TypeSpec, func(ref string) {
+				r, external, _ := parseExternalRef(ref)
+				if err != nil || !external {
+					return
+				}
+				s, has := schemas[r.doc]
+				if !has {
+					s.spec, s.err = resolveRefSchema(ctx, r)
+					schemas[r.doc] = s
+				}
+				err = g.checkExternalRef(typ, p, ref, r, s)
+			})
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+			if err != nil {
+				g.report(typ.Object.Name(), p.Field.Name(), err)
+				continue // in best-effort mode, the property is left out.
+			}
+			kept = append(kept, p)
+		}
+		typ.Properties = kept
+	}
+	return nil
+}
+
+// checkExternalRef checks that a property's reference is to a type or resource that its resolved schema has.
+func (g *generator) checkExternalRef(t *Type, p *Property, ref string, r externalRef, s refSchema) error {
+	if s.err != nil {
+		return g.ruleErrorf(p.Field, ruleExternalRefs, "field %v.%v refers to %s, whose schema couldn't be "+
+			"resolved: %v", t.Object.Name(), p.Field.Name(), ref, s.err)
+	}
+	found := false
+	switch r.kind {
+	case "types":
+		_, found = s.spec.Types[r.token]
+	case "resources":
+		_, found = s.spec.Resources[r.token]
+	}
+	if !found {
+		kind := strings.TrimSuffix(r.kind, "s")
+		err := g.ruleErrorf(p.Field, ruleExternalRefs, "field %v.%v refers to %s, but %s has no %s %s",
+			t.Object.Name(), p.Field.Name(), ref, s.spec.Name, kind, r.token)
+		return suggest(err, fmt.Sprintf("check the token, and that %s %s has it", s.spec.Name, s.spec.Version))
+	}
+	return nil
+}
+
+// resolveRefSchema resolves the schema an external reference is to.
+func resolveRefSchema(ctx context.Context, r externalRef) (*schema.PackageSpec, error) {
+	if r.pkg == "" {
+		return fetchRefSchema(ctx, r.doc)
+	} else if r.version == "" {
+		return nil, errors.New("it isn't pinned to a version, so there's no plugin to resolve it from")
+	}
+
+	dir, err := workspace.GetPluginDir()
+	if err != nil {
+		return nil, errors.Wrap(err, "finding the plugin cache")
+	}
+	name := "pulumi-resource-" + r.pkg
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	path := filepath.Join(dir, fmt.Sprintf("resource-%s-v%s", r.pkg, r.version), name)
+	if _, err = os.Stat(path); os.IsNotExist(err) {
+		return nil, errors.Errorf("the %s plugin v%s isn't installed; install it with `pulumi plugin install "+
+			"resource %s v%s`", r.pkg, r.version, r.pkg, r.version)
+	}
+	return ProviderSchema(ctx, path)
+}
+
+// fetchRefSchema fetches the schema at a URL.
+func fetchRefSchema(ctx context.Context, url string) (*schema.PackageSpec, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("fetching it failed with %s", resp.Status)
+	}
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	var spec schema.PackageSpec
+	if err = json.Unmarshal(b, &spec); err != nil {
+		return nil, errors.Wrap(err, "parsing it")
+	}
+	return &spec, nil
+}
@@ -0,0 +1,73 @@
+package mkschema
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// IsRemote returns true if a Go package is given at a version, as in `github.com/org/pkg@v1.4.0`, to be fetched by
+// FetchRemote rather than found locally.
+func IsRemote(pkg string) bool {
+	return strings.Contains(pkg, "@")
+}
+
+// FetchRemote fetches the Go module providing a package given at a version, as in `github.com/org/pkg@v1.4.0`,
+// through the Go module proxy, so that a published version can be generated from without a checkout. The module is
+// required by a scratch module in a new temporary directory, with a module cache of its own, so that nothing else is
+// touched. It returns the options to generate from the fetched package with, and a function that removes the directory.
+func FetchRemote(ctx context.Context, opts Options) (Options, func(), error) {
+	at := strings.LastIndex(opts.Package, "@")
+	if at == -1 {
+		return opts, func() {}, nil
+	}
+	pkg, version := opts.Package[:at], opts.Package[at+1:]
+	if pkg == "" || version == "" {
+		return opts, nil, errors.Errorf("malformed package %q; expected `path@version`", opts.Package)
+	}
+
+	dir, err := ioutil.TempDir("", "mkschema-remote")
+	if err != nil {
+		return opts, nil, err
+	}
+	env := append(opts.goCommandEnv(), "GOMODCACHE="+filepath.Join(dir, "mod"), "GOFLAGS=-mod=mod",
+		"GOWORK=off")
+	cleanup := func() {
+		// The module cache's files are read-only, so it's cleaned by the go command before removing the rest.
+		_ = goCommand(context.Background(), dir, env, "clean", "-modcache")
+		_ = os.RemoveAll(dir)
+	}
+	src := filepath.Join(dir, "src")
+	if err = os.Mkdir(src, 0700); err == nil {
+		err = goCommand(ctx, src, env, "mod", "init", "mkschema.remote")
+	}
+	if err == nil {
+		err = goCommand(ctx, src, env, "get", pkg+"@"+version)
+	}
+	if err != nil {
+		cleanup()
+		return opts, nil, errors.Wrapf(err, "fetching %s", opts.Package)
+	}
+
+	opts.Package, opts.Dir, opts.Env = pkg, src, env
+	return opts, cleanup, nil
+}
+
+// goCommand runs the go command in dir, with the given environment added to the process's, failing with its output.
+func goCommand(ctx context.Context, dir string, env []string, args ...string) error {
+	cmd := exec.CommandContext(ctx, "go", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return errors.Errorf("go %s: %s", strings.Join(args, " "), msg)
+		}
+		return errors.Wrapf(err, "go %s", strings.Join(args, " "))
+	}
+	return nil
+}
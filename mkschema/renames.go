@@ -0,0 +1,138 @@
+package mkschema
+
+import (
+	"bufio"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ReadRenames parses a rename file, which maps the tokens that resources, types, and enums would otherwise have to
+// the tokens they're renamed to, so that a package's tokens can be reorganized in one place, without annotating each
+// type. Each renamed resource is aliased to its old token in the schema, so that existing stacks see the rename
+// rather than a replacement; types and enums can't be aliased, so only their references follow them.
+//
+// The file is line-oriented. Blank lines and lines starting with `#` are ignored, and every other line is:
+//
+//	<old-token> <new-token>
+func ReadRenames(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	renames := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, errors.Errorf("%s:%d: malformed rename '%s'", path, line, text)
+		}
+		old, tok := fields[0], fields[1]
+		if _, has := renames[old]; has {
+			return nil, errors.Errorf("%s:%d: token %s is renamed more than once", path, line, old)
+		}
+		renames[old] = tok
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+	return renames, nil
+}
+
+// checkRenames rejects renames that aren't between tokens of the package, that are chained, since each token is
+// renamed just once, or that rename several tokens to the same one.
+func (g *generator) checkRenames() error {
+	targets := make(map[string]string, len(g.Renames))
+	for _, old := range sortedKeys(g.Renames) {
+		tok := g.Renames[old]
+		for _, t := range []string{old, tok} {
+			if parts := strings.Split(t, ":"); len(parts) != 3 || parts[0] != g.Name || parts[2] == "" {
+				return errors.Errorf("renamed token %q isn't of the form `%s:<module>:<name>`", t, g.Name)
+			}
+		}
+		if next, has := g.Renames[tok]; has {
+			return errors.Errorf("token %s is renamed to %s, which is itself renamed to %s; rename it to %s directly",
+				old, tok, next, next)
+		} else if prior, has := targets[tok]; has {
+			return errors.Errorf("tokens %s and %s are both renamed to %s", prior, old, tok)
+		}
+		targets[tok] = old
+	}
+	return nil
+}
+
+// rename returns the token that a token is renamed to, if any, recording that its rename was used.
+func (g *generator) rename(tok string) string {
+	renamed, has := g.Renames[tok]
+	if !has {
+		return tok
+	}
+	if g.renamed == nil {
+		g.renamed = make(map[string]bool)
+	}
+	g.renamed[tok] = true
+	return renamed
+}
+
+// checkRenamesUsed rejects renames of tokens that no resource, type, or enum has, which are most likely left over
+// from an earlier rename of the Go type itself, so that they don't go unnoticed, and renames to tokens that another
+// resource, type, or enum already has, which would take its place in the schema. The tokens are those of everything
+// gathered, mapped to the Go types that have them.
+func (g *generator) checkRenamesUsed(tokens map[string][]string) error {
+	var unused []string
+	for old := range g.Renames {
+		if !g.renamed[old] {
+			unused = append(unused, old)
+		}
+	}
+	if len(unused) > 0 {
+		sort.Strings(unused)
+		return errors.Errorf("renamed tokens %s aren't those of any resource, type, or enum",
+			strings.Join(unused, ", "))
+	}
+
+	for _, old := range sortedKeys(g.Renames) {
+		if tok := g.Renames[old]; len(tokens[tok]) > 1 {
+			names := append([]string(nil), tokens[tok]...)
+			sort.Strings(names)
+			return errors.Errorf("token %s is renamed to %s, so %s would share it", old, tok,
+				strings.Join(names, " and "))
+		}
+	}
+	return nil
+}
+
+// tokens returns the tokens of the gathered resources, types, and enums, mapped to the Go types that have them.
+func (g *generator) tokens() map[string][]string {
+	tokens := make(map[string][]string)
+	for name, r := range g.Resources {
+		tokens[r.Token] = append(tokens[r.Token], name)
+	}
+	for name, t := range g.Types {
+		tokens[t.Token] = append(tokens[t.Token], name)
+	}
+	for name, e := range g.Enums {
+		tokens[e.Token] = append(tokens[e.Token], name)
+	}
+	return tokens
+}
+
+// renamedFrom returns the old tokens that are renamed to a token, sorted.
+func (g *generator) renamedFrom(tok string) []string {
+	var olds []string
+	for old, renamed := range g.Renames {
+		if renamed == tok {
+			olds = append(olds, old)
+		}
+	}
+	sort.Strings(olds)
+	return olds
+}
@@ -0,0 +1,291 @@
+package mkschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"go/format"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+)
+
+// ProviderScaffold is the source of a component provider host, scaffolded from a gathered model.
+type ProviderScaffold struct {
+	Main      []byte // main.go, which serves the schema and dispatches Construct calls; it's safe to regenerate.
+	Construct []byte // construct.go, with a stub constructor per resource, for the component author to fill in.
+	Schema    []byte // schema.json, which main.go embeds.
+}
+
+// ProviderFramework is the framework that a scaffolded provider host is built on.
+type ProviderFramework string
+
+const (
+	// FrameworkSDK builds the host on the Pulumi SDK's own support for component providers.
+	FrameworkSDK ProviderFramework = "sdk"
+	// FrameworkInfer builds the host on the infer package of github.com/pulumi/pulumi-go-provider, registering each
+	// resource as an inferred component under its schema token. The generated code targets its context.Context-based
+	// API.
+	FrameworkInfer ProviderFramework = "infer"
+)
+
+// ScaffoldProvider scaffolds a component provider host for the model's resources on the given framework, serving the
+// given schema, which is typically the one generated from the model. The host's GetSchema returns the schema, and its
+// Construct unmarshals the inputs of each resource into the resource's Go struct before dispatching to the resource's
+// constructor. Those constructors are the only part left to write by hand, and are stubbed out in a separate file so
+// that the rest can be regenerated as the resources change.
+func (m *Model) ScaffoldProvider(spec *schema.PackageSpec, framework ProviderFramework) (*ProviderScaffold, error) {
+	if framework != FrameworkSDK && framework != FrameworkInfer {
+		return nil, errors.Errorf("unknown provider framework %q", framework)
+	}
+	sch, err := json.MarshalIndent(spec, "", "    ")
+	if err != nil {
+		return nil, errors.Wrapf(err, "serializing schema to JSON")
+	}
+
+	data := scaffoldData{Name: m.Name, Version: m.Metadata.Version, Package: m.Package}
+	if data.Version == "" {
+		data.Version = "0.0.1"
+	}
+	for _, r := range m.Resources {
+		data.Alias = r.Object.Pkg().Name()
+		if !r.IsComponent {
+			continue // custom resources have CRUD operations, rather than Construct, for the provider to implement.
+		}
+		data.Resources = append(data.Resources, scaffoldResource{
+			Token:  r.Token,
+			Module: r.Token[strings.Index(r.Token, ":")+1 : strings.LastIndex(r.Token, ":")],
+			Name:   r.Token[strings.LastIndex(r.Token, ":")+1:],
+			GoName: r.Object.Name(),
+		})
+	}
+	switch data.Alias {
+	case "", "main", "context", "fmt", "cmdutil", "infer", "os", "p", "provider", "pulumi",
+		"pulumiprovider":
+		data.Alias = "component"
+	}
+
+	main, err := renderScaffold(string(framework)+"-main", data)
+	if err != nil {
+		return nil, err
+	}
+	construct, err := renderScaffold(string(framework)+"-construct", data)
+	if err != nil {
+		return nil, err
+	}
+	return &ProviderScaffold{Main: main, Construct: construct, Schema: append(sch, '\n')}, nil
+}
+
+type scaffoldData struct {
+	Name      string             // the Pulumi package name.
+	Version   string             // the provider's default version.
+	Package   string             // the Go package declaring the resources.
+	Alias     string             // the name to import the Go package declaring the resources as.
+	Resources []scaffoldResource // the resources to dispatch, sorted by token.
+}
+
+type scaffoldResource struct {
+	Token  string // the resource's schema token.
+	Module string // the module part of the resource's token.
+	Name   string // the name part of the resource's token.
+	GoName string // the name of the resource's Go struct.
+}
+
+func renderScaffold(name string, data scaffoldData) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := scaffoldTemplates.ExecuteTemplate(&buf, name, data); err != nil {
+		return nil, errors.Wrapf(err, "scaffolding %s", name)
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, errors.Wrapf(err, "formatting %s", name)
+	}
+	return src, nil
+}
+
+var scaffoldTemplates = template.Must(template.New("scaffold").Parse(`
+{{- define "sdk-main" -}}
+// Code generated by pulumi-mkschema; DO NOT EDIT.
+
+package main
+
+import (
+	_ "embed"
+	"fmt"
+
+	"github.com/pulumi/pulumi/pkg/v3/resource/provider"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	pulumiprovider "github.com/pulumi/pulumi/sdk/v3/go/pulumi/provider"
+{{- if .Resources}}
+
+	{{.Alias}} "{{.Package}}"
+{{- end}}
+)
+
+// providerName is the name of the Pulumi package that this provider serves.
+const providerName = "{{.Name}}"
+
+// version is the provider's version, which may be overridden at build time with -ldflags "-X main.version=...".
+var version = "{{.Version}}"
+
+//go:embed schema.json
+var schema []byte
+
+func main() {
+	if err := provider.ComponentMain(providerName, version, schema, construct); err != nil {
+		cmdutil.ExitError(err.Error())
+	}
+}
+
+// construct creates a component resource, by unmarshaling its inputs into its Go struct and calling its constructor.
+func construct(ctx *pulumi.Context, typ, name string, inputs pulumiprovider.ConstructInputs,
+	options pulumi.ResourceOption) (*pulumiprovider.ConstructResult, error) {
+	var component pulumi.ComponentResource
+	var err error
+	switch typ {
+{{- range .Resources}}
+	case "{{.Token}}":
+		args := &{{$.Alias}}.{{.GoName}}{}
+		if err = inputs.CopyTo(args); err != nil {
+			return nil, fmt.Errorf("setting args for %s: %w", name, err)
+		}
+		component, err = construct{{.GoName}}(ctx, typ, name, args, options)
+{{- end}}
+	default:
+		return nil, fmt.Errorf("unknown resource type %s", typ)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return pulumiprovider.NewConstructResult(component)
+}
+{{end -}}
+
+{{- define "sdk-construct" -}}
+package main
+{{- if .Resources}}
+
+import (
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+
+	{{.Alias}} "{{.Package}}"
+)
+{{- end}}
+{{range .Resources}}
+// construct{{.GoName}} creates a {{.GoName}} component. Its args hold the inputs it was constructed with, and it
+// becomes the component itself, so that the outputs it sets on them are returned to the program.
+func construct{{.GoName}}(ctx *pulumi.Context, typ, name string, args *{{$.Alias}}.{{.GoName}},
+	options pulumi.ResourceOption) (pulumi.ComponentResource, error) {
+	if err := ctx.RegisterComponentResource(typ, name, args, options); err != nil {
+		return nil, err
+	}
+
+	// TODO: create the component's child resources, parented with pulumi.Parent(args), and set its outputs.
+
+	if err := ctx.RegisterResourceOutputs(args, pulumi.Map{}); err != nil {
+		return nil, err
+	}
+	return args, nil
+}
+{{end -}}
+{{end -}}
+
+{{- define "infer-main" -}}
+// Code generated by pulumi-mkschema; DO NOT EDIT.
+
+package main
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"os"
+
+	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/infer"
+{{- if .Resources}}
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+
+	{{.Alias}} "{{.Package}}"
+{{- end}}
+)
+
+// providerName is the name of the Pulumi package that this provider serves.
+const providerName = "{{.Name}}"
+
+// version is the provider's version, which may be overridden at build time with -ldflags "-X main.version=...".
+var version = "{{.Version}}"
+
+//go:embed schema.json
+var schema string
+
+func main() {
+	if err := p.RunProvider(providerName, version, provider()); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err.Error())
+		os.Exit(1)
+	}
+}
+
+// provider registers a component for every resource in the schema, and serves the schema itself rather than one
+// inferred from the Go types, so that the two can never diverge.
+func provider() p.Provider {
+	prov := infer.Provider(infer.Options{
+		Components: []infer.InferredComponent{
+{{- range .Resources}}
+			infer.Component[*{{.GoName}}Component, {{$.Alias}}.{{.GoName}}, *{{$.Alias}}.{{.GoName}}](),
+{{- end}}
+		},
+	})
+	prov.GetSchema = func(context.Context, p.GetSchemaRequest) (p.GetSchemaResponse, error) {
+		return p.GetSchemaResponse{Schema: schema}, nil
+	}
+	return prov
+}
+{{range .Resources}}
+// {{.GoName}}Component constructs {{.Token}} components with construct{{.GoName}}.
+type {{.GoName}}Component struct{}
+
+// Annotate gives the component the same token as in the schema.
+func (*{{.GoName}}Component) Annotate(a infer.Annotator) {
+	a.SetToken("{{.Module}}", "{{.Name}}")
+}
+
+// Construct creates a {{.GoName}} component.
+func (*{{.GoName}}Component) Construct(ctx *pulumi.Context, name, typ string, args {{$.Alias}}.{{.GoName}},
+	options pulumi.ResourceOption) (*{{$.Alias}}.{{.GoName}}, error) {
+	return construct{{.GoName}}(ctx, typ, name, &args, options)
+}
+{{end -}}
+{{end -}}
+
+{{- define "infer-construct" -}}
+package main
+{{- if .Resources}}
+
+import (
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+
+	{{.Alias}} "{{.Package}}"
+)
+{{- end}}
+{{range .Resources}}
+// construct{{.GoName}} creates a {{.GoName}} component. Its args hold the inputs it was constructed with, and it
+// becomes the component itself, so that the outputs it sets on them are returned to the program.
+func construct{{.GoName}}(ctx *pulumi.Context, typ, name string, args *{{$.Alias}}.{{.GoName}},
+	options pulumi.ResourceOption) (*{{$.Alias}}.{{.GoName}}, error) {
+	if err := ctx.RegisterComponentResource(typ, name, args, options); err != nil {
+		return nil, err
+	}
+
+	// TODO: create the component's child resources, parented with pulumi.Parent(args), and set its outputs.
+
+	if err := ctx.RegisterResourceOutputs(args, pulumi.Map{}); err != nil {
+		return nil, err
+	}
+	return args, nil
+}
+{{end -}}
+{{end -}}
+`))
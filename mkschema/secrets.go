@@ -0,0 +1,153 @@
+package mkschema
+
+import (
+	"go/ast"
+	"go/types"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+)
+
+// secretWords are the words that, ending a property's name, suggest that its values are secret.
+var secretWords = map[string]bool{
+	"password":   true,
+	"passwd":     true,
+	"passphrase": true,
+	"pwd":        true,
+	"secret":     true,
+	"token":      true,
+	"credential": true,
+	"apikey":     true,
+	"privatekey": true,
+}
+
+// secretKeyWords are the words that, followed by "key" at the end of a property's name, suggest that its values are
+// secret, as in `apiKey` or `privateKey`, unlike a `key` of a key-value pair.
+var secretKeyWords = map[string]bool{
+	"access":     true,
+	"api":        true,
+	"auth":       true,
+	"client":     true,
+	"encryption": true,
+	"master":     true,
+	"private":    true,
+	"secret":     true,
+	"shared":     true,
+	"signing":    true,
+}
+
+// secretName returns true if a property's name suggests that its values are secret: if its last word, or the plural
+// of it, is one such as "password" or "token", or is "key", following a word such as "private". Names that only start
+// with such a word, such as `tokenUrl` or `secretName`, usually aren't of secrets themselves.
+func secretName(name string) bool {
+	words := nameWords(name)
+	if len(words) == 0 {
+		return false
+	}
+	last := words[len(words)-1]
+	if singular := strings.TrimSuffix(last, "s"); len(singular) > 2 {
+		last = singular
+	}
+	if secretWords[last] {
+		return true
+	}
+	return last == "key" && len(words) > 1 && secretKeyWords[words[len(words)-2]]
+}
+
+// nameWords splits a camel case, snake case, or kebab case name into its lower case words, such that `dbPassword`,
+// `db_password`, and `DBPassword` are each "db" and "password".
+func nameWords(name string) []string {
+	var words []string
+	var word []rune
+	runes := []rune(name)
+	for i, r := range runes {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			if len(word) > 0 {
+				words, word = append(words, string(word)), nil
+			}
+			continue
+		case unicode.IsUpper(r) && len(word) > 0:
+			// An upper case letter starts a word after a lower case one, or ends an acronym before a lower case one.
+			prev := runes[i-1]
+			if !unicode.IsUpper(prev) || i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+				words, word = append(words, string(word)), nil
+			}
+		}
+		word = append(word, unicode.ToLower(r))
+	}
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+	return words
+}
+
+// stringValued returns true if a property's values are strings, or arrays or maps of them.
+func stringValued(t *schema.TypeSpec) bool {
+	for t != nil {
+		switch t.Type {
+		case "string":
+			return t.Ref == ""
+		case "array":
+			t = t.Items
+		case "object":
+			t = t.AdditionalProperties
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+// checkSecret warns of a property whose name suggests that its values are secret, but which isn't marked `secret`,
+// or, with AutoSecret, marks it secret, noting that it did, unless the warning is suppressed. Only properties of
+// strings, or of arrays or maps of them, are checked, since the likes of a `tokenCount` are rarely secret.
+func (g *generator) checkSecret(node *ast.TypeSpec, t *types.TypeName, fld *types.Var, name string,
+	spec *schema.PropertySpec) {
+	if spec.Secret || !secretName(name) || !stringValued(&spec.TypeSpec) {
+		return
+	}
+	if !g.AutoSecret {
+		err := g.ruleErrorf(fld, ruleSecretNames,
+			"field %v.%v's property %q is named like a secret, but isn't marked `secret`", t.Name(), fld.Name(), name)
+		g.warn(t.Name(), fld.Name(), g.suggestSecret(err, node, t, fld))
+		return
+	}
+
+	err := g.ruleErrorf(fld, ruleSecretNames,
+		"field %v.%v's property %q is named like a secret, so it was marked `secret`", t.Name(), fld.Name(), name)
+	d := newDiagnostic(SeverityNote, t.Name(), fld.Name(), err)
+	if g.suppressed(d) {
+		return
+	}
+	spec.Secret = true
+	if g.Diagnostics != nil && !g.noWarnings {
+		g.Diagnostics(d)
+	}
+}
+
+// suggestSecret suggests marking a field's property `secret`: in its annotation, if it has one, or else by adding the
+// option to the front of its tag.
+func (g *generator) suggestSecret(err error, node *ast.TypeSpec, t *types.TypeName, fld *types.Var) error {
+	if _, annotated := g.fieldAnnotation(t, fld); annotated {
+		return suggest(err, "set `secret: true` in its annotation, or suppress this if its values aren't secret")
+	}
+	suggestion := "add `secret` to its `pschema` tag, or suppress this if its values aren't secret"
+	if syntax := fieldSyntax(node, fld); syntax != nil {
+		options := "secret"
+		if syntax.Tag != nil {
+			if tag, uerr := strconv.Unquote(syntax.Tag.Value); uerr == nil {
+				if existing := reflect.StructTag(tag).Get(PropertyOptionsTag); existing != "" {
+					options += "," + existing
+				}
+			}
+		}
+		if fix, ok := g.tagEdit(syntax, PropertyOptionsTag, options); ok {
+			return suggest(err, suggestion, fix)
+		}
+	}
+	return suggest(err, suggestion)
+}
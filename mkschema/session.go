@@ -0,0 +1,70 @@
+package mkschema
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+)
+
+// Session keeps a package's gathered model in memory across requests, for long-running tools such as editor
+// integrations. Each request checks whether the package's files, or its module's, have changed, which only requires
+// listing the package, and reloads it if they have; otherwise, it's answered from memory. A Session is safe to use
+// from multiple goroutines.
+type Session struct {
+	opts Options
+
+	mu    sync.Mutex
+	key   string       // the cache key of the loaded model, if any.
+	model *Model       // the loaded model, if any.
+	diags []Diagnostic // the diagnostics reported while loading the model.
+	err   error        // the error loading the model, which is nil or a *PartialError if there is one.
+}
+
+// NewSession creates a session for generating with the given options. Nothing is loaded until the first request.
+func NewSession(opts Options) *Session {
+	return &Session{opts: opts.clone()}
+}
+
+// Model returns the package's current model, reloading it if anything has changed, along with the diagnostics
+// reported while gathering it. In best-effort mode, the model is returned alongside a *PartialError if anything was
+// skipped. Diagnostics are collected rather than sent to the options' sink.
+func (s *Session) Model(ctx context.Context) (*Model, []Diagnostic, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, err := cacheKey(ctx, s.opts)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "computing cache key")
+	}
+	if s.model != nil && key == s.key {
+		return s.model, s.diags, s.err
+	}
+
+	var diags []Diagnostic
+	opts := s.opts
+	opts.Diagnostics = func(d Diagnostic) {
+		diags = append(diags, d)
+	}
+	m, err := Gather(ctx, opts)
+	if m == nil {
+		// Failures aren't remembered, so that the next request tries again, in case it was fixed by an edit to a
+		// file that isn't part of the key.
+		s.key, s.model, s.diags, s.err = "", nil, nil, nil
+		return nil, diags, err
+	}
+	s.key, s.model, s.diags, s.err = key, m, diags, err
+	return m, diags, err
+}
+
+// Generate returns the package's current schema, as Generate would, reloading the package if anything has changed,
+// along with the diagnostics reported while gathering it.
+func (s *Session) Generate(ctx context.Context) (*schema.PackageSpec, []Diagnostic, error) {
+	m, diags, err := s.Model(ctx)
+	if m == nil {
+		return nil, diags, err
+	}
+	spec, err := finishSchema(ctx, m.Schema(), s.opts.Hooks, err)
+	return spec, diags, err
+}
@@ -0,0 +1,115 @@
+package mkschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+)
+
+// SetHook returns a hook that overrides fields of the generated schema, given as `PATH=VALUE` pairs applied in order.
+// A path names a field by its JSON keys, separated by dots, as in `language.go.importBasePath`; a literal dot in a
+// key, as in a token, is escaped with a backslash. Objects along the path are created as needed. A value is parsed as
+// JSON if it can be, so `true` and `["a","b"]` set a boolean and a list, while `null` removes the field; anything else
+// is a string. So that versions such as `1.0` aren't mangled, a number is only a number if it replaces one.
+func SetHook(overrides []string) (Hook, error) {
+	type override struct {
+		path  []string
+		value interface{}
+		raw   string
+	}
+	var parsed []override
+	for _, o := range overrides {
+		eq := strings.IndexByte(o, '=')
+		if eq <= 0 {
+			return nil, errors.Errorf("malformed override %q: expected PATH=VALUE", o)
+		}
+		var value interface{}
+		if err := json.Unmarshal([]byte(o[eq+1:]), &value); err != nil {
+			value = o[eq+1:]
+		}
+		parsed = append(parsed, override{path: splitSetPath(o[:eq]), value: value, raw: o[eq+1:]})
+	}
+
+	return func(spec *schema.PackageSpec) error {
+		b, err := json.Marshal(spec)
+		if err != nil {
+			return err
+		}
+		var doc map[string]interface{}
+		if err = json.Unmarshal(b, &doc); err != nil {
+			return err
+		}
+		for _, o := range parsed {
+			if err = setField(doc, o.path, o.value, o.raw); err != nil {
+				return errors.Wrapf(err, "overriding %s", strings.Join(o.path, "."))
+			}
+		}
+
+		// Decode strictly, so that a misspelled field is an error rather than silently dropped.
+		if b, err = json.Marshal(doc); err != nil {
+			return err
+		}
+		var result schema.PackageSpec
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.DisallowUnknownFields()
+		if err = dec.Decode(&result); err != nil {
+			return errors.Wrapf(err, "applying overrides")
+		}
+		*spec = result
+		return nil
+	}, nil
+}
+
+// splitSetPath splits an override's path at each dot that isn't escaped with a backslash.
+func splitSetPath(path string) []string {
+	var keys []string
+	var key strings.Builder
+	for i := 0; i < len(path); i++ {
+		switch {
+		case path[i] == '\\' && i+1 < len(path) && path[i+1] == '.':
+			key.WriteByte('.')
+			i++
+		case path[i] == '.':
+			keys = append(keys, key.String())
+			key.Reset()
+		default:
+			key.WriteByte(path[i])
+		}
+	}
+	return append(keys, key.String())
+}
+
+// setField sets the field at path within doc to value, creating objects along the way, or removes it if value is nil.
+// A numeric value is set as its raw text instead, unless the field is already a number.
+func setField(doc map[string]interface{}, path []string, value interface{}, raw string) error {
+	for i, key := range path[:len(path)-1] {
+		next, has := doc[key]
+		if !has || next == nil {
+			if value == nil {
+				return nil // there's nothing to remove.
+			}
+			next = make(map[string]interface{})
+			doc[key] = next
+		}
+		obj, ok := next.(map[string]interface{})
+		if !ok {
+			return errors.Errorf("%s is not an object", strings.Join(path[:i+1], "."))
+		}
+		doc = obj
+	}
+	key := path[len(path)-1]
+	if _, isNumber := value.(float64); isNumber {
+		if _, wasNumber := doc[key].(float64); !wasNumber {
+			value = raw
+		}
+	}
+	if value == nil {
+		delete(doc, key)
+	} else {
+		doc[key] = value
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package mkschema
+
+import (
+	"fmt"
+	"go/token"
+	"path/filepath"
+)
+
+// sourceMapVersion is the version of the source map's format, which is bumped whenever it changes incompatibly.
+const sourceMapVersion = 1
+
+// SourceMap maps the elements of a schema back to the Go declarations they were gathered from, for tools such as
+// editors jumping to a definition, or blaming a schema diff on the source change behind it.
+type SourceMap struct {
+	Version int `json:"version"`
+	// Elements maps the JSON pointer to each resource, type, property, and enum value in the schema, such as
+	// `#/resources/pkg:index:Bucket/properties/name`, to its Go declaration. Enum values are pointed to by index.
+	Elements map[string]SourceLocation `json:"elements"`
+}
+
+// SourceLocation is the position of a Go declaration.
+type SourceLocation struct {
+	File   string `json:"file"`             // the Go file, relative to the source map's base directory, if it has one.
+	Line   int    `json:"line"`             // the 1-based line.
+	Column int    `json:"column,omitempty"` // the 1-based column, in bytes.
+}
+
+// SourceMap returns the source map of the model's schema. Files are given relative to base, such as the directory the
+// source map is written to, with forward slashes, so that the map can be shared between machines; if base is empty,
+// they're given as they were loaded.
+func (m *Model) SourceMap(base string) *SourceMap {
+	sm := &SourceMap{Version: sourceMapVersion, Elements: make(map[string]SourceLocation)}
+	add := func(ptr string, pos token.Position) {
+		if !pos.IsValid() {
+			return
+		}
+		loc := SourceLocation{File: pos.Filename, Line: pos.Line, Column: pos.Column}
+		if base != "" {
+			if rel, err := filepath.Rel(base, pos.Filename); err == nil {
+				loc.File = filepath.ToSlash(rel)
+			}
+		}
+		sm.Elements[ptr] = loc
+	}
+	addType := func(section string, t *Type) {
+		ptr := "#/" + section + "/" + escapePointer(t.Token)
+		add(ptr, t.Pos)
+		for _, p := range t.Properties {
+			add(ptr+"/properties/"+escapePointer(p.Name), p.Pos)
+		}
+	}
+	for _, r := range m.Resources {
+		addType("resources", r)
+	}
+	for _, t := range m.Types {
+		addType("types", t)
+	}
+	for _, e := range m.Enums {
+		ptr := "#/types/" + escapePointer(e.Token)
+		add(ptr, e.Pos)
+		for i, v := range e.Values {
+			add(fmt.Sprintf("%s/enum/%d", ptr, i), v.Pos)
+		}
+	}
+	return sm
+}
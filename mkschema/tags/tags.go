@@ -0,0 +1,331 @@
+// Package tags defines the struct tag grammar that drives Pulumi schema generation. It has no dependencies beyond
+// the standard library, so that runtime component frameworks can interpret tags exactly as the schema generator
+// does, and never disagree with it.
+//
+// A property is declared with a `pulumi:"<name>"` tag, the same tag the Pulumi SDK itself uses, and its schema
+// options with a `pschema:"<option>,..."` tag. The options are:
+//
+//	optional    the property is optional (the default is required)
+//	replaces    changing the property replaces the resource
+//	in          the property is an input, but not an output, of the resource
+//	out         the property is an output, but not an input, of the resource
+//	secret      the property's values are secret
+//	ref=<ref>   the property references the given type, typically from another package
+//	example=<v> an example of the property's value, for its docs
+//
+// The value of an option may be quoted with single quotes, so that it may contain commas, with any single quote
+// within it doubled:
+//
+//	example='a, b'
+//	example='it''s a, b'
+//
+// An unquoted value ends at the next comma, so an example containing commas must be quoted.
+//
+// A tag that isn't of the form `key:"value"`, that has an unknown option, or that has an unterminated quoted value,
+// is malformed, and Parse reports where.
+//
+// The grammar is versioned by Version. Within a version, existing tags are guaranteed to keep their meaning. Version
+// 2 rejected unknown options and malformed tags, which version 1 ignored, version 3 added quoted values, version 4
+// added `secret`, and version 5 ended unquoted examples at the next comma, rather than at the end of the options.
+package tags
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Version is the version of the tag grammar implemented by this package.
+const Version = 5
+
+const (
+	// NameTag is the field tag used to drive the Pulumi schema name. By using the
+	// same tag as Pulumi, we avoid the need to redundantly declare multiple tags. Unfortunately,
+	// Pulumi does not permit comma-delimited options in this tag, so we need a separate options one.
+	NameTag = "pulumi"
+	// OptionsTag is the field tag used to control various schema options.
+	OptionsTag = "pschema"
+)
+
+// PropertyOptions represents a parsed field tag, controlling how properties are treated.
+type PropertyOptions struct {
+	Name     string // the property name to emit into the package.
+	Optional bool   // true if this is an optional property.
+	Replaces bool   // true if changing this property triggers a replacement of this resource.
+	In       bool   // true if this is part of the resource's input, but not its output, properties.
+	Out      bool   // true if the property is part of the resource's output, rather than input, properties.
+	Secret   bool   // true if the property's values are secret.
+	Ref      string // required if we're referencing another package's type.
+	Example  string // an example value of the property, for its docs.
+}
+
+// Parse parses a tag into a structured set of options. It also returns whether the tag had any Pulumi tags at all. A
+// malformed tag, whose Pulumi tags aren't of the form `key:"value"`, or which has an unknown option, a `ref=` without
+// a reference, or an unterminated quoted value, is an error, a *SyntaxError locating the problem within the tag.
+func Parse(tag string) (bool, PropertyOptions, error) {
+	var hadTags bool
+	var result PropertyOptions
+
+	// First see if there is a field name.
+	name, _, has, err := lookup(tag, NameTag)
+	if err != nil {
+		return false, result, err
+	} else if has {
+		hadTags = true
+		result.Name = name
+	}
+
+	// Next see if there are options and, if so, parse and decode the comma-delimited list.
+	opts, offset, has, err := lookup(tag, OptionsTag)
+	if err != nil {
+		return false, result, err
+	} else if has {
+		hadTags = true
+		// If the options were written with escapes, problems can only be located at the whole of them.
+		exact := offset+len(opts) <= len(tag) && tag[offset:offset+len(opts)] == opts
+		whole := offset
+		at := func(offset, length int) (int, int) {
+			if !exact {
+				return whole, len(opts)
+			}
+			return offset, length
+		}
+		options, serr := splitOptions(opts)
+		if serr != nil {
+			serr.Offset, serr.Length = at(offset+serr.Offset, serr.Length)
+			return false, result, serr
+		}
+		for i, o := range options {
+			key := o.text
+			switch {
+			case key == "":
+			case key == "optional":
+				result.Optional = true
+			case key == "replaces":
+				result.Replaces = true
+			case key == "in":
+				result.In = true
+			case key == "out":
+				result.Out = true
+			case key == "secret":
+				result.Secret = true
+			case strings.HasPrefix(key, "ref="):
+				if result.Ref = unquote(key[len("ref="):]); result.Ref == "" {
+					off, n := at(offset+o.offset, len(key))
+					return false, result, &SyntaxError{Offset: off, Length: n, Option: key,
+						Msg: "option `ref=` is missing its type reference"}
+				}
+			case strings.HasPrefix(key, "example="):
+				if result.Example = unquote(key[len("example="):]); result.Example == "" {
+					off, n := at(offset+o.offset, len(key))
+					return false, result, &SyntaxError{Offset: off, Length: n, Option: key,
+						Msg: "option `example=` is missing its example"}
+				}
+			default:
+				msg := "unknown option `" + key + "`"
+				// A comma within an unquoted example splits it, leaving the rest of it an unknown option.
+				if i > 0 && strings.HasPrefix(options[i-1].text, "example=") &&
+					!strings.HasPrefix(options[i-1].text, "example='") {
+					msg += "; an example containing commas must be quoted, as in `example='a, b'`"
+				}
+				off, n := at(offset+o.offset, len(key))
+				return false, result, &SyntaxError{Offset: off, Length: n, Option: key, Msg: msg}
+			}
+		}
+	}
+
+	return hadTags, result, nil
+}
+
+// Options are the options a `pschema:"..."` tag may have.
+var Options = []string{"optional", "replaces", "in", "out", "secret", "ref=<ref>", "example=<value>"}
+
+// option is an option within a `pschema` tag, as written, and its offset within the tag's options.
+type option struct {
+	text   string
+	offset int
+}
+
+// valueOptions are the options that take values, which may be quoted.
+var valueOptions = []string{"ref=", "example="}
+
+// splitOptions splits a `pschema` tag's options at the commas between them, other than those within quoted values. A
+// problem with a quoted value is an error, whose offset is within the options.
+func splitOptions(opts string) ([]option, *SyntaxError) {
+	var options []option
+	start := 0
+	for start <= len(opts) {
+		end := strings.IndexByte(opts[start:], ',')
+		if end == -1 {
+			end = len(opts)
+		} else {
+			end += start
+		}
+		for _, key := range valueOptions {
+			value := start + len(key)
+			if !strings.HasPrefix(opts[start:], key) || value >= len(opts) || opts[value] != '\'' {
+				continue
+			}
+			quote := closingQuote(opts, value)
+			if quote == -1 {
+				return nil, &SyntaxError{Offset: value, Length: len(opts) - value,
+					Msg: "option `" + key + "` has an unterminated quoted value; close it with `'`, writing any " +
+						"`'` within it as `''`"}
+			} else if quote+1 < len(opts) && opts[quote+1] != ',' {
+				return nil, &SyntaxError{Offset: quote + 1, Length: end - quote - 1,
+					Msg: "option `" + key + "` has text after its quoted value; separate options with commas"}
+			}
+			end = quote + 1
+		}
+		options = append(options, option{text: opts[start:end], offset: start})
+		start = end + 1
+	}
+	return options, nil
+}
+
+// closingQuote returns the index of the quote closing the quoted value starting at the given index, or -1 if there is
+// none. A doubled quote within the value is part of it.
+func closingQuote(s string, start int) int {
+	for i := start + 1; i < len(s); i++ {
+		if s[i] != '\'' {
+			continue
+		} else if i+1 < len(s) && s[i+1] == '\'' {
+			i++
+			continue
+		}
+		return i
+	}
+	return -1
+}
+
+// unquote returns an option's value, less its quotes, if it's quoted, and with any doubled quotes within it undoubled.
+func unquote(value string) string {
+	if len(value) < 2 || value[0] != '\'' || value[len(value)-1] != '\'' {
+		return value
+	}
+	return strings.ReplaceAll(value[1:len(value)-1], "''", "'")
+}
+
+// SplitOptions splits the options of a `pschema` tag, as written, at the commas between them, other than those within
+// quoted values. Rejoining them with commas gives back the tag's options.
+func SplitOptions(opts string) ([]string, error) {
+	options, err := splitOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	texts := make([]string, len(options))
+	for i, o := range options {
+		texts[i] = o.text
+	}
+	return texts, nil
+}
+
+// SyntaxError is returned for a malformed tag, locating the problem within it.
+type SyntaxError struct {
+	Offset int    // the byte offset of the problem within the tag.
+	Length int    // the length of the offending text, such as an unknown option.
+	Option string // the offending option, if the problem is with one.
+	Msg    string // a description of the problem.
+}
+
+func (e *SyntaxError) Error() string {
+	return "malformed tag: " + e.Msg
+}
+
+// lookup returns the value of the given key in a tag, and its offset within the tag, as reflect.StructTag.Lookup
+// does. If the value has escapes, the offset is that of its opening quote instead. If the key is present but isn't of
+// the form `key:"value"`, so that reflect would miss it, it's an error.
+func lookup(tag, key string) (string, int, bool, error) {
+	for rest := tag; rest != ""; {
+		// Skip leading space, and then scan to the colon ending the key, just as reflect does.
+		i := 0
+		for i < len(rest) && rest[i] == ' ' {
+			i++
+		}
+		rest = rest[i:]
+		if rest == "" {
+			break
+		}
+		i = 0
+		for i < len(rest) && rest[i] > ' ' && rest[i] != ':' && rest[i] != '"' && rest[i] != 0x7f {
+			i++
+		}
+		if i == 0 || i+1 >= len(rest) || rest[i] != ':' || rest[i+1] != '"' {
+			break
+		}
+		name := rest[:i]
+		rest = rest[i+1:]
+
+		// Scan the quoted value.
+		i = 1
+		for i < len(rest) && rest[i] != '"' {
+			if rest[i] == '\\' {
+				i++
+			}
+			i++
+		}
+		if i >= len(rest) {
+			break
+		}
+		quoted, start := rest[:i+1], len(tag)-len(rest)
+		rest = rest[i+1:]
+
+		if name == key {
+			value, err := strconv.Unquote(quoted)
+			if err != nil {
+				return "", 0, false, &SyntaxError{Offset: start, Length: len(quoted), Msg: "malformed `" + key +
+					"` value"}
+			}
+			if value == quoted[1:len(quoted)-1] {
+				start++ // without escapes, the value's offsets are those of its source.
+			}
+			return value, start, true, nil
+		}
+	}
+
+	// The key wasn't found, but it's an error if it's there nonetheless, as in `pulumi:name`.
+	for i := strings.Index(tag, key+":"); i != -1; i = indexFrom(tag, key+":", i+1) {
+		if i == 0 || tag[i-1] == ' ' {
+			return "", 0, false, &SyntaxError{Offset: i, Length: len(key) + 1,
+				Msg: "the `" + key + "` tag must be of the form " + key + `:"..."`}
+		}
+	}
+	return "", 0, false, nil
+}
+
+// indexFrom returns the index of the first instance of substr in s at or after from, or -1 if there is none.
+func indexFrom(s, substr string, from int) int {
+	if i := strings.Index(s[from:], substr); i != -1 {
+		return from + i
+	}
+	return -1
+}
+
+// NameFrom returns the name that another framework's tag gives a field, for fields without a `pulumi` tag, and whether
+// the tag names it. The `protobuf` tag's name is its `json=` option, as protojson marshals it, or else its `name=`
+// option; any other tag, such as `json` or `yaml`, names a field by its first comma-separated element, as
+// encoding/json does. A field that the tag leaves out, as with `json:"-"`, is named "-".
+func NameFrom(tag, key string) (string, bool) {
+	value, has := reflect.StructTag(tag).Lookup(key)
+	if !has {
+		return "", false
+	}
+	if key == "protobuf" {
+		var name string
+		for _, opt := range strings.Split(value, ",") {
+			if strings.HasPrefix(opt, "json=") {
+				return opt[len("json="):], true
+			} else if strings.HasPrefix(opt, "name=") {
+				name = opt[len("name="):]
+			}
+		}
+		return name, name != ""
+	}
+	name := strings.SplitN(value, ",", 2)[0]
+	return name, name != ""
+}
+
+// ParseField parses the tags of a struct field obtained through reflection, as a runtime framework would have it.
+func ParseField(field reflect.StructField) (bool, PropertyOptions, error) {
+	return Parse(string(field.Tag))
+}
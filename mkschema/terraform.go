@@ -0,0 +1,367 @@
+package mkschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+)
+
+// TerraformOptions select what TerraformSchema converts from a Terraform provider schema.
+type TerraformOptions struct {
+	// Provider is the provider to convert, by its source address, as in `registry.terraform.io/hashicorp/aws`, or by
+	// its type, as in `aws`. It may be empty if the schema describes only one provider.
+	Provider string
+	// Name is the Pulumi package's name, which defaults to the provider's type.
+	Name string
+	// Resources are patterns, as for path.Match, of the Terraform resource types to convert, such as `aws_s3_*`. If
+	// there are none, every resource is converted.
+	Resources []string
+}
+
+// tfSchemas is a Terraform provider schema, as printed by `terraform providers schema -json`.
+type tfSchemas struct {
+	ProviderSchemas map[string]tfProvider `json:"provider_schemas"`
+}
+
+// tfProvider is the schema of one provider, of which only the resources are converted.
+type tfProvider struct {
+	ResourceSchemas map[string]tfSchema `json:"resource_schemas"`
+}
+
+// tfSchema is the schema of a resource.
+type tfSchema struct {
+	Block tfBlock `json:"block"`
+}
+
+// tfBlock is a configuration block, of attributes and nested blocks.
+type tfBlock struct {
+	Attributes  map[string]tfAttribute `json:"attributes"`
+	BlockTypes  map[string]tfBlockType `json:"block_types"`
+	Description string                 `json:"description"`
+}
+
+// tfAttribute is an attribute of a block, of either a type or, with protocol version 6, a nested type.
+type tfAttribute struct {
+	Type        json.RawMessage `json:"type"`
+	NestedType  *tfNestedType   `json:"nested_type"`
+	Description string          `json:"description"`
+	Required    bool            `json:"required"`
+	Optional    bool            `json:"optional"`
+	Computed    bool            `json:"computed"`
+	Sensitive   bool            `json:"sensitive"`
+}
+
+// tfNestedType is an attribute's nested object type, and how it's nested.
+type tfNestedType struct {
+	Attributes  map[string]tfAttribute `json:"attributes"`
+	NestingMode string                 `json:"nesting_mode"`
+}
+
+// tfBlockType is a block nested within another, and how it's nested.
+type tfBlockType struct {
+	NestingMode string  `json:"nesting_mode"`
+	Block       tfBlock `json:"block"`
+	MinItems    int     `json:"min_items"`
+	MaxItems    int     `json:"max_items"`
+}
+
+// TerraformSchema converts the resources of a Terraform provider schema, as printed by `terraform providers schema
+// -json`, to a Pulumi package schema, to be scaffolded into annotated Go structs by Import, as a start on component
+// wrappers around bridged infrastructure. Each resource becomes a component resource, named after its Terraform type
+// less the provider's prefix, so that `aws_s3_bucket` becomes `S3Bucket`, and each nested block or object type becomes
+// an object type named after the resource and property it's declared in. Attribute and block names are converted to
+// camel case, as the Terraform bridge does.
+//
+// Required attributes are required inputs, optional ones optional inputs, and computed ones outputs; sensitive ones
+// are secret. As in the bridge, a list or set block of at most one item becomes a single object. Terraform's `id` is
+// left out, since every Pulumi resource has one, and tuple and dynamic types become `pulumi.json#/Any`.
+func TerraformSchema(b []byte, opts TerraformOptions) (*schema.PackageSpec, error) {
+	var schemas tfSchemas
+	if err := json.Unmarshal(b, &schemas); err != nil {
+		return nil, errors.Wrap(err, "parsing Terraform provider schema")
+	}
+	source, provider, err := schemas.provider(opts.Provider)
+	if err != nil {
+		return nil, err
+	}
+	typ := source[strings.LastIndex(source, "/")+1:]
+	name := opts.Name
+	if name == "" {
+		name = typ
+	}
+
+	c := &tfConverter{
+		spec:   &schema.PackageSpec{Name: name, Resources: map[string]schema.ResourceSpec{}},
+		tokens: &scaffoldTokens{pkg: name, taken: make(map[string]bool)},
+	}
+	// The resources' names are claimed before any of their types', which are named after them.
+	var selected, tokens []string
+	for _, tfName := range sortedKeys(provider.ResourceSchemas) {
+		if ok, err := tfSelected(tfName, opts.Resources); err != nil {
+			return nil, err
+		} else if ok {
+			selected = append(selected, tfName)
+			tokens = append(tokens, c.tokens.claim(goName(strings.TrimPrefix(tfName, typ+"_"))))
+		}
+	}
+	for i, tfName := range selected {
+		if err := c.resource(tokens[i], tfName, provider.ResourceSchemas[tfName]); err != nil {
+			return nil, errors.Wrapf(err, "converting Terraform resource %s", tfName)
+		}
+	}
+	if len(selected) == 0 {
+		return nil, errors.Errorf("provider %s has no resources matching %s", source,
+			strings.Join(opts.Resources, ", "))
+	}
+	return c.spec, nil
+}
+
+// provider returns the source address and schema of the provider by the given source address or type, or of the only
+// provider, if none is given.
+func (s *tfSchemas) provider(provider string) (string, tfProvider, error) {
+	sources := sortedKeys(s.ProviderSchemas)
+	if provider == "" {
+		if len(sources) != 1 {
+			return "", tfProvider{}, errors.Errorf("the Terraform schema has %d providers, so one must be picked: %s",
+				len(sources), strings.Join(sources, ", "))
+		}
+		provider = sources[0]
+	}
+	for _, source := range sources {
+		if source == provider || strings.HasSuffix(source, "/"+provider) {
+			return source, s.ProviderSchemas[source], nil
+		}
+	}
+	return "", tfProvider{}, errors.Errorf("the Terraform schema has no provider %s; it has %s", provider,
+		strings.Join(sources, ", "))
+}
+
+// tfSelected returns true if a Terraform resource type matches any of the given patterns, or if there are none.
+func tfSelected(tfName string, patterns []string) (bool, error) {
+	for _, pattern := range patterns {
+		matched, err := path.Match(pattern, tfName)
+		if err != nil {
+			return false, errors.Wrapf(err, "bad resource pattern %q", pattern)
+		} else if matched {
+			return true, nil
+		}
+	}
+	return len(patterns) == 0, nil
+}
+
+// tfConverter holds the state of a TerraformSchema conversion.
+type tfConverter struct {
+	spec   *schema.PackageSpec
+	tokens *scaffoldTokens
+}
+
+// resource declares a component resource of the given token from a Terraform resource's schema.
+func (c *tfConverter) resource(tok, tfName string, s tfSchema) error {
+	name := tok[strings.LastIndex(tok, ":")+1:]
+	props, required, err := c.properties(name, s.Block, true)
+	if err != nil {
+		return err
+	}
+	res := schema.ResourceSpec{
+		ObjectTypeSpec: schema.ObjectTypeSpec{
+			Description: fmt.Sprintf("%s wraps the %s Terraform resource.", name, tfName),
+			Type:        "object",
+			Properties:  map[string]schema.PropertySpec{},
+		},
+		InputProperties: map[string]schema.PropertySpec{},
+		IsComponent:     true,
+	}
+	if desc := strings.TrimSpace(s.Block.Description); desc != "" {
+		res.Description += "\n\n" + desc
+	}
+	for _, p := range props {
+		res.Properties[p.name] = p.spec
+		if p.computed || required[p.name] {
+			res.Required = append(res.Required, p.name)
+		}
+		if p.input {
+			res.InputProperties[p.name] = p.spec
+			if required[p.name] {
+				res.RequiredInputs = append(res.RequiredInputs, p.name)
+			}
+		}
+	}
+	c.spec.Resources[tok] = res
+	return nil
+}
+
+// tfProperty is a property converted from an attribute or nested block.
+type tfProperty struct {
+	name     string
+	spec     schema.PropertySpec
+	input    bool // true if the property can be configured, rather than only read back.
+	computed bool // true if the provider always sets the property.
+}
+
+// properties converts a block's attributes and nested blocks to properties, in order, and returns the names of those
+// that are required. Object types for nested blocks are named after the given name and their properties'. The top
+// level of a resource leaves out `id`.
+func (c *tfConverter) properties(name string, block tfBlock, top bool) ([]tfProperty, map[string]bool, error) {
+	var props []tfProperty
+	required := make(map[string]bool)
+	for _, attr := range sortedKeys(block.Attributes) {
+		if top && attr == "id" {
+			continue
+		}
+		a := block.Attributes[attr]
+		p := tfProperty{name: camelName(attr), input: a.Required || a.Optional, computed: a.Computed}
+		t, err := c.attributeType(name+goName(p.name), a)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "attribute %s", attr)
+		}
+		p.spec = schema.PropertySpec{TypeSpec: t, Description: strings.TrimSpace(a.Description), Secret: a.Sensitive}
+		required[p.name] = a.Required
+		props = append(props, p)
+	}
+	for _, nested := range sortedKeys(block.BlockTypes) {
+		bt := block.BlockTypes[nested]
+		p := tfProperty{name: camelName(nested), input: true}
+		tok, err := c.objectType(name+goName(p.name), bt.Block.Description, "the "+nested+" block of "+name,
+			bt.Block)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "block %s", nested)
+		}
+		t, err := tfNest(bt.NestingMode, bt.MaxItems, schema.TypeSpec{Ref: "#/types/" + tok})
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "block %s", nested)
+		}
+		p.spec = schema.PropertySpec{TypeSpec: t, Description: strings.TrimSpace(bt.Block.Description)}
+		required[p.name] = bt.MinItems > 0
+		props = append(props, p)
+	}
+	return props, required, nil
+}
+
+// objectType declares an object type of the given name from a block, returning its token. Without a description,
+// it's described as what it is, as in "the versioning block of S3Bucket".
+func (c *tfConverter) objectType(name, description, what string, block tfBlock) (string, error) {
+	tok := c.tokens.claim(name)
+	if strings.TrimSpace(description) == "" {
+		description = tok[strings.LastIndex(tok, ":")+1:] + " is " + what + "."
+	}
+	props, required, err := c.properties(name, block, false)
+	if err != nil {
+		return "", err
+	}
+	obj := schema.ObjectTypeSpec{
+		Description: strings.TrimSpace(description),
+		Type:        "object",
+		Properties:  map[string]schema.PropertySpec{},
+	}
+	for _, p := range props {
+		obj.Properties[p.name] = p.spec
+		if required[p.name] {
+			obj.Required = append(obj.Required, p.name)
+		}
+	}
+	if c.spec.Types == nil {
+		c.spec.Types = map[string]schema.ComplexTypeSpec{}
+	}
+	c.spec.Types[tok] = schema.ComplexTypeSpec{ObjectTypeSpec: obj}
+	return tok, nil
+}
+
+// attributeType converts an attribute's type, declaring object types of the given name for any object types within
+// it.
+func (c *tfConverter) attributeType(name string, a tfAttribute) (schema.TypeSpec, error) {
+	if a.NestedType == nil {
+		return c.ctyType(name, a.Type)
+	}
+	block := tfBlock{Attributes: a.NestedType.Attributes}
+	tok, err := c.objectType(name, "", "the type of an attribute's nested objects", block)
+	if err != nil {
+		return schema.TypeSpec{}, err
+	}
+	return tfNest(a.NestedType.NestingMode, 0, schema.TypeSpec{Ref: "#/types/" + tok})
+}
+
+// ctyType converts a type in the JSON form of Terraform's type system, such as `"string"` or `["list","string"]`.
+func (c *tfConverter) ctyType(name string, raw json.RawMessage) (schema.TypeSpec, error) {
+	var primitive string
+	if err := json.Unmarshal(raw, &primitive); err == nil {
+		switch primitive {
+		case "string":
+			return schema.TypeSpec{Type: "string"}, nil
+		case "number":
+			return schema.TypeSpec{Type: "number"}, nil
+		case "bool":
+			return schema.TypeSpec{Type: "boolean"}, nil
+		case "dynamic":
+			return schema.TypeSpec{Ref: "pulumi.json#/Any"}, nil
+		}
+		return schema.TypeSpec{}, errors.Errorf("unknown type %q", primitive)
+	}
+
+	var kind string
+	var compound []json.RawMessage
+	err := json.Unmarshal(raw, &compound)
+	if err != nil || len(compound) != 2 || json.Unmarshal(compound[0], &kind) != nil {
+		return schema.TypeSpec{}, errors.Errorf("malformed type %s", raw)
+	}
+	switch kind {
+	case "list", "set", "map":
+		elem, err := c.ctyType(name, compound[1])
+		if err != nil {
+			return schema.TypeSpec{}, err
+		}
+		if kind == "map" {
+			return schema.TypeSpec{Type: "object", AdditionalProperties: &elem}, nil
+		}
+		return schema.TypeSpec{Type: "array", Items: &elem}, nil
+	case "object":
+		var types map[string]json.RawMessage
+		if err := json.Unmarshal(compound[1], &types); err != nil {
+			return schema.TypeSpec{}, errors.Errorf("malformed object type %s", raw)
+		}
+		// An object type's attributes are neither required nor optional, so they're all left optional.
+		block := tfBlock{Attributes: make(map[string]tfAttribute, len(types))}
+		for attr, t := range types {
+			block.Attributes[attr] = tfAttribute{Type: t, Optional: true}
+		}
+		tok, err := c.objectType(name, "", "the type of an attribute's objects", block)
+		if err != nil {
+			return schema.TypeSpec{}, err
+		}
+		return schema.TypeSpec{Ref: "#/types/" + tok}, nil
+	case "tuple":
+		return schema.TypeSpec{Ref: "pulumi.json#/Any"}, nil
+	}
+	return schema.TypeSpec{}, errors.Errorf("unknown type %q", kind)
+}
+
+// tfNest nests an object type as a block or nested attribute is, by its nesting mode. A list or set of at most one
+// item is flattened to the item itself.
+func tfNest(mode string, maxItems int, t schema.TypeSpec) (schema.TypeSpec, error) {
+	switch mode {
+	case "single", "group":
+		return t, nil
+	case "list", "set":
+		if maxItems == 1 {
+			return t, nil
+		}
+		return schema.TypeSpec{Type: "array", Items: &t}, nil
+	case "map":
+		return schema.TypeSpec{Type: "object", AdditionalProperties: &t}, nil
+	}
+	return schema.TypeSpec{}, errors.Errorf("unknown nesting mode %q", mode)
+}
+
+// camelName converts a Terraform snake case name to camel case, as in `bucket_prefix` becoming `bucketPrefix`.
+func camelName(name string) string {
+	parts := strings.Split(name, "_")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
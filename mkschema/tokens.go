@@ -0,0 +1,71 @@
+package mkschema
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/types"
+
+	"github.com/pkg/errors"
+)
+
+// TokenConstants generates the source of a Go file, to be added to the model's package, that declares a constant for
+// the schema token of each gathered resource, type, and enum, named after its Go type:
+//
+//	const BucketToken = "mypkg:index:Bucket"
+//
+// so that a provider's Construct dispatch and its tests can refer to tokens symbolically, rather than with string
+// literals that drift from the schema.
+func (m *Model) TokenConstants() ([]byte, error) {
+	type token struct {
+		Object *types.TypeName
+		Token  string
+	}
+	groups := []struct {
+		kind   string
+		tokens []token
+	}{{kind: "resource"}, {kind: "type"}, {kind: "enum"}}
+	for _, r := range m.Resources {
+		groups[0].tokens = append(groups[0].tokens, token{r.Object, r.Token})
+	}
+	for _, t := range m.Types {
+		groups[1].tokens = append(groups[1].tokens, token{t.Object, t.Token})
+	}
+	for _, e := range m.Enums {
+		groups[2].tokens = append(groups[2].tokens, token{e.Object, e.Token})
+	}
+
+	var pkg *types.Package
+	for _, group := range groups {
+		for _, t := range group.tokens {
+			pkg = t.Object.Pkg()
+		}
+	}
+	if pkg == nil {
+		return nil, errors.Errorf("package %s has no resources or types to declare tokens for", m.Package)
+	}
+
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "// Code generated by pulumi-mkschema; DO NOT EDIT.\n\npackage %s\n", pkg.Name())
+	for _, group := range groups {
+		if len(group.tokens) == 0 {
+			continue
+		}
+		fmt.Fprintf(&src, "\nconst (\n")
+		for i, t := range group.tokens {
+			name := t.Object.Name()
+			if i > 0 {
+				fmt.Fprintf(&src, "\n")
+			}
+			fmt.Fprintf(&src, "\t// %sToken is the schema token of the %s %s.\n", name, t.Object.Name(), group.kind)
+			fmt.Fprintf(&src, "\t%sToken = %q\n", name, t.Token)
+		}
+		fmt.Fprintf(&src, ")\n")
+	}
+
+	out, err := format.Source(src.Bytes())
+	if err != nil {
+		return nil, errors.Wrapf(err, "formatting token constants")
+	}
+	return out, nil
+}
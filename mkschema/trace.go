@@ -0,0 +1,52 @@
+package mkschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/types"
+	"strings"
+
+	"github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+)
+
+// traceStep records a step taken resolving the current field's type, if tracing or recording provenance.
+func (g *generator) traceStep(format string, args ...interface{}) {
+	if g.Trace != nil || g.provenance {
+		g.steps = append(g.steps, fmt.Sprintf(format, args...))
+	}
+}
+
+// traceRef records where the reference emitted for a named type came from, if tracing or recording provenance.
+func (g *generator) traceRef(t *types.Named, opts PropertyOptions, ref string) {
+	switch {
+	case g.Trace == nil && !g.provenance:
+	case opts.Ref != "":
+		g.traceStep("referenced by the field's `ref=` option")
+	case g.Mappings[t.String()] == ref:
+		g.traceStep("referenced by the mapping for %v", t)
+	default:
+		g.traceStep("referenced within this package")
+	}
+}
+
+// traceResolve writes the steps taken resolving a field's type, ending with the schema type it resolved to, or why
+// it was rejected, if tracing.
+func (g *generator) traceResolve(t *types.TypeName, fld *types.Var, spec *schema.TypeSpec, err error) {
+	if g.Trace == nil {
+		return
+	}
+	steps := append([]string(nil), g.steps...)
+	if err != nil {
+		steps = append(steps, "rejected: "+err.Error())
+	} else if b, jerr := json.Marshal(spec); jerr == nil {
+		steps = append(steps, string(b))
+	}
+	pos := g.Fset.Position(fld.Pos())
+	fmt.Fprintf(g.Trace, "%s:%d:%d: %v.%v: %s\n", pos.Filename, pos.Line, pos.Column, t.Name(), fld.Name(),
+		strings.Join(steps, " → "))
+}
+
+// typeString prints a Go type as it's written within the target package, qualifying types from other packages.
+func (g *generator) typeString(t types.Type) string {
+	return types.TypeString(t, types.RelativeTo(g.Pkg))
+}
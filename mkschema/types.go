@@ -1,6 +1,6 @@
 // Copyright 2016-2017, Pulumi Corporation.  All rights reserved.
 
-package main
+package mkschema
 
 import (
 	"go/types"
@@ -81,7 +81,7 @@ func pkgMatch(pkg string, match string) bool {
 }
 
 func IsSpecial(obj *types.TypeName) (bool, SpecialType) {
-	if obj != nil && pkgMatch(obj.Pkg().Path(), idlResourceType.PkgPath()) {
+	if obj != nil && obj.Pkg() != nil && pkgMatch(obj.Pkg().Path(), idlResourceType.PkgPath()) {
 		switch obj.Name() {
 		case idlArchiveType.Name():
 			return true, SpecialArchiveType
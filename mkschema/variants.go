@@ -0,0 +1,100 @@
+package mkschema
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+)
+
+// Variant is a variant of a package's schema, generated from the files selected by a set of build tags, such as a
+// preview schema whose extra types are in files constrained by `//go:build pulumi_preview`.
+type Variant struct {
+	Name string   // the variant's name, such as `stable` or `preview`.
+	Tags []string // the build tags that select the variant's files, on top of any in the options' build flags.
+}
+
+// ParseVariant parses a variant given as `NAME=TAGS`, where the tags are comma-separated and may be empty, as in
+// `preview=pulumi_preview` or `stable=`.
+func ParseVariant(s string) (Variant, error) {
+	eq := strings.IndexByte(s, '=')
+	if eq <= 0 {
+		return Variant{}, errors.Errorf("malformed variant %q; expected NAME=TAGS", s)
+	}
+	return Variant{Name: s[:eq], Tags: appendTags(nil, s[eq+1:])}, nil
+}
+
+// GenerateVariants generates a schema for each variant, keyed by name, by loading the package once per variant with
+// the variant's build tags added to those in the options. A diagnostic found in several variants, such as one in a
+// file they share, is only reported once. In best-effort mode, the schemas are returned alongside a *PartialError
+// covering everything skipped in any variant.
+func GenerateVariants(ctx context.Context, opts Options, variants []Variant) (map[string]*schema.PackageSpec, error) {
+	opts = opts.clone()
+	sink, seen := opts.Diagnostics, make(map[string]bool)
+	if sink != nil {
+		opts.Diagnostics = func(d Diagnostic) {
+			key := fmt.Sprintf("%v\x00%v\x00%s\x00%s", d.Severity, d.Pos, d.Code, d.Message)
+			if !seen[key] {
+				seen[key] = true
+				sink(d)
+			}
+		}
+	}
+
+	specs := make(map[string]*schema.PackageSpec, len(variants))
+	partial := &PartialError{}
+	for _, v := range variants {
+		if _, has := specs[v.Name]; has {
+			return nil, errors.Errorf("variant %s is given more than once", v.Name)
+		}
+		vopts := opts
+		vopts.BuildFlags = withBuildTags(opts.BuildFlags, v.Tags)
+		spec, err := Generate(ctx, vopts)
+		var perr *PartialError
+		if errors.As(err, &perr) {
+			partial.Failures = append(partial.Failures, perr.Failures...)
+		} else if err != nil {
+			return nil, errors.Wrapf(err, "generating variant %s", v.Name)
+		}
+		specs[v.Name] = spec
+	}
+	if len(partial.Failures) > 0 {
+		return specs, partial
+	}
+	return specs, nil
+}
+
+// withBuildTags returns build flags with the given tags added to any `-tags` flag among them, since the go command
+// only heeds the last one.
+func withBuildTags(flags, tags []string) []string {
+	if len(tags) == 0 {
+		return flags
+	}
+	var res, all []string
+	for i := 0; i < len(flags); i++ {
+		switch f := flags[i]; {
+		case f == "-tags" || f == "--tags":
+			if i+1 < len(flags) {
+				all = appendTags(all, flags[i+1])
+				i++
+			}
+		case strings.HasPrefix(f, "-tags=") || strings.HasPrefix(f, "--tags="):
+			all = appendTags(all, f[strings.IndexByte(f, '=')+1:])
+		default:
+			res = append(res, f)
+		}
+	}
+	return append(res, "-tags="+strings.Join(append(all, tags...), ","))
+}
+
+// appendTags appends the tags in a comma-separated list to tags.
+func appendTags(tags []string, list string) []string {
+	for _, tag := range strings.Split(list, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
@@ -0,0 +1,183 @@
+package mkschema
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	pbempty "github.com/golang/protobuf/ptypes/empty"
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/rpcutil"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+	"google.golang.org/grpc"
+)
+
+// SchemaDifference records a place where two schemas disagree.
+type SchemaDifference struct {
+	Path      string      // the JSON pointer to the differing value, such as `#/resources/pkg:index:Bucket`.
+	Generated interface{} // the value in the generated schema, or nil if it has none.
+	Provider  interface{} // the value in the provider's schema, or nil if it has none.
+}
+
+func (d SchemaDifference) String() string {
+	switch {
+	case d.Provider == nil:
+		return fmt.Sprintf("%s: missing from the provider's schema", d.Path)
+	case d.Generated == nil:
+		return fmt.Sprintf("%s: only in the provider's schema", d.Path)
+	}
+	return fmt.Sprintf("%s: generated %s, but the provider has %s", d.Path, jsonString(d.Generated),
+		jsonString(d.Provider))
+}
+
+func jsonString(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(b)
+}
+
+// DiffSchemas structurally compares a generated schema with one returned by a provider, returning every difference,
+// sorted by path. Schemas that serialize to the same JSON don't differ, regardless of the order of their keys.
+func DiffSchemas(generated, provider *schema.PackageSpec) ([]SchemaDifference, error) {
+	var a, b interface{}
+	for _, s := range []struct {
+		spec *schema.PackageSpec
+		v    *interface{}
+	}{{generated, &a}, {provider, &b}} {
+		j, err := json.Marshal(s.spec)
+		if err != nil {
+			return nil, errors.Wrapf(err, "serializing schema to JSON")
+		}
+		if err = json.Unmarshal(j, s.v); err != nil {
+			return nil, errors.Wrapf(err, "deserializing schema from JSON")
+		}
+	}
+
+	var diffs []SchemaDifference
+	diffValues("#", a, b, &diffs)
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Path < diffs[j].Path })
+	return diffs, nil
+}
+
+// diffValues appends the differences between two JSON values, at the given path, to diffs.
+func diffValues(path string, a, b interface{}, diffs *[]SchemaDifference) {
+	switch a := a.(type) {
+	case map[string]interface{}:
+		if b, ok := b.(map[string]interface{}); ok {
+			for k, av := range a {
+				diffValues(path+"/"+escapePointer(k), av, b[k], diffs)
+			}
+			for k, bv := range b {
+				if _, has := a[k]; !has {
+					diffValues(path+"/"+escapePointer(k), nil, bv, diffs)
+				}
+			}
+			return
+		}
+	case []interface{}:
+		if b, ok := b.([]interface{}); ok && len(a) == len(b) {
+			for i := range a {
+				diffValues(path+"/"+strconv.Itoa(i), a[i], b[i], diffs)
+			}
+			return
+		}
+	}
+	if !reflect.DeepEqual(a, b) {
+		*diffs = append(*diffs, SchemaDifference{Path: path, Generated: a, Provider: b})
+	}
+}
+
+// escapePointer escapes a key for use in a JSON pointer.
+func escapePointer(k string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(k, "~", "~0"), "/", "~1")
+}
+
+// ProviderSchema launches the provider plugin at path, as the engine would, and returns the schema that its GetSchema
+// returns. The provider is shut down afterwards. It's connected to a stand-in for the engine, which discards anything
+// the provider logs.
+func ProviderSchema(ctx context.Context, path string) (*schema.PackageSpec, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Serve the stand-in engine, whose address the provider expects as its argument.
+	stop := make(chan bool)
+	enginePort, engineDone, err := rpcutil.Serve(0, stop, []func(*grpc.Server) error{
+		func(srv *grpc.Server) error {
+			pulumirpc.RegisterEngineServer(srv, &standInEngine{})
+			return nil
+		},
+	}, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "serving the engine")
+	}
+	defer func() {
+		close(stop)
+		<-engineDone
+	}()
+
+	// Launch the provider, which writes the port it's listening on to stdout.
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, path, fmt.Sprintf("127.0.0.1:%d", enginePort))
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err = cmd.Start(); err != nil {
+		return nil, errors.Wrapf(err, "launching provider %s", path)
+	}
+	defer func() {
+		cancel()
+		_ = cmd.Wait()
+	}()
+	failed := func(err error, msg string) error {
+		if out := strings.TrimSpace(stderr.String()); out != "" {
+			return errors.Wrapf(err, "%s: %s", msg, out)
+		}
+		return errors.Wrap(err, msg)
+	}
+
+	line, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil {
+		return nil, failed(err, "reading the provider's port")
+	}
+	port, err := strconv.Atoi(strings.TrimSpace(line))
+	if err != nil {
+		return nil, failed(err, "reading the provider's port")
+	}
+
+	conn, err := grpc.DialContext(ctx, fmt.Sprintf("127.0.0.1:%d", port), grpc.WithInsecure())
+	if err != nil {
+		return nil, failed(err, "connecting to the provider")
+	}
+	defer conn.Close()
+	resp, err := pulumirpc.NewResourceProviderClient(conn).GetSchema(ctx, &pulumirpc.GetSchemaRequest{})
+	if err != nil {
+		return nil, failed(err, "getting the provider's schema")
+	}
+
+	var spec schema.PackageSpec
+	if err = json.Unmarshal([]byte(resp.GetSchema()), &spec); err != nil {
+		return nil, errors.Wrapf(err, "parsing the provider's schema")
+	}
+	return &spec, nil
+}
+
+// standInEngine stands in for the engine while a provider is queried, discarding its logs.
+type standInEngine struct {
+	pulumirpc.UnimplementedEngineServer
+}
+
+func (*standInEngine) Log(context.Context, *pulumirpc.LogRequest) (*pbempty.Empty, error) {
+	return &pbempty.Empty{}, nil
+}
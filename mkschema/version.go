@@ -0,0 +1,63 @@
+package mkschema
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+)
+
+// BuildVersion is the package version to use when nothing else sets one. It's meant to be injected when building a
+// tool that generates a particular package's schema, with:
+//
+//	go build -ldflags "-X github.com/pulumi/pulumi-mkschema/mkschema.BuildVersion=1.2.3"
+var BuildVersion string
+
+// GitVersion returns a package version describing the git checkout at dir, from `git describe`: the most recent tag,
+// without any leading `v`, followed by the number of commits since it and the abbreviated commit hash, if the checkout
+// isn't at the tag, and `-dirty`, if it has uncommitted changes.
+func GitVersion(ctx context.Context, dir string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", "describe", "--tags", "--dirty")
+	cmd.Dir = dir
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", errors.Errorf("describing git version: %s", msg)
+		}
+		return "", errors.Wrapf(err, "describing git version")
+	}
+	return strings.TrimPrefix(strings.TrimSpace(string(out)), "v"), nil
+}
+
+// StampPluginJSON writes the schema's name, version, and plugin download URL into the pulumi-plugin.json file at
+// path, creating it if it doesn't exist yet.
+func StampPluginJSON(path string, spec *schema.PackageSpec) error {
+	desc := plugin.PulumiPluginJSON{Resource: true}
+	b, err := ioutil.ReadFile(path)
+	switch {
+	case err == nil:
+		if err = json.Unmarshal(b, &desc); err != nil {
+			return errors.Wrapf(err, "parsing %s", path)
+		}
+	case !os.IsNotExist(err):
+		return err
+	}
+
+	desc.Name = spec.Name
+	desc.Version = strings.TrimPrefix(spec.Version, "v")
+	if spec.PluginDownloadURL != "" {
+		desc.Server = spec.PluginDownloadURL
+	}
+	if b, err = desc.JSON(); err != nil {
+		return errors.Wrapf(err, "serializing %s", path)
+	}
+	return ioutil.WriteFile(path, append(b, '\n'), 0600)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+
+	"github.com/pulumi/pulumi-mkschema/mkschema"
+)
+
+// packageMain implements the `package` subcommand, which assembles a schema and the provider's binaries into the
+// layout that `pulumi plugin install` and `pulumi package publish` expect.
+func packageMain(args []string) {
+	flags := flag.NewFlagSet("package", flag.ExitOnError)
+	out := flags.String("out", "dist", "the directory to assemble the package in")
+	readme := flags.String("readme", "",
+		"the README to include (defaults to one written from the schema's description)")
+	binaries := make(mapFlag)
+	flags.Var(binaries, "bin", "package a provider binary for a platform, as `OS-ARCH=PATH` (repeatable)")
+	flags.Usage = func() {
+		log.Printf("usage: package [FLAGS] [SCHEMA-JSON]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		log.Fatalf("error: %v", err)
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	spec := readSchema(flags.Arg(0))
+	opts := mkschema.PackageOptions{OutDir: *out, Binaries: binaries}
+	if *readme != "" {
+		b, err := ioutil.ReadFile(*readme)
+		if err != nil {
+			log.Fatalf("error: reading README: %v", err)
+		}
+		opts.Readme = string(b)
+	}
+
+	written, err := mkschema.Package(spec, opts)
+	if err != nil {
+		log.Fatalf("error: %v", err)
+	}
+	for _, path := range written {
+		fmt.Println(path)
+	}
+}
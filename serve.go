@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+
+	pbempty "github.com/golang/protobuf/ptypes/empty"
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/rpcutil"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+	"google.golang.org/grpc"
+
+	"github.com/pulumi/pulumi-mkschema/mkschema"
+)
+
+// serveMain implements the `serve` subcommand, which serves a package's schema over the Pulumi provider gRPC
+// interface, regenerating it from the Go source on every request. The engine launches providers with its own flags and
+// the address of its host appended, so whatever follows the package arguments is ignored, unparsed, since the engine
+// may pass any flags of its own, such as `--logflow`.
+func serveMain(args []string) {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	options := optionsFlags(flags)
+	flags.Usage = func() {
+		log.Printf("usage: serve [FLAGS] [PULUMI-PKG-NAME] [GO-SOURCE-PKG] [ENGINE-ARGS...]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		log.Fatalf("error: %v", err)
+	}
+	if flags.NArg() < 2 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	prov := &schemaProvider{opts: options(flags.Arg(0), flags.Arg(1))}
+	port, done, err := rpcutil.Serve(0, nil, []func(*grpc.Server) error{
+		func(srv *grpc.Server) error {
+			pulumirpc.RegisterResourceProviderServer(srv, prov)
+			return nil
+		},
+	}, nil)
+	if err != nil {
+		log.Fatalf("error: serving: %v", err)
+	}
+
+	// The provider protocol requires that the chosen port be the first thing written to stdout.
+	fmt.Printf("%d\n", port)
+	if err = <-done; err != nil {
+		log.Fatalf("error: serving: %v", err)
+	}
+}
+
+// schemaProvider is a schema-only resource provider: it can describe its package, but not manage any resources.
+type schemaProvider struct {
+	pulumirpc.UnimplementedResourceProviderServer
+
+	opts mkschema.Options // the options to generate the schema with.
+	mu   sync.Mutex       // serializes generation, which loads packages with the go command.
+}
+
+// generate regenerates the schema, so that it reflects the Go source as it is now. In best-effort mode, anything
+// skipped is reported to stderr, and the partial schema is served.
+func (p *schemaProvider) generate(ctx context.Context) ([]byte, string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	opts := p.opts
+	opts.Diagnostics = func(d mkschema.Diagnostic) {
+		if d.Severity == mkschema.SeverityWarning {
+			fmt.Fprintf(os.Stderr, "%s\n\n", d.Render())
+		}
+	}
+	sch, err := mkschema.Generate(ctx, opts)
+	var partial *mkschema.PartialError
+	if err != nil && !errors.As(err, &partial) {
+		return nil, "", err
+	}
+	b, err := json.Marshal(sch)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "serializing schema to JSON")
+	}
+	return b, sch.Version, nil
+}
+
+// GetPluginInfo returns the provider's version.
+func (p *schemaProvider) GetPluginInfo(ctx context.Context, _ *pbempty.Empty) (*pulumirpc.PluginInfo, error) {
+	if v := p.opts.Metadata.Version; v != "" {
+		return &pulumirpc.PluginInfo{Version: v}, nil
+	}
+	_, version, err := p.generate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &pulumirpc.PluginInfo{Version: version}, nil
+}
+
+// GetSchema regenerates and returns the package's schema.
+func (p *schemaProvider) GetSchema(ctx context.Context,
+	req *pulumirpc.GetSchemaRequest) (*pulumirpc.GetSchemaResponse, error) {
+	if v := req.GetVersion(); v != 0 {
+		return nil, errors.Errorf("unsupported schema version %d", v)
+	}
+	b, _, err := p.generate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &pulumirpc.GetSchemaResponse{Schema: string(b)}, nil
+}
+
+// CheckConfig accepts any configuration, since the provider never uses it.
+func (p *schemaProvider) CheckConfig(_ context.Context, req *pulumirpc.CheckRequest) (*pulumirpc.CheckResponse, error) {
+	return &pulumirpc.CheckResponse{Inputs: req.GetNews()}, nil
+}
+
+// DiffConfig reports that configuration changes never matter.
+func (p *schemaProvider) DiffConfig(context.Context, *pulumirpc.DiffRequest) (*pulumirpc.DiffResponse, error) {
+	return &pulumirpc.DiffResponse{}, nil
+}
+
+// Configure accepts any configuration.
+func (p *schemaProvider) Configure(context.Context,
+	*pulumirpc.ConfigureRequest) (*pulumirpc.ConfigureResponse, error) {
+	return &pulumirpc.ConfigureResponse{}, nil
+}
+
+// Cancel has nothing to cancel.
+func (p *schemaProvider) Cancel(context.Context, *pbempty.Empty) (*pbempty.Empty, error) {
+	return &pbempty.Empty{}, nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+
+	"github.com/pkg/errors"
+
+	"github.com/pulumi/pulumi-mkschema/mkschema"
+)
+
+// verifyMain implements the `verify` subcommand, which checks that a built provider serves the schema that would be
+// generated now, catching binaries that embed a stale schema.
+func verifyMain(args []string) {
+	flags := flag.NewFlagSet("verify", flag.ExitOnError)
+	options := optionsFlags(flags)
+	provider := flags.String("provider", "", "the provider plugin binary to verify (required)")
+	flags.Usage = func() {
+		log.Printf("usage: verify -provider PATH [FLAGS] [PULUMI-PKG-NAME] [GO-SOURCE-PKG]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		log.Fatalf("error: %v", err)
+	}
+	if flags.NArg() != 2 || *provider == "" {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	opts := options(flags.Arg(0), flags.Arg(1))
+	opts.Diagnostics = func(d mkschema.Diagnostic) {
+		if d.Severity == mkschema.SeverityWarning {
+			fmt.Fprintf(os.Stderr, "%s\n", d)
+		}
+	}
+	generated, err := mkschema.Generate(ctx, opts)
+	var partial *mkschema.PartialError
+	if err != nil && !errors.As(err, &partial) {
+		log.Fatalf("error: %s", err.Error())
+	}
+	served, err := mkschema.ProviderSchema(ctx, *provider)
+	if err != nil {
+		log.Fatalf("error: %s", err.Error())
+	}
+
+	diffs, err := mkschema.DiffSchemas(generated, served)
+	if err != nil {
+		log.Fatalf("error: %s", err.Error())
+	}
+	if len(diffs) > 0 {
+		for _, d := range diffs {
+			fmt.Fprintf(os.Stderr, "error: %s\n", d)
+		}
+		log.Fatalf("error: %s serves a stale schema (%d differences)", *provider, len(diffs))
+	}
+}